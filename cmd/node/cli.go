@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"reservechain/internal/config"
+	storepkg "reservechain/internal/store"
+)
+
+// Defaults for the paths the node reads from the project checkout and the
+// ones it writes per node. Flags override the environment, which overrides
+// these and the config file.
+const (
+	defaultConfigPath = "config/devnet.yaml"
+	defaultSchemaPath = "database/schema.sql"
+	defaultKeyFile    = "runtime/node_key.json"
+	defaultSQLitePath = "runtime/chain_devnet.sqlite"
+)
+
+// nodeFlags are the options shared by the subcommands that act on a node.
+type nodeFlags struct {
+	configPath string
+	dataDir    string
+	listen     string
+	peers      string
+	schemaPath string
+}
+
+// register adds the shared flags to fs. withNet adds -listen and -peers,
+// which only matter to commands that start the node.
+func (o *nodeFlags) register(fs *flag.FlagSet, withNet bool) {
+	fs.StringVar(&o.configPath, "config", envOr("RESERVECHAIN_CONFIG", defaultConfigPath),
+		"config file (env RESERVECHAIN_CONFIG)")
+	fs.StringVar(&o.dataDir, "data-dir", os.Getenv("RESERVECHAIN_DATA_DIR"),
+		"directory relative key, database and log file paths resolve against (env RESERVECHAIN_DATA_DIR; default: working directory)")
+	fs.StringVar(&o.schemaPath, "schema", envOr("RESERVECHAIN_SCHEMA", defaultSchemaPath),
+		"SQL schema applied to the database (env RESERVECHAIN_SCHEMA)")
+	if withNet {
+		fs.StringVar(&o.listen, "listen", os.Getenv("RESERVECHAIN_LISTEN"),
+			"HTTP listen address, overrides node.rpc.http_listen (env RESERVECHAIN_LISTEN)")
+		fs.StringVar(&o.peers, "peers", os.Getenv("RESERVECHAIN_PEERS"),
+			"comma-separated peer URLs, replaces node.peers (env RESERVECHAIN_PEERS)")
+	}
+}
+
+// load reads the config file and applies the overrides: -listen and
+// -peers replace their settings, and the key, database and log file paths
+// get their defaults and are resolved against -data-dir.
+func (o *nodeFlags) load() (*config.NodeConfig, error) {
+	cfg, err := config.Load(o.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	if o.listen != "" {
+		cfg.Node.RPC.HTTPListen = o.listen
+	}
+	if o.peers != "" {
+		cfg.Node.Peers = nil
+		for _, p := range strings.Split(o.peers, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				cfg.Node.Peers = append(cfg.Node.Peers, p)
+			}
+		}
+	}
+
+	if cfg.Node.KeyFile == "" {
+		cfg.Node.KeyFile = defaultKeyFile
+	}
+	if cfg.Node.DB.SQLitePath == "" {
+		cfg.Node.DB.SQLitePath = defaultSQLitePath
+	}
+	cfg.Node.KeyFile = o.dataPath(cfg.Node.KeyFile)
+	cfg.Node.DB.SQLitePath = o.dataPath(cfg.Node.DB.SQLitePath)
+	for i, s := range cfg.Logging.Sinks {
+		if s.Output != "" && s.Output != "stderr" && s.Output != "stdout" {
+			cfg.Logging.Sinks[i].Output = o.dataPath(s.Output)
+		}
+	}
+	return cfg, nil
+}
+
+// dataPath resolves a relative path against the data directory.
+func (o *nodeFlags) dataPath(p string) string {
+	if o.dataDir == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(o.dataDir, p)
+}
+
+// openDB opens the node's SQLite database and applies the schema.
+func (o *nodeFlags) openDB(cfg *config.NodeConfig) (*storepkg.DB, error) {
+	db, err := openSQLite(cfg.Node.DB.SQLitePath)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", cfg.Node.DB.SQLitePath, err)
+	}
+	if err := storepkg.EnsureSchemaFromFile(db, o.schemaPath); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("apply %s: %w", o.schemaPath, err)
+	}
+	return db, nil
+}
+
+// openSQLite opens the database at path, creating its directory first so
+// a fresh data directory works.
+func openSQLite(path string) (*storepkg.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return storepkg.OpenSQLite(path)
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// errUsage makes main print the usage of the failing command and exit 2.
+var errUsage = errors.New("usage")
+
+// newFlagSet returns a flag set for a subcommand whose usage lists the
+// synopsis and its flags.
+func newFlagSet(name, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: node %s\n\nFlags:\n", synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args into fs and checks that exactly nargs positional
+// arguments remain. Parse errors have already been printed with the usage.
+func parseFlags(fs *flag.FlagSet, args []string, nargs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() != nargs {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
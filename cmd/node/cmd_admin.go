@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"reservechain/internal/identity"
+)
+
+// cmdInit prepares a node's data: the data directory, the node key (an
+// existing one is kept) and the database with the schema applied. It is
+// safe to run again.
+func cmdInit(args []string) error {
+	var o nodeFlags
+	fs := newFlagSet("init", "init [flags]")
+	o.register(fs, false)
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	cfg, err := o.load()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("%s:\n%w", o.configPath, err)
+	}
+	if o.dataDir != "" {
+		if err := os.MkdirAll(o.dataDir, 0o755); err != nil {
+			return err
+		}
+	}
+	key, err := identity.LoadOrCreateNodeKey(cfg.Node.KeyFile)
+	if err != nil {
+		return fmt.Errorf("node key: %w", err)
+	}
+	db, err := o.openDB(cfg)
+	if err != nil {
+		return err
+	}
+	if err := db.Close(); err != nil {
+		return err
+	}
+	printKey(cfg.Node.KeyFile, key)
+	fmt.Printf("database    %s\n", cfg.Node.DB.SQLitePath)
+	return nil
+}
+
+// cmdReset deletes the node database, including SQLite's side files, and
+// with -keys the node key. The node must not be running.
+func cmdReset(args []string) error {
+	var o nodeFlags
+	fs := newFlagSet("reset", "reset [flags]")
+	o.register(fs, false)
+	yes := fs.Bool("yes", false, "delete without this safety stop")
+	keys := fs.Bool("keys", false, "also delete the node key; the node gets a new identity")
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	cfg, err := o.load()
+	if err != nil {
+		return err
+	}
+
+	db := cfg.Node.DB.SQLitePath
+	candidates := []string{db, db + "-wal", db + "-shm", db + "-journal"}
+	if *keys {
+		candidates = append(candidates, cfg.Node.KeyFile)
+	}
+	var paths []string
+	for _, p := range candidates {
+		if _, err := os.Stat(p); err == nil {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) == 0 {
+		fmt.Println("nothing to delete")
+		return nil
+	}
+	if !*yes {
+		for _, p := range paths {
+			fmt.Printf("would delete %s\n", p)
+		}
+		return errors.New("rerun with -yes to delete")
+	}
+	for _, p := range paths {
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+		fmt.Printf("deleted %s\n", p)
+	}
+	return nil
+}
+
+// cmdConfig runs "config validate": load the config with the command-line
+// and environment overrides applied and report every invalid setting.
+func cmdConfig(args []string) error {
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Fprintln(os.Stderr, "usage: node config validate [flags]")
+		return errUsage
+	}
+	var o nodeFlags
+	fs := newFlagSet("config validate", "config validate [flags]")
+	o.register(fs, true)
+	if err := parseFlags(fs, args[1:], 0); err != nil {
+		return err
+	}
+	cfg, err := o.load()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("%s:\n%w", o.configPath, err)
+	}
+	fmt.Printf("%s: ok\n", o.configPath)
+	return nil
+}
+
+// cmdKeys runs "keys show", which prints the node identity, and "keys
+// generate", which creates the node key (replacing one only with -force).
+func cmdKeys(args []string) error {
+	sub := ""
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	if sub != "show" && sub != "generate" {
+		fmt.Fprintln(os.Stderr, "usage: node keys show|generate [flags]")
+		return errUsage
+	}
+	var o nodeFlags
+	fs := newFlagSet("keys "+sub, "keys "+sub+" [flags]")
+	o.register(fs, false)
+	force := false
+	if sub == "generate" {
+		fs.BoolVar(&force, "force", false, "replace an existing key; the node gets a new identity")
+	}
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	cfg, err := o.load()
+	if err != nil {
+		return err
+	}
+	path := cfg.Node.KeyFile
+
+	if sub == "show" {
+		key, err := identity.LoadNodeKey(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no node key at %s; run \"node keys generate\" or \"node init\"", path)
+		} else if err != nil {
+			return err
+		}
+		printKey(path, key)
+		return nil
+	}
+
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s exists; use -force to replace it", path)
+	}
+	key, err := identity.GenerateNodeKey(path)
+	if err != nil {
+		return err
+	}
+	printKey(path, key)
+	return nil
+}
+
+func printKey(path string, key *identity.NodeKey) {
+	fmt.Printf("key file    %s\n", path)
+	fmt.Printf("node id     %s\n", key.ID())
+	fmt.Printf("public key  %s\n", key.PublicKeyHex())
+}
@@ -1,22 +1,124 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"reservechain/internal/config"
 	"reservechain/internal/core"
 	"reservechain/internal/econ"
+	"reservechain/internal/identity"
+	"reservechain/internal/logging"
 	"reservechain/internal/net"
 	storepkg "reservechain/internal/store"
 )
 
+// shutdownTimeout bounds how long a signalled node waits for HTTP requests
+// and background loops to finish before closing the database.
+const shutdownTimeout = 15 * time.Second
+
+var nodeLog = logging.For("node")
+
+// command is a node subcommand. run returns errUsage after printing the
+// usage for bad arguments.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"run", "start the node (default)", cmdRun},
+	{"init", "create the data directory, node key and database", cmdInit},
+	{"export-chain", "write the block log as JSON lines", cmdExportChain},
+	{"import-chain", "load blocks written by export-chain into the database", cmdImportChain},
+	{"verify-chain", "check the stored block log's linkage and proof of work", cmdVerifyChain},
+	{"reset", "delete the node database (and optionally its key)", cmdReset},
+	{"config", "config validate: check a config file", cmdConfig},
+	{"keys", "keys show|generate: manage the node key", cmdKeys},
+}
+
+// main runs the subcommand named by the first argument. Without one, or
+// when the first argument is a flag, the node starts as "run".
 func main() {
-	cfg, err := config.Load("config/devnet.yaml")
+	args := os.Args[1:]
+	name := "run"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		usage(os.Stdout)
+		return
+	}
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		err := c.run(args)
+		switch {
+		case err == nil:
+		case errors.Is(err, flag.ErrHelp):
+		case errors.Is(err, errUsage):
+			os.Exit(2)
+		default:
+			fmt.Fprintf(os.Stderr, "node %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "node: unknown command %q\n\n", name)
+	usage(os.Stderr)
+	os.Exit(2)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: node <command> [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-13s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(w, "\nRun \"node <command> -h\" for a command's flags.\n")
+}
+
+// cmdRun starts the node and blocks until SIGINT/SIGTERM or a fatal
+// server error, then shuts down gracefully.
+func cmdRun(args []string) error {
+	var o nodeFlags
+	fs := newFlagSet("run", "run [flags]")
+	o.register(fs, true)
+	if err := parseFlags(fs, args, 0); err != nil {
+		return err
+	}
+	cfg, err := o.load()
 	if err != nil {
-		log.Fatalf("load config: %v", err)
+		return err
+	}
+	if err := logging.Setup(loggingOptions(cfg.Logging)); err != nil {
+		return fmt.Errorf("logging: %w", err)
+	}
+	defer logging.Close()
+
+	// SIGINT/SIGTERM cancel ctx, which every background loop runs under.
+	// A second signal kills the process without waiting.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var wg sync.WaitGroup
+	run := func(loop func(context.Context)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			loop(ctx)
+		}()
 	}
 
 	// Enable seed mode for this node if configured.
@@ -24,12 +126,33 @@ func main() {
 		net.EnableSeedMode(true)
 	}
 
-	nodeID := cfg.Node.ID
-	allNodes := []string{nodeID}
+	chainID := cfg.Node.ChainID
+	if chainID == "" {
+		network := cfg.Node.Network
+		if network == "" {
+			network = "devnet"
+		}
+		chainID = "reservechain-" + network
+	}
+
+	// Node identity: a persisted ed25519 key; the node ID is derived from it.
+	nodeKey, err := identity.LoadOrCreateNodeKey(cfg.Node.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load node key: %w", err)
+	}
+	net.SetLocalNodeInfo(nodeKey, chainID)
+	nodeLog.Info("identity loaded", "node", cfg.Node.ID, "identity", nodeKey.ID(), "chain", chainID)
+
+	nodeID := nodeKey.ID()
 
 	// Core services
-	leaderSel := net.NewLeaderSelector(allNodes)
-	wsHub := net.NewWSHub()
+	wsHub := net.NewWSHubWithOptions(net.WSHubOptions{
+		SendQueue:    cfg.Node.RPC.WSSendQueue,
+		SlowConsumer: net.SlowConsumerPolicy(cfg.Node.RPC.WSSlowConsumer),
+	})
+	// Valuation ticks are emitted by one elected node and relayed by the rest.
+	leaderSel := net.NewLeaderSelector(nodeID, wsHub)
+	net.SetLeaderSelector(leaderSel)
 	store := core.NewAccountStore()
 	core.SeedDemoBalances(store)
 
@@ -41,55 +164,99 @@ func main() {
 
 	// Open DevNet SQLite database (optional; logs if missing schema).
 	dbPath := cfg.Node.DB.SQLitePath
-	if dbPath == "" {
-		dbPath = "runtime/chain_devnet.sqlite"
-	}
-	sqldb, dberr := storepkg.OpenSQLite(dbPath)
+	sqldb, dberr := openSQLite(dbPath)
 	if dberr == nil && sqldb != nil {
-		if err := storepkg.EnsureSchemaFromFile(sqldb, "database/schema.sql"); err != nil {
-			log.Printf("[node] warning: could not apply schema.sql automatically: %v", err)
+		if err := storepkg.EnsureSchemaFromFile(sqldb, o.schemaPath); err != nil {
+			nodeLog.Warn("could not apply schema.sql automatically", logging.Err(err))
+		}
+		if n, err := sqldb.IndexChainTx(context.Background()); err != nil {
+			nodeLog.Warn("address index backfill failed", logging.Err(err))
+		} else if n > 0 {
+			nodeLog.Info("indexed chain txs by address", "count", n)
 		}
 	}
 	// Construct chain engine once DB is available so it can replay or persist.
 	chain = core.NewChain(store, sqldb)
+	snapKeep := cfg.Snapshots.Keep
+	if snapKeep <= 0 {
+		snapKeep = 3
+	}
+	chain.SetSnapshotPolicy(cfg.Snapshots.IntervalBlocks, snapKeep)
+	// Record the econ epoch of each block for epoch-end state queries.
+	chain.SetEpochSource(econ.CurrentDevnetEpoch)
 	// Wire chain + DB into econ so DevNet epoch settlement can credit payouts.
 	econ.SetRuntime(chain, sqldb)
 
 	// Heartbeat miner (produces EMPTY blocks when enabled)
 	miner := core.NewMiner(chain, 5*time.Second)
 	if dberr != nil {
-		log.Printf("[node] warning: could not open SQLite DB: %v", dberr)
+		nodeLog.Warn("could not open SQLite DB", "path", dbPath, logging.Err(dberr))
 	}
-	defer func() {
-		if sqldb != nil {
-			_ = sqldb.Close()
-		}
-	}()
 
 	listenAddr := cfg.Node.RPC.HTTPListen
 	if listenAddr == "" {
 		listenAddr = ":8080"
 	}
 
+	// Sequence and persist every event so clients can resume after reconnecting.
+	journal := net.NewEventJournal(sqldb, time.Duration(cfg.Events.RetentionHours)*time.Hour, cfg.Events.MemoryBuffer)
+	wsHub.AttachJournal(journal)
+	run(func(ctx context.Context) { journal.RunPruner(ctx, 10*time.Minute) })
+
+	isDevnet := cfg.Node.Network == "" || cfg.Node.Network == "devnet"
+	if cfg.Auth.DevnetOpenWrites {
+		if isDevnet {
+			nodeLog.Warn("auth.devnet_open_writes: anonymous writes are accepted")
+		} else {
+			nodeLog.Warn("auth.devnet_open_writes ignored", "network", cfg.Node.Network)
+		}
+	}
+	net.SetDevnetOpenWrites(cfg.Auth.DevnetOpenWrites && isDevnet)
+	net.SetBootstrapAdmins(cfg.Auth.Admins)
+	net.SetAllowedOrigins(cfg.Node.RPC.AllowedOrigins)
+	net.SetSessionPolicy(time.Duration(cfg.Auth.SessionIdleHours)*time.Hour,
+		time.Duration(cfg.Auth.SessionMaxDays)*24*time.Hour)
+
+	if cfg.RateLimit.Enabled {
+		classes := make(map[string]net.RateLimitClass, len(cfg.RateLimit.Classes))
+		for name, c := range cfg.RateLimit.Classes {
+			classes[name] = net.RateLimitClass{Rate: c.Rate, Burst: c.Burst}
+		}
+		net.SetRateLimits(&net.RateLimitOptions{
+			Classes:         classes,
+			TierMultipliers: cfg.RateLimit.TierMultipliers,
+		})
+	}
+
 	httpServer := net.NewHTTPServer(listenAddr, wsHub, store, wm, sqldb, chain, miner)
 
-	// Start HTTP + WS server
-	go wsHub.Run()
+	// Trading terminal channels follow live engine data; the synthetic
+	// generator is opt-in and devnet-only.
+	terminalDemo := cfg.Node.RPC.TerminalDemo
+	if terminalDemo && !isDevnet {
+		nodeLog.Warn("rpc.terminal_demo ignored", "network", cfg.Node.Network)
+		terminalDemo = false
+	}
+	terminal := net.NewTerminalFeed(wsHub, chain, terminalDemo)
+	net.SetTerminalFeed(terminal)
+	run(terminal.Run)
+
+	// Start HTTP + WS server. If it cannot serve, the node shuts down.
+	run(wsHub.Run)
 	go func() {
-		log.Printf("HTTP/WS server on %s", listenAddr)
+		nodeLog.Info("HTTP/WS server listening", "addr", listenAddr)
 		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal(err)
+			nodeLog.Error("http server failed", logging.Err(err))
+			stop()
 		}
 	}()
 
-	// Optional follower sync loop: if this node is configured with an
-	// upstream URL, it will act as a HTTP follower and mirror the block
-	// log from that peer. This is a DevNet-friendly way to run multiple
-	// nodes without full P2P wiring yet.
-	if cfg.Node.FollowUpstreamURL != "" {
-		log.Printf("[node] starting follower loop against %s", cfg.Node.FollowUpstreamURL)
-		follower := net.NewChainFollower(chain, sqldb, cfg.Node.FollowUpstreamURL, 3*time.Second)
-		go follower.Run()
+	leaderSel.AttachDB(sqldb)
+	run(leaderSel.Run)
+
+	// Seeds keep re-probing registered peers so only live ones are advertised.
+	if cfg.P2P.Mode == "seed" {
+		run(func(ctx context.Context) { net.RunSeedProber(ctx, 1*time.Minute) })
 	}
 
 	// Cluster v2 P2P-style peer sync:
@@ -108,11 +275,11 @@ func main() {
 		} else {
 			selfBase = "http://" + selfBase
 		}
-		discoveredPeers = net.DiscoverPeersFromSeeds(cfg.P2P.SeedNodes, selfBase, cfg.P2P.MaxPeers)
+		discoveredPeers = net.DiscoverPeersFromSeeds(sqldb, cfg.P2P.SeedNodes, selfBase, cfg.P2P.MaxPeers)
 		if len(discoveredPeers) == 0 {
-			log.Printf("[node] seed discovery returned no peers, falling back to static peers only")
+			nodeLog.Warn("seed discovery returned no peers, falling back to static peers only")
 		} else {
-			log.Printf("[node] discovered %d peer(s) from seed registry", len(discoveredPeers))
+			nodeLog.Info("discovered peers from seed registry", "peers", len(discoveredPeers))
 		}
 	}
 
@@ -129,6 +296,9 @@ func main() {
 		if _, ok := seen[p]; !ok {
 			seen[p] = struct{}{}
 			allPeers = append(allPeers, p)
+			if err := sqldb.UpsertPeer(context.Background(), p, storepkg.PeerSourceStatic); err != nil {
+				nodeLog.Warn("could not persist static peer", "peer", p, logging.Err(err))
+			}
 		}
 	}
 	for _, p := range discoveredPeers {
@@ -141,35 +311,109 @@ func main() {
 		}
 	}
 
+	// A node with an empty chain restores the newest verified peer snapshot
+	// first, so block sync only has to fetch blocks after it.
+	if cfg.Snapshots.SyncFromPeers && chain.Height() == 0 {
+		sources := allPeers
+		if cfg.Node.FollowUpstreamURL != "" {
+			sources = append([]string{cfg.Node.FollowUpstreamURL}, sources...)
+		}
+		if len(sources) > 0 {
+			opts := net.SnapshotSyncOptions{
+				Quorum:           cfg.Snapshots.Quorum,
+				MaxSize:          cfg.Snapshots.MaxSizeMB << 20,
+				CheckpointHeight: cfg.Snapshots.TrustedCheckpoint.Height,
+				CheckpointHash:   cfg.Snapshots.TrustedCheckpoint.BlockHash,
+			}
+			if err := net.SyncFromSnapshot(chain, sources, opts); err != nil {
+				nodeLog.Warn("snapshot sync skipped", logging.Err(err))
+			}
+		}
+	}
+
+	// Optional follower sync loop: if this node is configured with an
+	// upstream URL, it will act as a HTTP follower and mirror the block
+	// log from that peer. This is a DevNet-friendly way to run multiple
+	// nodes without full P2P wiring yet.
+	if cfg.Node.FollowUpstreamURL != "" {
+		nodeLog.Info("starting follower loop", "upstream", cfg.Node.FollowUpstreamURL)
+		follower := net.NewChainFollower(chain, sqldb, cfg.Node.FollowUpstreamURL, 3*time.Second)
+		run(follower.Run)
+	}
+
 	if len(allPeers) > 0 {
-		log.Printf("[node] starting peer sync against %d peer(s)", len(allPeers))
+		nodeLog.Info("starting peer sync", "peers", len(allPeers))
 		ps := net.NewPeerSync(chain, sqldb, allPeers, 5*time.Second)
-		go ps.Run()
+		run(ps.Run)
+	}
+
+	runValuationTicks(ctx, leaderSel, nodeID, wm)
+	stop()
+
+	// Shutdown: fail readiness, stop producing blocks, drain HTTP, wait for
+	// the background loops (which also closed the stream clients), then
+	// close the DB once nothing writes to it any more.
+	nodeLog.Info("shutting down")
+	net.SetDraining()
+	miner.Stop()
+	drainCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(drainCtx); err != nil {
+		nodeLog.Error("http shutdown failed", logging.Err(err))
 	}
+	loopsDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(loopsDone)
+	}()
+	select {
+	case <-loopsDone:
+	case <-drainCtx.Done():
+		nodeLog.Warn("background loops still running", "after", shutdownTimeout)
+	}
+	if err := sqldb.Close(); err != nil {
+		nodeLog.Error("close db failed", logging.Err(err))
+	}
+	nodeLog.Info("stopped")
+	return nil
+}
 
-	// Periodic valuation ticks
+// runValuationTicks emits valuation ticks every second until ctx is done.
+// Tick IDs are unix seconds so every node maps a tick to the same leader
+// slot; only the elected leader computes and signs the tick.
+func runValuationTicks(ctx context.Context, leaderSel *net.LeaderSelector, nodeID string, wm *econ.WindowManager) {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
-	var tickID uint64
-	for range ticker.C {
-		tickID++
+	for {
+		var now time.Time
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+		tickID := uint64(now.Unix())
 		leaderID := leaderSel.LeaderForTick(tickID)
 		if leaderID != nodeID {
 			continue
 		}
-		val := econ.ComputeDevnetTick(tickID, leaderID, wm, time.Now().UTC())
-		ev := net.Event{
-			ID:        makeTickEventID(tickID),
-			Type:      net.EventValuationTick,
-			Version:   "v1",
-			Payload:   val,
-			Timestamp: time.Now().UTC(),
+		val := econ.ComputeDevnetTick(tickID, leaderID, wm, now.UTC())
+		ev := net.NewEvent(makeTickEventID(tickID), net.ValuationTickPayload(val), now.UTC())
+		if err := leaderSel.PublishTick(tickID, ev); err != nil {
+			nodeLog.Error("publish tick failed", "tick", tickID, logging.Err(err))
 		}
-		wsHub.Broadcast(ev)
 	}
 }
 
+// loggingOptions maps the logging section of the node config.
+func loggingOptions(c config.LoggingSettings) logging.Options {
+	opts := logging.Options{Level: c.Level, Components: c.Components}
+	for _, s := range c.Sinks {
+		opts.Sinks = append(opts.Sinks, logging.Sink{Output: s.Output, Format: s.Format, Level: s.Level})
+	}
+	return opts
+}
+
 func makeTickEventID(tickID uint64) string {
-	return "tick-" + time.Now().Format("20060102150405")
+	return "tick-" + strconv.FormatUint(tickID, 10)
 }
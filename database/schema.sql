@@ -388,7 +388,8 @@ CREATE TABLE IF NOT EXISTS chain_blocks (
     timestamp   DATETIME NOT NULL,
     tx_type     TEXT NOT NULL,
     nonce       INTEGER NOT NULL,
-    difficulty  INTEGER NOT NULL
+    difficulty  INTEGER NOT NULL,
+    state_root  TEXT                -- post-state root committed in the PoW header
 );
 
 CREATE TABLE IF NOT EXISTS chain_tx (
@@ -403,6 +404,31 @@ CREATE TABLE IF NOT EXISTS chain_tx (
 CREATE UNIQUE INDEX IF NOT EXISTS idx_chain_tx_hash  ON chain_tx(tx_hash);
 CREATE INDEX IF NOT EXISTS idx_chain_tx_block       ON chain_tx(block_height);
 
+-- State snapshots (accounts, staking, PoP registry, econ) taken at fixed
+-- block intervals. Served to fresh nodes in chunks; data is verified
+-- against the state_root of the block at the same height.
+CREATE TABLE IF NOT EXISTS state_snapshots (
+    height      INTEGER PRIMARY KEY,
+    block_hash  TEXT NOT NULL,
+    state_root  TEXT NOT NULL,
+    data        BLOB NOT NULL,      -- JSON-encoded snapshot sections
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Event journal: every event pushed to /ws and the SSE stream, with a
+-- monotonically increasing sequence so clients can resume after a
+-- reconnect. Rows older than the configured retention are pruned.
+CREATE TABLE IF NOT EXISTS event_journal (
+    seq         INTEGER PRIMARY KEY,
+    event_id    TEXT NOT NULL,
+    event_type  TEXT NOT NULL,
+    version     TEXT NOT NULL,
+    payload     TEXT NOT NULL,      -- JSON payload
+    created_at  TEXT NOT NULL       -- RFC3339Nano event timestamp
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_journal_created ON event_journal(created_at);
+
 -- Optional typed transaction tables for fast queries. These are
 -- non-canonical projections over chain_tx and can be rebuilt from
 -- the chain log if needed.
@@ -436,6 +462,41 @@ CREATE TABLE IF NOT EXISTS chain_tx_tier (
     FOREIGN KEY (tx_id) REFERENCES chain_tx(id) ON DELETE CASCADE
 );
 
+-- Address index: one row per address a tx touches (sender, receiver,
+-- vault pseudo-address, staker, operator). Backs /api/address/{addr}/txs.
+CREATE TABLE IF NOT EXISTS chain_tx_addresses (
+    tx_id        INTEGER NOT NULL,
+    address      TEXT NOT NULL COLLATE NOCASE,
+    role         TEXT NOT NULL,
+    block_height INTEGER NOT NULL,
+    tx_type      TEXT NOT NULL,
+    PRIMARY KEY (tx_id, address, role),
+    FOREIGN KEY (tx_id) REFERENCES chain_tx(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_chain_tx_addresses_addr ON chain_tx_addresses(address, tx_id);
+
+-- Per-block account state diffs: the post-block balance of every
+-- address/asset pair a block changed. The balance at height H is the row
+-- with the greatest block_height <= H.
+CREATE TABLE IF NOT EXISTS account_balance_history (
+    address      TEXT NOT NULL,
+    asset        TEXT NOT NULL,
+    block_height INTEGER NOT NULL,
+    balance      REAL NOT NULL,
+    PRIMARY KEY (address, asset, block_height)
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_balance_history_height ON account_balance_history(block_height);
+
+-- Econ epoch each block was sealed in, for "state at the end of epoch E".
+CREATE TABLE IF NOT EXISTS chain_block_epochs (
+    height  INTEGER PRIMARY KEY,
+    epoch   INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_chain_block_epochs_epoch ON chain_block_epochs(epoch, height);
+
 
 ----------------------------------------------------------------------
 -- Node operators: work accounting and rewards
@@ -503,6 +564,7 @@ CREATE TABLE IF NOT EXISTS pop_nodes (
     node_id         TEXT UNIQUE NOT NULL,
     operator_wallet TEXT NOT NULL,            -- canonical wallet id
     role            TEXT,
+    node_pubkey     TEXT,                     -- hex ed25519 key the node_id is derived from
     tx_hash         TEXT, -- optional: chain tx hash for idempotent replay
     created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at      DATETIME,
@@ -593,3 +655,79 @@ CREATE TABLE IF NOT EXISTS slashing_events (
 );
 CREATE INDEX IF NOT EXISTS idx_slashing_events_epoch ON slashing_events(epoch);
 CREATE INDEX IF NOT EXISTS idx_slashing_events_subject ON slashing_events(subject_type, subject_id);
+
+-- -------------------- p2p peer registry --------------------
+-- Seed-registered and discovered peers, persisted so a node can bootstrap
+-- from its last known peer set when every configured seed is down.
+CREATE TABLE IF NOT EXISTS p2p_peers (
+    addr            TEXT PRIMARY KEY,       -- HTTP base URL, e.g. http://127.0.0.1:8081
+    node_id         TEXT,                   -- derived from pubkey (rcn1...)
+    pubkey          TEXT,                   -- hex ed25519 node key from the signed handshake
+    version         TEXT,
+    chain_id        TEXT,
+    source          TEXT NOT NULL,          -- 'registered' | 'discovered' | 'static' | 'seed'
+    status          TEXT NOT NULL DEFAULT 'pending', -- 'pending' | 'live' | 'unreachable'
+    failures        INTEGER NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    last_seen       TEXT,                   -- RFC3339, last successful handshake
+    last_probe      TEXT,                   -- RFC3339, last probe attempt
+    created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at      DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_p2p_peers_status ON p2p_peers(status, last_seen);
+
+-- -------------------- role grants --------------------
+-- Roles held by wallet identities beyond the implicit "client" role.
+-- Managed through the admin-only /api/admin/roles endpoints; every access
+-- decision on a role-guarded route is written to audit_events.
+CREATE TABLE IF NOT EXISTS role_grants (
+    identity        TEXT NOT NULL,          -- canonical wallet identity, e.g. rc:... / evm:0x...
+    role            TEXT NOT NULL,          -- 'operator' | 'treasury' | 'admin'
+    granted_by      TEXT NOT NULL,          -- identity of the granting admin, or 'config'
+    created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (identity, role)
+);
+CREATE INDEX IF NOT EXISTS idx_role_grants_role ON role_grants(role);
+
+-- -------------------- wallet auth --------------------
+-- Login challenges (one per identity, single use) and sessions, persisted so
+-- sessions survive restarts and can be shared by nodes on the same DB.
+-- Sessions are keyed by the SHA-256 of their token; the token itself is
+-- never stored. Expired rows are pruned in the background.
+CREATE TABLE IF NOT EXISTS auth_challenges (
+    identity        TEXT PRIMARY KEY,       -- canonical wallet identity
+    challenge       TEXT NOT NULL,
+    expires_at      TEXT NOT NULL           -- fixed-width RFC3339Nano (UTC)
+);
+
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    id              TEXT PRIMARY KEY,       -- hex sha256 of the session token
+    identity        TEXT NOT NULL,
+    role            TEXT NOT NULL,
+    device_name     TEXT,                   -- client-supplied label
+    user_agent      TEXT,
+    ip              TEXT,
+    created_at      TEXT NOT NULL,
+    last_seen_at    TEXT NOT NULL,
+    expires_at      TEXT NOT NULL,          -- sliding; pushed out on use
+    max_expires_at  TEXT NOT NULL           -- absolute lifetime cap
+);
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_identity ON auth_sessions(identity);
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_expires ON auth_sessions(expires_at);
+
+-- -------------------- API keys --------------------
+-- Bearer keys created by a wallet session for bots and scripts. The key
+-- is "rck_<id>_<secret>"; only the SHA-256 of the whole key is stored.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id              TEXT PRIMARY KEY,       -- public key id (hex)
+    key_hash        TEXT NOT NULL,          -- hex sha256 of the full key
+    identity        TEXT NOT NULL,          -- owning wallet identity
+    name            TEXT NOT NULL,
+    scopes          TEXT NOT NULL,          -- comma-separated: read,trade,transfer,staking,operator
+    allowed_ips     TEXT NOT NULL DEFAULT '', -- comma-separated IPs/CIDRs; empty = any
+    created_at      TEXT NOT NULL,
+    expires_at      TEXT,                   -- NULL = no expiry
+    last_used_at    TEXT,
+    revoked_at      TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_identity ON api_keys(identity);
@@ -1,7 +1,12 @@
 package config
 
 import (
+    "errors"
+    "fmt"
+    "net"
+    "net/url"
     "os"
+    "strings"
 
     "gopkg.in/yaml.v3"
 )
@@ -19,6 +24,20 @@ const (
 type RPCSettings struct {
     HTTPListen string `yaml:"http_listen"`
     WSPath     string `yaml:"ws_path"`
+
+    // WebSocket delivery: per-client queue length and what to do when a
+    // client falls behind ("drop" events or "disconnect" the client).
+    WSSendQueue    int    `yaml:"ws_send_queue"`
+    WSSlowConsumer string `yaml:"ws_slow_consumer"`
+
+    // AllowedOrigins are browser origins ("https://app.example") other
+    // than the node's own that may open /rpc, /ws, /ws/terminal and the
+    // event stream.
+    AllowedOrigins []string `yaml:"allowed_origins"`
+
+    // TerminalDemo streams synthetic data on /ws/terminal instead of the
+    // live engine feed. Honoured on devnet only.
+    TerminalDemo bool `yaml:"terminal_demo"`
 }
 
 // DBSettings controls persistence for the chain log and related state.
@@ -47,9 +66,98 @@ type P2PSettings struct {
     AllowExternal bool    `yaml:"allow_external"`
 }
 
+// SnapshotSettings controls periodic state snapshots and whether a fresh
+// node bootstraps from a peer snapshot instead of replaying every block.
+type SnapshotSettings struct {
+    IntervalBlocks uint64 `yaml:"interval_blocks"` // 0 disables snapshot production
+    Keep           int    `yaml:"keep"`            // how many snapshots to retain
+    SyncFromPeers  bool   `yaml:"sync_from_peers"` // fresh nodes restore from a peer snapshot
+
+    // A peer snapshot is only restored when its anchor block matches
+    // TrustedCheckpoint or, without one, when Quorum peers (and a majority
+    // of the peers that answered) report the same block at its height.
+    Quorum            int                `yaml:"quorum"`      // default 2
+    MaxSizeMB         int                `yaml:"max_size_mb"` // largest snapshot accepted, default 256
+    TrustedCheckpoint SnapshotCheckpoint `yaml:"trusted_checkpoint"`
+}
+
+// SnapshotCheckpoint pins the block a restored snapshot must be taken at.
+type SnapshotCheckpoint struct {
+    Height    uint64 `yaml:"height"`
+    BlockHash string `yaml:"block_hash"`
+}
+
+// EventSettings controls the event journal behind /ws and the SSE stream.
+type EventSettings struct {
+    RetentionHours int `yaml:"retention_hours"` // how long events stay resumable
+    MemoryBuffer   int `yaml:"memory_buffer"`   // newest events kept in memory
+}
+
+// AuthSettings controls who may call write and privileged endpoints.
+type AuthSettings struct {
+    // DevnetOpenWrites accepts writes without a wallet session. Honoured
+    // on devnet only; sessions that are present are still checked.
+    DevnetOpenWrites bool `yaml:"devnet_open_writes"`
+    // Admins are wallet identities ("rc:<addr>", "evm:0x...") holding the
+    // admin role without a stored grant, so a fresh node can grant roles.
+    Admins []string `yaml:"admins"`
+    // SessionIdleHours expires a session after this long without use
+    // (default 24). SessionMaxDays caps a session's lifetime from login,
+    // refreshes included (default 30).
+    SessionIdleHours int `yaml:"session_idle_hours"`
+    SessionMaxDays   int `yaml:"session_max_days"`
+}
+
+// RateLimitClass is a token bucket: Rate requests per second, bursting to
+// Burst.
+type RateLimitClass struct {
+    Rate  float64 `yaml:"rate"`
+    Burst int     `yaml:"burst"`
+}
+
+// RateLimitSettings throttles API callers per route class (auth, write,
+// read, sim, rpc, peer). Callers are keyed by API key, session identity or
+// IP; tier multipliers scale the limits of wallets with an active tier.
+type RateLimitSettings struct {
+    Enabled         bool                      `yaml:"enabled"`
+    Classes         map[string]RateLimitClass `yaml:"classes"`
+    TierMultipliers map[string]float64        `yaml:"tier_multipliers"`
+}
+
+// LogSink is one log destination: Output is "stderr", "stdout" or a file
+// path (appended to), Format is "text" or "json", and Level drops records
+// below it for this sink only.
+type LogSink struct {
+    Output string `yaml:"output"`
+    Format string `yaml:"format"`
+    Level  string `yaml:"level"`
+}
+
+// LoggingSettings controls structured logging. Level is the node-wide
+// minimum (debug, info, warn, error); Components overrides it per
+// component tag ("chain", "p2p", "http", ...). Without sinks, logs go to
+// stderr as text.
+type LoggingSettings struct {
+    Level      string            `yaml:"level"`
+    Components map[string]string `yaml:"components"`
+    Sinks      []LogSink         `yaml:"sinks"`
+}
+
+// LightClientSettings are the trust anchors of a light client following
+// this network: the genesis block hash, and the minimum difficulty a
+// header must claim (0 = the protocol floor). Raise MinDifficulty above
+// what honest blocks can retarget to and the client rejects the chain.
+type LightClientSettings struct {
+    GenesisHash   string `yaml:"genesis_hash"`
+    MinDifficulty uint32 `yaml:"min_difficulty"`
+}
+
 // NodeSettings configures the behaviour of a single DevNet node.
 type NodeSettings struct {
     ID                  string        `yaml:"id"`
+    Network             string        `yaml:"network"`  // devnet / testnet / mainnet
+    ChainID             string        `yaml:"chain_id"` // peers must match this in the p2p handshake
+    KeyFile             string        `yaml:"key_file"` // ed25519 node key; node identity is derived from it
     HostPublicUI        bool          `yaml:"host_web_interface"`
     HostFinancePlatform bool          `yaml:"host_finance_platform"`
     FollowUpstreamURL   string        `yaml:"follow_upstream_url"`
@@ -107,6 +215,14 @@ type NodeConfig struct {
     Yield    YieldSettings   `yaml:"yield"`
     Rewards  RewardsSettings `yaml:"rewards"`
     P2P      P2PSettings     `yaml:"p2p"`
+
+    Snapshots SnapshotSettings  `yaml:"snapshots"`
+    Events    EventSettings     `yaml:"events"`
+    Auth      AuthSettings      `yaml:"auth"`
+    RateLimit RateLimitSettings `yaml:"rate_limit"`
+    Logging   LoggingSettings   `yaml:"logging"`
+
+    LightClient LightClientSettings `yaml:"light_client"`
 }
 
 // Load reads a YAML configuration file and unmarshals it into NodeConfig.
@@ -121,3 +237,120 @@ func Load(path string) (*NodeConfig, error) {
     }
     return cfg, nil
 }
+
+// Validate reports every setting that the node would reject or silently
+// ignore, joined into one error. Paths are not checked: they may be
+// created by the node or made relative to a data directory.
+func (c *NodeConfig) Validate() error {
+    var errs []error
+    bad := func(format string, args ...interface{}) {
+        errs = append(errs, fmt.Errorf(format, args...))
+    }
+
+    if !oneOf(c.Node.Network, "", "devnet", "testnet", "mainnet") {
+        bad("node.network: unknown network %q", c.Node.Network)
+    }
+    if addr := c.Node.RPC.HTTPListen; addr != "" {
+        if _, _, err := net.SplitHostPort(addr); err != nil {
+            bad("node.rpc.http_listen: %v", err)
+        }
+    }
+    if !oneOf(c.Node.RPC.WSSlowConsumer, "", "drop", "disconnect") {
+        bad("node.rpc.ws_slow_consumer: want drop or disconnect, got %q", c.Node.RPC.WSSlowConsumer)
+    }
+    if c.Node.RPC.WSSendQueue < 0 {
+        bad("node.rpc.ws_send_queue: must not be negative")
+    }
+    for _, o := range c.Node.RPC.AllowedOrigins {
+        if u, err := url.Parse(o); err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+            bad("node.rpc.allowed_origins: %q is not a scheme://host[:port] origin", o)
+        }
+    }
+    if !oneOf(c.Node.DB.Backend, "", "sqlite") {
+        bad("node.db.backend: unsupported backend %q", c.Node.DB.Backend)
+    }
+    for _, p := range c.Node.Peers {
+        if strings.TrimSpace(p) == "" {
+            bad("node.peers: empty peer address")
+        }
+    }
+
+    if !oneOf(c.P2P.Mode, "", "seed", "peer") {
+        bad("p2p.mode: want seed or peer, got %q", c.P2P.Mode)
+    }
+    if c.P2P.MaxPeers < 0 {
+        bad("p2p.max_peers: must not be negative")
+    }
+
+    if !oneOf(string(c.Windows.Mode), "", string(WindowFixed), string(WindowTriggered), string(WindowBoth)) {
+        bad("windows.mode: unknown mode %q", c.Windows.Mode)
+    }
+    if c.Windows.MinWindowSeconds > 0 && c.Windows.MaxWindowSeconds > 0 &&
+        c.Windows.MinWindowSeconds > c.Windows.MaxWindowSeconds {
+        bad("windows: min_window_seconds exceeds max_window_seconds")
+    }
+
+    w := c.Rewards.WorkWeights
+    if w.Consensus < 0 || w.Network < 0 || w.Storage < 0 || w.Service < 0 {
+        bad("rewards.work_weights: weights must not be negative")
+    }
+
+    if c.Snapshots.Keep < 0 {
+        bad("snapshots.keep: must not be negative")
+    }
+    if c.Snapshots.Quorum < 0 || c.Snapshots.MaxSizeMB < 0 {
+        bad("snapshots: quorum and max_size_mb must not be negative")
+    }
+    if cp := c.Snapshots.TrustedCheckpoint; (cp.Height == 0) != (cp.BlockHash == "") {
+        bad("snapshots.trusted_checkpoint: set both height and block_hash, or neither")
+    }
+    if c.Events.RetentionHours < 0 || c.Events.MemoryBuffer < 0 {
+        bad("events: retention_hours and memory_buffer must not be negative")
+    }
+    if c.Auth.SessionIdleHours < 0 || c.Auth.SessionMaxDays < 0 {
+        bad("auth: session_idle_hours and session_max_days must not be negative")
+    }
+
+    for name, cl := range c.RateLimit.Classes {
+        if cl.Rate <= 0 || cl.Burst <= 0 {
+            bad("rate_limit.classes.%s: rate and burst must be positive", name)
+        }
+    }
+    for tier, m := range c.RateLimit.TierMultipliers {
+        if m <= 0 {
+            bad("rate_limit.tier_multipliers.%s: must be positive", tier)
+        }
+    }
+
+    if !validLogLevel(c.Logging.Level) {
+        bad("logging.level: unknown level %q", c.Logging.Level)
+    }
+    for comp, l := range c.Logging.Components {
+        if !validLogLevel(l) {
+            bad("logging.components.%s: unknown level %q", comp, l)
+        }
+    }
+    for i, sink := range c.Logging.Sinks {
+        if !oneOf(strings.ToLower(sink.Format), "", "text", "json") {
+            bad("logging.sinks[%d].format: want text or json, got %q", i, sink.Format)
+        }
+        if !validLogLevel(sink.Level) {
+            bad("logging.sinks[%d].level: unknown level %q", i, sink.Level)
+        }
+    }
+
+    return errors.Join(errs...)
+}
+
+func oneOf(v string, allowed ...string) bool {
+    for _, a := range allowed {
+        if v == a {
+            return true
+        }
+    }
+    return false
+}
+
+func validLogLevel(l string) bool {
+    return oneOf(strings.ToLower(l), "", "debug", "info", "warn", "error")
+}
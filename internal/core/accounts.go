@@ -2,6 +2,7 @@ package core
 
 import (
 	"errors"
+	"sort"
 	"sync"
 )
 
@@ -23,19 +24,67 @@ type Account struct {
 type AccountStore struct {
 	mu       sync.RWMutex
 	accounts map[string]*Account
+	// dirty holds the balances changed since the last TakeDirty, so each
+	// block can persist the state it produced.
+	dirty map[balanceKey]struct{}
+	// parent is the store a Fork reads through to; accounts are copied
+	// into the fork on first write.
+	parent *AccountStore
+}
+
+type balanceKey struct {
+	addr  string
+	asset string
 }
 
 // NewAccountStore creates an empty store.
 func NewAccountStore() *AccountStore {
 	return &AccountStore{
 		accounts: make(map[string]*Account),
+		dirty:    make(map[balanceKey]struct{}),
+	}
+}
+
+// Fork returns a copy-on-write view of s: reads fall through to s, writes
+// stay in the fork. It is used for dry runs and is never merged back.
+func (s *AccountStore) Fork() *AccountStore {
+	return &AccountStore{
+		accounts: make(map[string]*Account),
+		dirty:    make(map[balanceKey]struct{}),
+		parent:   s,
+	}
+}
+
+// copyAccount returns a deep copy of addr's account, looking through to
+// parent stores.
+func (s *AccountStore) copyAccount(addr string) (*Account, bool) {
+	s.mu.RLock()
+	acc, ok := s.accounts[addr]
+	if !ok {
+		s.mu.RUnlock()
+		if s.parent == nil {
+			return nil, false
+		}
+		return s.parent.copyAccount(addr)
+	}
+	defer s.mu.RUnlock()
+	out := &Account{Address: acc.Address, Balances: make(Balances, len(acc.Balances)), Nonce: acc.Nonce}
+	for k, v := range acc.Balances {
+		out.Balances[k] = v
 	}
+	return out, true
 }
 
 func (s *AccountStore) getOrCreate(addr string) *Account {
 	if acc, ok := s.accounts[addr]; ok {
 		return acc
 	}
+	if s.parent != nil {
+		if acc, ok := s.parent.copyAccount(addr); ok {
+			s.accounts[addr] = acc
+			return acc
+		}
+	}
 	acc := &Account{
 		Address:  addr,
 		Balances: make(Balances),
@@ -62,6 +111,9 @@ func (s *AccountStore) GetNonce(addr string) uint64 {
 	if acc, ok := s.accounts[addr]; ok {
 		return acc.Nonce
 	}
+	if s.parent != nil {
+		return s.parent.GetNonce(addr)
+	}
 	return 0
 }
 
@@ -71,6 +123,7 @@ func (s *AccountStore) Credit(addr, asset string, amount float64) {
 	defer s.mu.Unlock()
 	acc := s.getOrCreate(addr)
 	acc.Balances[asset] += amount
+	s.dirty[balanceKey{addr, asset}] = struct{}{}
 }
 
 // Debit decreases a balance for an address/asset pair.
@@ -82,6 +135,7 @@ func (s *AccountStore) Debit(addr, asset string, amount float64) error {
 		return ErrInsufficientFunds
 	}
 	acc.Balances[asset] -= amount
+	s.dirty[balanceKey{addr, asset}] = struct{}{}
 	return nil
 }
 
@@ -108,6 +162,9 @@ func (s *AccountStore) Snapshot(addr string) *Account {
 		}
 		return out
 	}
+	if s.parent != nil {
+		return s.parent.Snapshot(addr)
+	}
 	// If we don't know this account yet, still return an empty record.
 	return &Account{
 		Address:  addr,
@@ -130,6 +187,84 @@ func (s *AccountStore) SnapshotAll() []*Account {
 		}
 		out = append(out, copyAcc)
 	}
+	if s.parent != nil {
+		for _, acc := range s.parent.SnapshotAll() {
+			if _, ok := s.accounts[acc.Address]; !ok {
+				out = append(out, acc)
+			}
+		}
+	}
+	return out
+}
+
+// ExportAccounts returns a copy of every account, including nonces,
+// sorted by address so the result can be hashed deterministically.
+func (s *AccountStore) ExportAccounts() []Account {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Account, 0, len(s.accounts))
+	for _, acc := range s.accounts {
+		copyAcc := Account{
+			Address:  acc.Address,
+			Balances: make(Balances, len(acc.Balances)),
+			Nonce:    acc.Nonce,
+		}
+		for k, v := range acc.Balances {
+			copyAcc.Balances[k] = v
+		}
+		out = append(out, copyAcc)
+	}
+	if s.parent != nil {
+		for _, acc := range s.parent.ExportAccounts() {
+			if _, ok := s.accounts[acc.Address]; !ok {
+				out = append(out, acc)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Address < out[j].Address })
+	return out
+}
+
+// Restore replaces the whole store with the given accounts.
+func (s *AccountStore) Restore(accounts []Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Balances that disappear read as zero in the next TakeDirty.
+	for _, acc := range s.accounts {
+		for asset := range acc.Balances {
+			s.dirty[balanceKey{acc.Address, asset}] = struct{}{}
+		}
+	}
+	s.accounts = make(map[string]*Account, len(accounts))
+	for _, acc := range accounts {
+		acc := acc
+		if acc.Balances == nil {
+			acc.Balances = make(Balances)
+		}
+		s.accounts[acc.Address] = &acc
+		for asset := range acc.Balances {
+			s.dirty[balanceKey{acc.Address, asset}] = struct{}{}
+		}
+	}
+}
+
+// TakeDirty returns the current value of every balance changed since the
+// previous call, keyed by address, and resets the change set.
+func (s *AccountStore) TakeDirty() map[string]Balances {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]Balances)
+	for k := range s.dirty {
+		if out[k.addr] == nil {
+			out[k.addr] = make(Balances)
+		}
+		var v float64
+		if acc, ok := s.accounts[k.addr]; ok {
+			v = acc.Balances[k.asset]
+		}
+		out[k.addr][k.asset] = v
+	}
+	s.dirty = make(map[balanceKey]struct{})
 	return out
 }
 
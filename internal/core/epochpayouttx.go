@@ -5,6 +5,7 @@ import (
     "fmt"
     "time"
 
+    "reservechain/internal/logging"
     "reservechain/internal/store"
 )
 
@@ -24,9 +25,10 @@ type EpochPayoutCommitTx struct {
 const epochPayoutAuthorDefault = "econ"
 
 // ApplyEpochPayoutCommit records a payout commitment as an on-chain tx.
-func (c *Chain) ApplyEpochPayoutCommit(tx EpochPayoutCommitTx) (*Block, string, error) {
+func (c *Chain) ApplyEpochPayoutCommit(ctx context.Context, tx EpochPayoutCommitTx) (*Block, string, error) {
     c.mu.Lock()
     defer c.mu.Unlock()
+    ctx = applyCtx(ctx)
 
     if tx.Author == "" {
         tx.Author = epochPayoutAuthorDefault
@@ -38,11 +40,13 @@ func (c *Chain) ApplyEpochPayoutCommit(tx EpochPayoutCommitTx) (*Block, string,
         return nil, "", err
     }
 
-    blk := c.appendBlockLocked("TX_EPOCH_PAYOUT_COMMIT", tx)
+    blk := c.appendBlockLocked(ctx, "TX_EPOCH_PAYOUT_COMMIT", tx)
+
+    ctx = logging.WithTx(ctx, blk.Hash)
 
     // Best-effort persistence for fast queries.
-    if c.db != nil {
-        _ = c.db.InsertEpochPayoutCommit(context.Background(), store.EpochPayoutCommit{
+    if c.db != nil && !c.dryRun {
+        store.LogWriteError(ctx, "insert_epoch_payout_commit", c.db.InsertEpochPayoutCommit(ctx, store.EpochPayoutCommit{
             Epoch:             int64(tx.EpochIndex),
             TxHash:            blk.Hash,
             Author:            tx.Author,
@@ -52,7 +56,7 @@ func (c *Chain) ApplyEpochPayoutCommit(tx EpochPayoutCommitTx) (*Block, string,
             PopBudgetGRC:      tx.PopBudgetGRC,
             TreasuryBudgetGRC: tx.TreasuryBudgetGRC,
             CreatedAt:         time.Now().UTC(),
-        })
+        }))
     }
 
     return blk, blk.Hash, nil
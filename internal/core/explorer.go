@@ -0,0 +1,194 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Block lookups for explorer-style APIs. The chain keeps one tx per block,
+// so a tx hash is its block's hash and byHash serves both lookups.
+
+// indexBlockLocked records blk in the hash index. c.mu must be held (or
+// the chain not yet shared).
+func (c *Chain) indexBlockLocked(blk *Block) {
+	if c.byHash == nil {
+		c.byHash = make(map[string]*Block, 1024)
+	}
+	c.byHash[blk.Hash] = blk
+}
+
+// Head returns the tip block, or nil for an empty chain.
+func (c *Chain) Head() *Block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.blocks) == 0 {
+		return nil
+	}
+	return c.blocks[len(c.blocks)-1]
+}
+
+// Blocks returns a copy of the block list in ascending height.
+func (c *Chain) Blocks() []*Block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]*Block, len(c.blocks))
+	copy(out, c.blocks)
+	return out
+}
+
+// BlockByHeight returns the block at height, if this node holds it.
+func (c *Chain) BlockByHeight(height uint64) (*Block, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if height < c.base || height-c.base >= uint64(len(c.blocks)) {
+		return nil, false
+	}
+	return c.blocks[height-c.base], true
+}
+
+// BlockByHash returns the block with the given hash (equivalently, the
+// block carrying the tx with that hash).
+func (c *Chain) BlockByHash(hash string) (*Block, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	blk, ok := c.byHash[hash]
+	return blk, ok
+}
+
+// BlockRange returns up to limit blocks with from <= height <= to in
+// ascending order. to == 0 means up to the tip.
+func (c *Chain) BlockRange(from, to uint64, limit int) []*Block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.blocks) == 0 || limit <= 0 {
+		return nil
+	}
+	tip := c.base + uint64(len(c.blocks)) - 1
+	if from < c.base {
+		from = c.base
+	}
+	if to == 0 || to > tip {
+		to = tip
+	}
+	if from > to {
+		return nil
+	}
+	n := to - from + 1
+	if n > uint64(limit) {
+		n = uint64(limit)
+	}
+	out := make([]*Block, n)
+	copy(out, c.blocks[from-c.base:from-c.base+n])
+	return out
+}
+
+// BalanceEffect is one balance change made by a transaction.
+type BalanceEffect struct {
+	Address string  `json:"address"`
+	Asset   string  `json:"asset"`
+	Delta   float64 `json:"delta"`
+}
+
+// DecodedTx is a block's transaction rendered with its typed body and the
+// balance changes it made.
+type DecodedTx struct {
+	Hash      string          `json:"hash"`
+	Height    uint64          `json:"height"`
+	Timestamp time.Time       `json:"timestamp"`
+	Type      string          `json:"type"`
+	Body      interface{}     `json:"body"`
+	Effects   []BalanceEffect `json:"effects"`
+	// Note flags effects that cannot be fully reconstructed from the body
+	// (mint and redeem record only one leg of the NAV conversion).
+	Note string `json:"note,omitempty"`
+}
+
+// txBodyTypes maps each tx type to a constructor for its typed body.
+var txBodyTypes = map[string]func() interface{}{
+	"TX_MINT":                func() interface{} { return new(MintTx) },
+	"TX_REDEEM":              func() interface{} { return new(RedeemTx) },
+	"TX_TRANSFER":            func() interface{} { return new(TransferTx) },
+	"TX_TIER_RENEW":          func() interface{} { return new(TxTierRenew) },
+	"TX_VAULT_CREATE":        func() interface{} { return new(TxVaultCreate) },
+	"TX_VAULT_DEPOSIT":       func() interface{} { return new(TxVaultDeposit) },
+	"TX_VAULT_WITHDRAW":      func() interface{} { return new(TxVaultWithdraw) },
+	"TX_VAULT_TRANSFER":      func() interface{} { return new(TxVaultTransfer) },
+	"TX_STAKE_LOCK":          func() interface{} { return new(StakeLockTx) },
+	"TX_STAKE_UNLOCK":        func() interface{} { return new(StakeUnlockTx) },
+	"TX_POP_REGISTER_NODE":   func() interface{} { return new(PoPRegisterNodeTx) },
+	"TX_POP_SET_CAPS":        func() interface{} { return new(PoPSetCapsTx) },
+	"TX_POP_WORK_CLAIM":      func() interface{} { return new(PoPWorkClaimTx) },
+	"TX_EPOCH_PAYOUT_COMMIT": func() interface{} { return new(EpochPayoutCommitTx) },
+}
+
+// DecodeBlockTx renders blk's transaction. Unknown types (EMPTY, GENESIS)
+// keep their raw body and have no effects.
+func DecodeBlockTx(blk *Block) (DecodedTx, error) {
+	out := DecodedTx{
+		Hash:      blk.Hash,
+		Height:    blk.Height,
+		Timestamp: blk.Timestamp,
+		Type:      blk.TxType,
+		Body:      blk.Tx,
+		Effects:   []BalanceEffect{},
+	}
+	mk, ok := txBodyTypes[blk.TxType]
+	if !ok {
+		return out, nil
+	}
+	raw, err := json.Marshal(blk.Tx)
+	if err != nil {
+		return out, err
+	}
+	body := mk()
+	if err := json.Unmarshal(raw, body); err != nil {
+		return out, fmt.Errorf("decode %s: %w", blk.TxType, err)
+	}
+	out.Body = body
+	out.Effects, out.Note = txEffects(body)
+	return out, nil
+}
+
+// txEffects mirrors the balance moves of the matching Apply* function.
+func txEffects(body interface{}) ([]BalanceEffect, string) {
+	move := func(from, to, asset string, amount float64) []BalanceEffect {
+		if asset == "" {
+			asset = "GRC"
+		}
+		return []BalanceEffect{
+			{Address: from, Asset: asset, Delta: -amount},
+			{Address: to, Asset: asset, Delta: amount},
+		}
+	}
+	switch tx := body.(type) {
+	case *MintTx:
+		minted := []BalanceEffect{{Address: tx.Address, Asset: "GRC", Delta: tx.Amount}}
+		if tx.Deposit <= 0 {
+			return minted, "backing deposit to treasury is not recorded in the tx"
+		}
+		return append(move(tx.Address, "treasury", tx.Asset, tx.Deposit), minted...), ""
+	case *RedeemTx:
+		if tx.Burned <= 0 {
+			return []BalanceEffect{{Address: tx.Address, Asset: tx.Asset, Delta: tx.Amount}},
+				"GRC burned at NAV is not recorded in the tx"
+		}
+		return append(move("treasury", tx.Address, tx.Asset, tx.Amount),
+			BalanceEffect{Address: tx.Address, Asset: "GRC", Delta: -tx.Burned}), ""
+	case *TransferTx:
+		return move(tx.From, tx.To, tx.Asset, tx.Amount), ""
+	case *TxTierRenew:
+		return move(tx.Sender, "treasury-tiers", "GRC", tx.Payment.AmountGRC), ""
+	case *TxVaultDeposit:
+		return move(tx.From, vaultAddress(tx.VaultID), tx.Asset, tx.Amount), ""
+	case *TxVaultWithdraw:
+		return move(vaultAddress(tx.VaultID), tx.To, tx.Asset, tx.Amount), ""
+	case *TxVaultTransfer:
+		return move(vaultAddress(tx.FromVaultID), vaultAddress(tx.ToVaultID), tx.Asset, tx.Amount), ""
+	case *StakeLockTx:
+		return move(tx.StakerWallet, stakeEscrowAddress, "RSX", tx.AmountRSX), ""
+	case *StakeUnlockTx:
+		return move(stakeEscrowAddress, tx.StakerWallet, "RSX", tx.AmountRSX), ""
+	}
+	return []BalanceEffect{}, ""
+}
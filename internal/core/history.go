@@ -0,0 +1,120 @@
+package core
+
+import (
+	"context"
+	"sort"
+
+	"reservechain/internal/logging"
+	"reservechain/internal/store"
+)
+
+// Balance history. Every block persists the post-block value of each
+// balance it changed (account_balance_history), so the state of any
+// account at any recorded height can be read back without replaying the
+// chain.
+
+// SetEpochSource sets the function that reports the current econ epoch.
+// Each sealed block records it so callers can ask for the state at the
+// end of an epoch.
+func (c *Chain) SetEpochSource(fn func() int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.epochOf = fn
+}
+
+// recordStateLocked persists the balances changed since the previous block
+// as the diff of the block at height. c.mu must be held.
+func (c *Chain) recordStateLocked(ctx context.Context, height uint64) {
+	dirty := c.store.TakeDirty()
+	if c.db == nil {
+		return
+	}
+	epoch := int64(-1)
+	if c.epochOf != nil {
+		epoch = c.epochOf()
+	}
+	store.LogWriteError(ctx, "insert_state_diffs", c.db.InsertStateDiffs(ctx, height, epoch, balanceDiffs(dirty)))
+}
+
+// replayWithHistory replays txs one block at a time and records the diff
+// of every block above the highest height already in the history, which
+// backfills chains logged before history existed. Replayed blocks carry
+// no epoch.
+func (c *Chain) replayWithHistory(ctx context.Context, txs []store.ChainTxRow) error {
+	_, recorded, ok, err := c.db.StateHistoryRange(ctx)
+	if err != nil {
+		chainLog.Warn("balance history unavailable", logging.Err(err))
+		return c.replayStateFromTxRows(txs)
+	}
+	record := func(height uint64) {
+		dirty := c.store.TakeDirty()
+		if ok && height <= recorded {
+			return
+		}
+		store.LogWriteError(ctx, "insert_state_diffs", c.db.InsertStateDiffs(ctx, height, -1, balanceDiffs(dirty)))
+	}
+	if c.base > 0 {
+		record(c.base)
+	}
+	for i, row := range txs {
+		if err := c.replayStateFromTxRows(txs[i : i+1]); err != nil {
+			return err
+		}
+		record(row.BlockHeight)
+	}
+	c.store.TakeDirty()
+	return nil
+}
+
+func balanceDiffs(dirty map[string]Balances) []store.BalanceDiff {
+	out := make([]store.BalanceDiff, 0, len(dirty))
+	for addr, bals := range dirty {
+		for asset, v := range bals {
+			out = append(out, store.BalanceDiff{Address: addr, Asset: asset, Balance: v})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Address != out[j].Address {
+			return out[i].Address < out[j].Address
+		}
+		return out[i].Asset < out[j].Asset
+	})
+	return out
+}
+
+// AccountsFromBalances groups per-asset balances into accounts, in the
+// order the balances are given. Nonces are not part of the history.
+func AccountsFromBalances(rows []store.BalanceDiff) []*Account {
+	var out []*Account
+	idx := map[string]*Account{}
+	for _, r := range rows {
+		acc, ok := idx[r.Address]
+		if !ok {
+			acc = &Account{Address: r.Address, Balances: make(Balances)}
+			idx[r.Address] = acc
+			out = append(out, acc)
+		}
+		acc.Balances[r.Asset] = r.Balance
+	}
+	return out
+}
+
+// MonetarySnapshotOf computes the reserve, supply and NAV figures of
+// DevnetMonetarySnapshot for an arbitrary set of accounts, such as a
+// historical one.
+func MonetarySnapshotOf(accounts []*Account) (reserve, supply, nav float64) {
+	for _, acc := range accounts {
+		if acc.Address == "treasury" {
+			for _, asset := range []string{"USDC", "USDT", "DAI", "ETH", "WBTC"} {
+				reserve += acc.Balances[asset] * devnetPriceUSD[asset]
+			}
+		}
+		supply += acc.Balances["GRC"]
+	}
+	if supply <= 0 {
+		nav = 1.0
+	} else {
+		nav = reserve / supply
+	}
+	return
+}
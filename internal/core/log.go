@@ -0,0 +1,25 @@
+package core
+
+import (
+	"context"
+
+	"reservechain/internal/logging"
+)
+
+var (
+	chainLog    = logging.For("chain")
+	snapshotLog = logging.For("snapshot")
+)
+
+// applyCtx is the context of a tx's state and DB writes: it keeps the
+// caller's values (request ID) for logging but not its cancellation, so a
+// client going away cannot leave a block in memory without its DB rows.
+func applyCtx(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// txContext is applyCtx tagged with blk's hash, which is also the hash of
+// the tx it carries.
+func txContext(ctx context.Context, blk *Block) context.Context {
+	return logging.WithTx(applyCtx(ctx), blk.Hash)
+}
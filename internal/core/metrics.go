@@ -0,0 +1,37 @@
+package core
+
+import "reservechain/internal/metrics"
+
+var (
+	chainHeight = metrics.NewGaugeVec("reservechain_chain_height",
+		"Height of the chain tip.")
+	chainDifficulty = metrics.NewGaugeVec("reservechain_chain_difficulty",
+		"PoW difficulty of the tip block.")
+	blockInterval = metrics.NewHistogramVec("reservechain_block_interval_seconds",
+		"Time between consecutive blocks, by whether this node mined the block.",
+		[]float64{1, 2, 5, 10, 15, 20, 30, 60, 120, 300}, "source")
+)
+
+// Values of the blockInterval source label.
+const (
+	blockSourceLocal  = "local"
+	blockSourceRemote = "remote"
+)
+
+// observeTipLocked updates the chain metrics for the block just appended.
+// An empty source updates the gauges only (reload, snapshot restore).
+// c.mu must be held.
+func (c *Chain) observeTipLocked(source string) {
+	n := len(c.blocks)
+	if n == 0 {
+		return
+	}
+	blk := c.blocks[n-1]
+	chainHeight.Set(float64(blk.Height))
+	chainDifficulty.Set(float64(blk.Difficulty))
+	if n > 1 && source != "" {
+		if dt := blk.Timestamp.Sub(c.blocks[n-2].Timestamp).Seconds(); dt >= 0 {
+			blockInterval.Observe(dt, source)
+		}
+	}
+}
@@ -4,16 +4,39 @@ import (
     "context"
     "fmt"
 
+    "reservechain/internal/identity"
+    "reservechain/internal/logging"
     "reservechain/internal/store"
 )
 
 // PoPRegisterNodeTx registers (or updates) a PoP node to an operator identity.
 // This is recorded as an on-chain transaction so the node registry is auditable.
+//
+// NodeID must be derived from NodePubKey, and NodeSig is the node key's
+// signature over PoPRegisterNodeMessage, so only the holder of the node key
+// can bind it to an operator wallet.
 type PoPRegisterNodeTx struct {
     OperatorWallet string `json:"operator_wallet"`
     NodeID         string `json:"node_id"`
+    NodePubKey     string `json:"node_pubkey"`
     Role           string `json:"role"`
     Nonce          uint64 `json:"nonce"`
+    NodeSig        string `json:"node_sig"`
+}
+
+// PoPRegisterNodeMessage returns the bytes a node key signs to authorise
+// binding itself to tx.OperatorWallet.
+func PoPRegisterNodeMessage(tx PoPRegisterNodeTx) []byte {
+    return []byte(fmt.Sprintf("reservechain-pop-register|%s|%s|%s|%d",
+        tx.OperatorWallet, tx.NodeID, tx.Role, tx.Nonce))
+}
+
+// verifyPoPNodeBinding checks the node key signature on a registration.
+func verifyPoPNodeBinding(tx PoPRegisterNodeTx) error {
+    if tx.NodePubKey == "" || tx.NodeSig == "" {
+        return fmt.Errorf("missing node_pubkey/node_sig")
+    }
+    return identity.VerifyNodeSignature(tx.NodeID, tx.NodePubKey, PoPRegisterNodeMessage(tx), tx.NodeSig)
 }
 
 // PoPSetCapsTx sets (or updates) a node's capability ceilings used for PoP scoring.
@@ -27,33 +50,48 @@ type PoPSetCapsTx struct {
     Nonce           uint64  `json:"nonce"`
 }
 
-func (c *Chain) ApplyPoPRegisterNode(tx PoPRegisterNodeTx) (*Block, string, error) {
+func (c *Chain) ApplyPoPRegisterNode(ctx context.Context, tx PoPRegisterNodeTx) (*Block, string, error) {
     c.mu.Lock()
     defer c.mu.Unlock()
+    ctx = applyCtx(ctx)
 
     if tx.OperatorWallet == "" || tx.NodeID == "" {
         return nil, "", fmt.Errorf("missing operator_wallet/node_id")
     }
+    if err := verifyPoPNodeBinding(tx); err != nil {
+        return nil, "", err
+    }
     if err := c.store.ExpectAndIncrementNonce(tx.OperatorWallet, tx.Nonce); err != nil {
         return nil, "", err
     }
 
-    blk := c.appendBlockLocked("TX_POP_REGISTER_NODE", tx)
+    node := store.PoPNode{
+        NodeID:         tx.NodeID,
+        OperatorWallet: tx.OperatorWallet,
+        Role:           tx.Role,
+        NodePubKey:     tx.NodePubKey,
+    }
+    // Write the row before sealing so the block's state root covers it;
+    // the tx hash is recorded once the block exists.
+    if c.db != nil && !c.dryRun {
+        store.LogWriteError(ctx, "upsert_pop_node", c.db.UpsertPoPNode(ctx, node))
+    }
+
+    blk := c.appendBlockLocked(ctx, "TX_POP_REGISTER_NODE", tx)
 
-    if c.db != nil {
-        _ = c.db.UpsertPoPNodeWithTxHash(context.Background(), store.PoPNode{
-            NodeID:         tx.NodeID,
-            OperatorWallet: tx.OperatorWallet,
-            Role:           tx.Role,
-        }, blk.Hash)
+    ctx = logging.WithTx(ctx, blk.Hash)
+
+    if c.db != nil && !c.dryRun {
+        store.LogWriteError(ctx, "upsert_pop_node", c.db.UpsertPoPNodeWithTxHash(ctx, node, blk.Hash))
     }
 
     return blk, blk.Hash, nil
 }
 
-func (c *Chain) ApplyPoPSetCaps(tx PoPSetCapsTx) (*Block, string, error) {
+func (c *Chain) ApplyPoPSetCaps(ctx context.Context, tx PoPSetCapsTx) (*Block, string, error) {
     c.mu.Lock()
     defer c.mu.Unlock()
+    ctx = applyCtx(ctx)
 
     if tx.OperatorWallet == "" || tx.NodeID == "" {
         return nil, "", fmt.Errorf("missing operator_wallet/node_id")
@@ -62,16 +100,24 @@ func (c *Chain) ApplyPoPSetCaps(tx PoPSetCapsTx) (*Block, string, error) {
         return nil, "", err
     }
 
-    blk := c.appendBlockLocked("TX_POP_SET_CAPS", tx)
+    caps := store.PoPCapability{
+        NodeID:         tx.NodeID,
+        CPUScore:       tx.CPUScore,
+        RAMScore:       tx.RAMScore,
+        StorageScore:   tx.StorageScore,
+        BandwidthScore: tx.BandwidthScore,
+    }
+    // As above: write first so the state root covers the new caps.
+    if c.db != nil && !c.dryRun {
+        store.LogWriteError(ctx, "upsert_pop_capability", c.db.UpsertPoPCapability(ctx, caps))
+    }
+
+    blk := c.appendBlockLocked(ctx, "TX_POP_SET_CAPS", tx)
+
+    ctx = logging.WithTx(ctx, blk.Hash)
 
-    if c.db != nil {
-        _ = c.db.UpsertPoPCapabilityWithTxHash(context.Background(), store.PoPCapability{
-            NodeID:         tx.NodeID,
-            CPUScore:       tx.CPUScore,
-            RAMScore:       tx.RAMScore,
-            StorageScore:   tx.StorageScore,
-            BandwidthScore: tx.BandwidthScore,
-        }, blk.Hash)
+    if c.db != nil && !c.dryRun {
+        store.LogWriteError(ctx, "upsert_pop_capability", c.db.UpsertPoPCapabilityWithTxHash(ctx, caps, blk.Hash))
     }
 
     return blk, blk.Hash, nil
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"reservechain/internal/logging"
 	"reservechain/internal/store"
 )
 
@@ -26,9 +27,10 @@ type PoPWorkClaimTx struct {
 // ApplyPoPWorkClaim appends an on-chain work-claim tx and persists the metrics into SQLite.
 // IMPORTANT: pop_epoch_metrics is only mutated via this on-chain path, so callers cannot
 // spoof PoP metrics by writing directly to the DB through an API endpoint.
-func (c *Chain) ApplyPoPWorkClaim(tx PoPWorkClaimTx) (*Block, string, error) {
+func (c *Chain) ApplyPoPWorkClaim(ctx context.Context, tx PoPWorkClaimTx) (*Block, string, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	ctx = applyCtx(ctx)
 
 	if tx.OperatorWallet == "" || tx.NodeID == "" {
 		return nil, "", fmt.Errorf("missing operator_wallet/node_id")
@@ -43,7 +45,7 @@ func (c *Chain) ApplyPoPWorkClaim(tx PoPWorkClaimTx) (*Block, string, error) {
 
 	// Verify the node registry (if DB available).
 	if c.db != nil {
-		n, err := c.db.GetPoPNode(context.Background(), tx.NodeID)
+		n, err := c.db.GetPoPNode(ctx, tx.NodeID)
 		if err != nil {
 			return nil, "", fmt.Errorf("unknown node_id")
 		}
@@ -53,11 +55,12 @@ func (c *Chain) ApplyPoPWorkClaim(tx PoPWorkClaimTx) (*Block, string, error) {
 	}
 
 	// Append block first so we can use the block hash as tx_hash for idempotent metric inserts.
-	blk := c.appendBlockLocked("TX_POP_WORK_CLAIM", tx)
+	blk := c.appendBlockLocked(ctx, "TX_POP_WORK_CLAIM", tx)
+	ctx = logging.WithTx(ctx, blk.Hash)
 
 	// Persist metrics (auditable) keyed by tx hash to avoid replay duplicates.
-	if c.db != nil {
-		_ = c.db.InsertPoPMetricsWithTxHash(context.Background(), store.PoPMetrics{
+	if c.db != nil && !c.dryRun {
+		store.LogWriteError(ctx, "insert_pop_metrics", c.db.InsertPoPMetricsWithTxHash(ctx, store.PoPMetrics{
 			Epoch:          tx.Epoch,
 			NodeID:         tx.NodeID,
 			UptimeScore:    tx.UptimeScore,
@@ -65,7 +68,7 @@ func (c *Chain) ApplyPoPWorkClaim(tx PoPWorkClaimTx) (*Block, string, error) {
 			BlocksRelayed:  tx.BlocksRelayed,
 			StorageIO:      tx.StorageIO,
 			LatencyScore:   tx.LatencyScore,
-		}, blk.Hash)
+		}, blk.Hash))
 	}
 
 	return blk, blk.Hash, nil
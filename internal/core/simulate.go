@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// SimulationResult is the outcome of a dry-run transaction. Nothing in it
+// was committed: the block is unsealed and never reaches the chain log.
+type SimulationResult struct {
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+	TxType string `json:"tx_type"`
+	// Deltas are the net balance changes the tx would make, both legs
+	// included (unlike the recorded body of a mint or redeem).
+	Deltas []BalanceEffect `json:"deltas"`
+	// Fees is always empty: DevNet transactions carry no fee.
+	Fees     Balances `json:"fees"`
+	NAV      float64  `json:"nav"`
+	NAVAfter float64  `json:"nav_after"`
+	// Block is the tx as it would be recorded, with its block effects.
+	Block *DecodedTx `json:"block,omitempty"`
+}
+
+// Simulate runs a tx of txType against a copy-on-write fork of the current
+// state and reports what it would do. Apply* run unchanged on the fork,
+// which skips PoW and every DB write. The error return is for a body that
+// cannot be decoded; a tx the chain would reject is reported in the
+// result.
+//
+// For TX_MINT and TX_REDEEM the body carries the request amounts (deposit
+// and GRC to burn), as the Apply* calls take them.
+func (c *Chain) Simulate(ctx context.Context, txType string, raw []byte) (SimulationResult, error) {
+	mk, ok := txBodyTypes[txType]
+	if !ok {
+		return SimulationResult{}, fmt.Errorf("unsupported tx type %q", txType)
+	}
+	body := mk()
+	if err := json.Unmarshal(raw, body); err != nil {
+		return SimulationResult{}, fmt.Errorf("decode %s: %w", txType, err)
+	}
+
+	// Hold the read lock so no block lands while the fork is in use.
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	sim := &Chain{store: c.store.Fork(), base: c.base, db: c.db, dryRun: true}
+	if n := len(c.blocks); n > 0 {
+		sim.base = c.blocks[n-1].Height
+		sim.blocks = []*Block{c.blocks[n-1]}
+	}
+	res := SimulationResult{
+		TxType: txType,
+		Deltas: []BalanceEffect{},
+		Fees:   Balances{},
+		NAV:    sim.computeDevnetNAVLocked(),
+	}
+	blk, err := sim.applyTx(ctx, body)
+	if err != nil {
+		res.Error = err.Error()
+		res.NAVAfter = res.NAV
+		return res, nil
+	}
+	res.OK = true
+	res.NAVAfter = sim.computeDevnetNAVLocked()
+	res.Deltas = balanceDeltas(c.store, sim.store.TakeDirty())
+	if dec, err := DecodeBlockTx(blk); err == nil {
+		res.Block = &dec
+	}
+	return res, nil
+}
+
+// applyTx dispatches a decoded tx body to its Apply* function.
+func (c *Chain) applyTx(ctx context.Context, body interface{}) (*Block, error) {
+	var blk *Block
+	var err error
+	switch tx := body.(type) {
+	case *MintTx:
+		blk, _, err = c.ApplyMint(ctx, tx.Address, tx.Asset, tx.Amount)
+	case *RedeemTx:
+		blk, _, err = c.ApplyRedeem(ctx, tx.Address, tx.Asset, tx.Amount)
+	case *TransferTx:
+		blk, _, err = c.ApplyTransfer(ctx, *tx)
+	case *TxTierRenew:
+		blk, _, err = c.ApplyTierRenew(ctx, *tx)
+	case *TxVaultCreate:
+		blk, _, err = c.ApplyVaultCreate(ctx, *tx)
+	case *TxVaultDeposit:
+		blk, _, err = c.ApplyVaultDeposit(ctx, *tx)
+	case *TxVaultWithdraw:
+		blk, _, err = c.ApplyVaultWithdraw(ctx, *tx)
+	case *TxVaultTransfer:
+		blk, _, err = c.ApplyVaultTransfer(ctx, *tx)
+	case *StakeLockTx:
+		blk, _, err = c.ApplyStakeLock(ctx, *tx)
+	case *StakeUnlockTx:
+		blk, _, err = c.ApplyStakeUnlock(ctx, *tx)
+	case *PoPRegisterNodeTx:
+		blk, _, err = c.ApplyPoPRegisterNode(ctx, *tx)
+	case *PoPSetCapsTx:
+		blk, _, err = c.ApplyPoPSetCaps(ctx, *tx)
+	case *PoPWorkClaimTx:
+		blk, _, err = c.ApplyPoPWorkClaim(ctx, *tx)
+	case *EpochPayoutCommitTx:
+		blk, _, err = c.ApplyEpochPayoutCommit(ctx, *tx)
+	default:
+		err = fmt.Errorf("unsupported tx body %T", body)
+	}
+	return blk, err
+}
+
+// balanceDeltas compares the post-tx balances of a fork against base.
+func balanceDeltas(base *AccountStore, after map[string]Balances) []BalanceEffect {
+	out := []BalanceEffect{}
+	for addr, bals := range after {
+		before := base.Snapshot(addr).Balances
+		for asset, v := range bals {
+			if d := v - before[asset]; d != 0 {
+				out = append(out, BalanceEffect{Address: addr, Asset: asset, Delta: d})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Address != out[j].Address {
+			return out[i].Address < out[j].Address
+		}
+		return out[i].Asset < out[j].Asset
+	})
+	return out
+}
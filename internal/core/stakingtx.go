@@ -36,9 +36,10 @@ const stakeEscrowAddress = "stake-escrow"
 // IMPORTANT: the staking tables in SQLite are only mutated from this
 // on-chain transaction path (not direct API writes), so stake state
 // cannot be spoofed by callers.
-func (c *Chain) ApplyStakeLock(tx StakeLockTx) (*Block, string, error) {
+func (c *Chain) ApplyStakeLock(ctx context.Context, tx StakeLockTx) (*Block, string, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	ctx = applyCtx(ctx)
 
 	if tx.StakerWallet == "" || tx.ValidatorID == "" {
 		return nil, "", fmt.Errorf("missing staker_wallet/validator_id")
@@ -61,19 +62,20 @@ func (c *Chain) ApplyStakeLock(tx StakeLockTx) (*Block, string, error) {
 	c.store.Credit(stakeEscrowAddress, "RSX", tx.AmountRSX)
 
 	// Persist stake state (only via on-chain tx).
-	if c.db != nil {
-		_ = c.db.ApplyStakeDelta(context.Background(), tx.StakerWallet, tx.ValidatorID, +tx.AmountRSX, tx.LockUntilEpoch)
+	if c.db != nil && !c.dryRun {
+		store.LogWriteError(ctx, "apply_stake_delta", c.db.ApplyStakeDelta(ctx, tx.StakerWallet, tx.ValidatorID, +tx.AmountRSX, tx.LockUntilEpoch))
 	}
 
-	blk := c.appendBlockLocked("TX_STAKE_LOCK", tx)
+	blk := c.appendBlockLocked(ctx, "TX_STAKE_LOCK", tx)
 	return blk, blk.Hash, nil
 }
 
 // ApplyStakeUnlock unlocks RSX and updates staking state. Lock expiry is
 // enforced at the API layer (which has access to the epoch scheduler/state).
-func (c *Chain) ApplyStakeUnlock(tx StakeUnlockTx) (*Block, string, error) {
+func (c *Chain) ApplyStakeUnlock(ctx context.Context, tx StakeUnlockTx) (*Block, string, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	ctx = applyCtx(ctx)
 
 	if tx.StakerWallet == "" || tx.ValidatorID == "" {
 		return nil, "", fmt.Errorf("missing staker_wallet/validator_id")
@@ -89,7 +91,7 @@ func (c *Chain) ApplyStakeUnlock(tx StakeUnlockTx) (*Block, string, error) {
 	// Ensure position exists and amount is available (DB is authoritative).
 	pos := store.StakePosition{}
 	if c.db != nil {
-		p, err := c.db.GetStakePosition(context.Background(), tx.StakerWallet, tx.ValidatorID)
+		p, err := c.db.GetStakePosition(ctx, tx.StakerWallet, tx.ValidatorID)
 		if err == nil {
 			pos = p
 		}
@@ -107,10 +109,10 @@ func (c *Chain) ApplyStakeUnlock(tx StakeUnlockTx) (*Block, string, error) {
 	}
 	c.store.Credit(tx.StakerWallet, "RSX", tx.AmountRSX)
 
-	if c.db != nil {
-		_ = c.db.ApplyStakeDelta(context.Background(), tx.StakerWallet, tx.ValidatorID, -tx.AmountRSX, 0)
+	if c.db != nil && !c.dryRun {
+		store.LogWriteError(ctx, "apply_stake_delta", c.db.ApplyStakeDelta(ctx, tx.StakerWallet, tx.ValidatorID, -tx.AmountRSX, 0))
 	}
 
-	blk := c.appendBlockLocked("TX_STAKE_UNLOCK", tx)
+	blk := c.appendBlockLocked(ctx, "TX_STAKE_UNLOCK", tx)
 	return blk, blk.Hash, nil
 }
@@ -0,0 +1,539 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"reservechain/internal/logging"
+	"reservechain/internal/proof"
+	"reservechain/internal/store"
+)
+
+// minBlockDifficulty is the lowest PoW difficulty a valid block may claim.
+const minBlockDifficulty = proof.MinDifficulty
+
+// State sections committed by the state root in every block header. Each
+// block's root covers the state *after* its transaction was applied.
+const (
+	StateSectionAccounts = proof.AccountsSection
+	StateSectionStaking  = "staking"
+	StateSectionPoP      = "pop"
+)
+
+// StateSection is one named slice of node state inside a snapshot.
+// Consensus sections are covered by the block state root; the rest travel
+// with snapshots but are node-local (e.g. econ state driven by leader ticks)
+// and are only checked against their own hash. The accounts section hash is
+// a Merkle root over individual accounts so single accounts can be proven.
+type StateSection struct {
+	Name      string          `json:"name"`
+	Consensus bool            `json:"consensus"`
+	Hash      string          `json:"hash"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// StateSnapshot is the full state of a node at Height, taken right after
+// the block with BlockHash was applied.
+type StateSnapshot struct {
+	Height    uint64         `json:"height"`
+	BlockHash string         `json:"block_hash"`
+	StateRoot string         `json:"state_root"`
+	Sections  []StateSection `json:"sections"`
+}
+
+// ExtraStateSection lets packages layered on top of core carry their own
+// (non-consensus) state in snapshots. Export and Import are called without
+// the chain lock held.
+type ExtraStateSection struct {
+	Export func() (json.RawMessage, error)
+	Import func(json.RawMessage) error
+}
+
+type stakingState struct {
+	Stakes []store.StakePosition `json:"stakes"`
+}
+
+// ErrStateUnavailable is returned when this node holds no committed state
+// for the requested height.
+var ErrStateUnavailable = errors.New("state not available at height")
+
+// ErrAccountNotFound is returned when an account is absent from a state.
+var ErrAccountNotFound = errors.New("account not found")
+
+// committedState is the consensus state a block's root was computed from.
+type committedState struct {
+	block    *Block
+	sections []StateSection
+}
+
+type popState struct {
+	Nodes []store.PoPNode       `json:"nodes"`
+	Caps  []store.PoPCapability `json:"caps"`
+}
+
+// RegisterStateSection adds a non-consensus section to future snapshots.
+func (c *Chain) RegisterStateSection(name string, s ExtraStateSection) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.extraSections == nil {
+		c.extraSections = make(map[string]ExtraStateSection)
+	}
+	c.extraSections[name] = s
+}
+
+// SetSnapshotPolicy makes the chain record a snapshot every interval blocks,
+// keeping the newest keep of them. An interval of 0 disables snapshots.
+func (c *Chain) SetSnapshotPolicy(interval uint64, keep int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.snapInterval = interval
+	c.snapKeep = keep
+}
+
+// Height returns the height of the chain tip.
+func (c *Chain) Height() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.blocks) == 0 {
+		return c.base
+	}
+	return c.base + uint64(len(c.blocks)) - 1
+}
+
+func newStateSection(name string, consensus bool, v interface{}) (StateSection, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return StateSection{}, err
+	}
+	sum := sha256.Sum256(data)
+	return StateSection{Name: name, Consensus: consensus, Hash: hex.EncodeToString(sum[:]), Data: data}, nil
+}
+
+// accountLeaves returns the Merkle leaves for accounts (already sorted by
+// address): the canonical JSON encoding of each account.
+func accountLeaves(accounts []Account) ([][]byte, error) {
+	leaves := make([][]byte, len(accounts))
+	for i, acc := range accounts {
+		data, err := json.Marshal(acc)
+		if err != nil {
+			return nil, err
+		}
+		leaves[i] = data
+	}
+	return leaves, nil
+}
+
+func newAccountsSection(accounts []Account) (StateSection, error) {
+	data, err := json.Marshal(accounts)
+	if err != nil {
+		return StateSection{}, err
+	}
+	leaves, err := accountLeaves(accounts)
+	if err != nil {
+		return StateSection{}, err
+	}
+	return StateSection{Name: StateSectionAccounts, Consensus: true, Hash: proof.MerkleRoot(leaves), Data: data}, nil
+}
+
+// sectionHash recomputes a section's hash from its data.
+func sectionHash(sec StateSection) (string, error) {
+	if sec.Name == StateSectionAccounts && sec.Consensus {
+		var accounts []Account
+		if err := json.Unmarshal(sec.Data, &accounts); err != nil {
+			return "", err
+		}
+		leaves, err := accountLeaves(accounts)
+		if err != nil {
+			return "", err
+		}
+		return proof.MerkleRoot(leaves), nil
+	}
+	sum := sha256.Sum256(sec.Data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// exportConsensusLocked serialises the consensus sections in a canonical
+// (sorted) order. It expects c.mu to be held.
+func (c *Chain) exportConsensusLocked() ([]StateSection, error) {
+	ctx := context.Background()
+
+	accounts := c.store.ExportAccounts()
+
+	stakes, err := c.db.ListStakes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("export stakes: %w", err)
+	}
+	sort.Slice(stakes, func(i, j int) bool {
+		if stakes[i].StakerWallet != stakes[j].StakerWallet {
+			return stakes[i].StakerWallet < stakes[j].StakerWallet
+		}
+		return stakes[i].ValidatorID < stakes[j].ValidatorID
+	})
+
+	nodes, err := c.db.ListPoPNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("export pop nodes: %w", err)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeID < nodes[j].NodeID })
+	caps, err := c.db.ListPoPCapabilities(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("export pop caps: %w", err)
+	}
+	sort.Slice(caps, func(i, j int) bool { return caps[i].NodeID < caps[j].NodeID })
+
+	accSec, err := newAccountsSection(accounts)
+	if err != nil {
+		return nil, err
+	}
+	out := []StateSection{accSec}
+	for _, s := range []struct {
+		name string
+		v    interface{}
+	}{
+		{StateSectionStaking, stakingState{Stakes: nonNil(stakes)}},
+		{StateSectionPoP, popState{Nodes: nonNil(nodes), Caps: nonNil(caps)}},
+	} {
+		sec, err := newStateSection(s.name, true, s.v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sec)
+	}
+	return out, nil
+}
+
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// ComputeStateRoot folds the consensus section hashes (by name) into a
+// single root.
+func ComputeStateRoot(sections []StateSection) string {
+	return proof.StateRoot(consensusHashes(sections))
+}
+
+func consensusHashes(sections []StateSection) map[string]string {
+	out := make(map[string]string, len(sections))
+	for _, s := range sections {
+		if s.Consensus {
+			out[s.Name] = s.Hash
+		}
+	}
+	return out
+}
+
+// VerifyStateSnapshot checks every section against its hash and the
+// consensus sections against the snapshot's state root.
+func VerifyStateSnapshot(s *StateSnapshot) error {
+	seen := make(map[string]bool)
+	for _, sec := range s.Sections {
+		hash, err := sectionHash(sec)
+		if err != nil {
+			return fmt.Errorf("section %s: %w", sec.Name, err)
+		}
+		if hash != sec.Hash {
+			return fmt.Errorf("section %s: hash mismatch", sec.Name)
+		}
+		seen[sec.Name] = sec.Consensus
+	}
+	for _, name := range []string{StateSectionAccounts, StateSectionStaking, StateSectionPoP} {
+		if !seen[name] {
+			return fmt.Errorf("section %s missing", name)
+		}
+	}
+	if root := ComputeStateRoot(s.Sections); root != s.StateRoot {
+		return fmt.Errorf("state root mismatch: computed %s, snapshot %s", root, s.StateRoot)
+	}
+	return nil
+}
+
+// Header returns the block in the wire form light clients verify, with Tx
+// as the exact payload that was mined. Blocks decoded from JSON keep Tx as
+// raw bytes for this reason.
+func (b *Block) Header() (proof.BlockHeader, error) {
+	h := proof.BlockHeader{
+		Height:     b.Height,
+		PrevHash:   b.PrevHash,
+		Hash:       b.Hash,
+		Timestamp:  b.Timestamp,
+		TxType:     b.TxType,
+		Nonce:      b.Nonce,
+		Difficulty: b.Difficulty,
+		StateRoot:  b.StateRoot,
+	}
+	if raw, ok := b.Tx.(json.RawMessage); ok {
+		h.Tx = raw
+		return h, nil
+	}
+	payload, err := json.Marshal(b.Tx)
+	if err != nil {
+		return h, err
+	}
+	h.Tx = payload
+	return h, nil
+}
+
+// VerifyBlockPoW recomputes a block's header hash and checks it against
+// the claimed hash and difficulty.
+func VerifyBlockPoW(blk *Block) error {
+	if blk == nil {
+		return errors.New("nil block")
+	}
+	h, err := blk.Header()
+	if err != nil {
+		return err
+	}
+	return h.VerifyPoW()
+}
+
+// maybeSnapshotLocked records a snapshot if blk lands on the configured
+// interval. sections are the consensus sections blk's root was computed
+// from. The write happens in the background, outside the chain lock.
+func (c *Chain) maybeSnapshotLocked(ctx context.Context, blk *Block, sections []StateSection) {
+	if c.snapInterval == 0 || c.db == nil || blk.Height == 0 || blk.Height%c.snapInterval != 0 {
+		return
+	}
+	extras := make(map[string]ExtraStateSection, len(c.extraSections))
+	for k, v := range c.extraSections {
+		extras[k] = v
+	}
+	keep := c.snapKeep
+	snap := StateSnapshot{
+		Height:    blk.Height,
+		BlockHash: blk.Hash,
+		StateRoot: blk.StateRoot,
+		Sections:  append([]StateSection(nil), sections...),
+	}
+	go func() {
+		names := make([]string, 0, len(extras))
+		for name := range extras {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			data, err := extras[name].Export()
+			if err != nil {
+				snapshotLog.ErrorContext(ctx, "export section failed", "section", name, "height", snap.Height, logging.Err(err))
+				continue
+			}
+			sec, err := newStateSection(name, false, data)
+			if err != nil {
+				continue
+			}
+			snap.Sections = append(snap.Sections, sec)
+		}
+		data, err := json.Marshal(snap)
+		if err != nil {
+			snapshotLog.ErrorContext(ctx, "encode failed", "height", snap.Height, logging.Err(err))
+			return
+		}
+		if err := c.db.SaveStateSnapshot(ctx, store.StateSnapshotRow{
+			Height:    snap.Height,
+			BlockHash: snap.BlockHash,
+			StateRoot: snap.StateRoot,
+			Data:      data,
+		}, keep); err != nil {
+			store.LogWriteError(ctx, "save_state_snapshot", err)
+			return
+		}
+		snapshotLog.InfoContext(ctx, "recorded state snapshot", "height", snap.Height, "bytes", len(data))
+	}()
+}
+
+// RestoreSnapshot replaces the local chain with the verified snapshot. The
+// anchor block (at snap.Height) must carry valid PoW and commit to the
+// snapshot's state root; it becomes the first block of the local chain and
+// sync continues from the height after it. data is the encoded snapshot,
+// stored locally so the node can restart from it.
+func (c *Chain) RestoreSnapshot(snap *StateSnapshot, anchor *Block, data []byte) error {
+	if err := VerifyBlockPoW(anchor); err != nil {
+		return err
+	}
+	if anchor.Height != snap.Height || anchor.Hash != snap.BlockHash {
+		return errors.New("anchor block does not match snapshot")
+	}
+	if anchor.StateRoot == "" || anchor.StateRoot != snap.StateRoot {
+		return errors.New("snapshot state root not committed by anchor block")
+	}
+	if err := VerifyStateSnapshot(snap); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	ctx := logging.WithTx(context.Background(), anchor.Hash)
+	var imports []func() error
+	for _, sec := range snap.Sections {
+		sec := sec
+		switch sec.Name {
+		case StateSectionAccounts:
+			var accounts []Account
+			if err := json.Unmarshal(sec.Data, &accounts); err != nil {
+				c.mu.Unlock()
+				return fmt.Errorf("decode accounts: %w", err)
+			}
+			imports = append(imports, func() error { c.store.Restore(accounts); return nil })
+		case StateSectionStaking:
+			var st stakingState
+			if err := json.Unmarshal(sec.Data, &st); err != nil {
+				c.mu.Unlock()
+				return fmt.Errorf("decode staking: %w", err)
+			}
+			imports = append(imports, func() error { return c.db.ReplaceStakes(ctx, st.Stakes) })
+		case StateSectionPoP:
+			var ps popState
+			if err := json.Unmarshal(sec.Data, &ps); err != nil {
+				c.mu.Unlock()
+				return fmt.Errorf("decode pop: %w", err)
+			}
+			imports = append(imports, func() error { return c.db.ReplacePoPRegistry(ctx, ps.Nodes, ps.Caps) })
+		}
+	}
+	if err := c.db.ResetChainLog(ctx); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	for _, fn := range imports {
+		if err := fn(); err != nil {
+			c.mu.Unlock()
+			return err
+		}
+	}
+	c.blocks = []*Block{anchor}
+	c.byHash = nil
+	c.indexBlockLocked(anchor)
+	c.base = anchor.Height
+	c.recordStateLocked(ctx, anchor.Height)
+	c.observeTipLocked("")
+	c.tip = committedState{block: anchor, sections: consensusSections(snap.Sections)}
+	c.pendingTxs = c.pendingTxs[:0]
+	store.LogWriteError(ctx, "insert_block", c.db.InsertBlockAndTxAt(ctx, anchor.Timestamp, anchor.Hash, anchor.PrevHash, anchor.Height, anchor.TxType, anchor.Nonce, anchor.Difficulty, anchor.StateRoot, anchor.Tx))
+	store.LogWriteError(ctx, "save_state_snapshot", c.db.SaveStateSnapshot(ctx, store.StateSnapshotRow{
+		Height:    snap.Height,
+		BlockHash: snap.BlockHash,
+		StateRoot: snap.StateRoot,
+		Data:      data,
+	}, c.snapKeep))
+	extras := c.extraSections
+	c.mu.Unlock()
+
+	// Node-local sections are applied outside the chain lock.
+	for _, sec := range snap.Sections {
+		if sec.Consensus {
+			continue
+		}
+		if ex, ok := extras[sec.Name]; ok && ex.Import != nil {
+			if err := ex.Import(sec.Data); err != nil {
+				snapshotLog.ErrorContext(ctx, "import section failed", "section", sec.Name, logging.Err(err))
+			}
+		}
+	}
+	return nil
+}
+
+// loadBaseSnapshot restores account state from the local snapshot the
+// chain log starts at (a node that was bootstrapped via snapshot sync).
+// Staking and PoP rows already live in the DB and are left untouched.
+func (c *Chain) loadBaseSnapshot(ctx context.Context, height uint64) error {
+	row, err := c.db.GetStateSnapshot(ctx, height)
+	if err != nil {
+		return err
+	}
+	var snap StateSnapshot
+	if err := json.Unmarshal(row.Data, &snap); err != nil {
+		return err
+	}
+	if err := VerifyStateSnapshot(&snap); err != nil {
+		return err
+	}
+	for _, sec := range snap.Sections {
+		if sec.Name != StateSectionAccounts {
+			continue
+		}
+		var accounts []Account
+		if err := json.Unmarshal(sec.Data, &accounts); err != nil {
+			return err
+		}
+		c.store.Restore(accounts)
+	}
+	return nil
+}
+
+func consensusSections(sections []StateSection) []StateSection {
+	out := make([]StateSection, 0, len(sections))
+	for _, sec := range sections {
+		if sec.Consensus {
+			out = append(out, sec)
+		}
+	}
+	return out
+}
+
+// committedStateAt returns the consensus state committed by the block at
+// height: the last block this node sealed or verified, or a stored snapshot.
+func (c *Chain) committedStateAt(ctx context.Context, height uint64) (committedState, error) {
+	c.mu.RLock()
+	tip := c.tip
+	db := c.db
+	c.mu.RUnlock()
+	if tip.block != nil && tip.block.Height == height {
+		return tip, nil
+	}
+	row, err := db.GetStateSnapshot(ctx, height)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return committedState{}, ErrStateUnavailable
+		}
+		return committedState{}, err
+	}
+	var snap StateSnapshot
+	if err := json.Unmarshal(row.Data, &snap); err != nil {
+		return committedState{}, err
+	}
+	blk := &Block{Height: snap.Height, Hash: snap.BlockHash, StateRoot: snap.StateRoot}
+	return committedState{block: blk, sections: consensusSections(snap.Sections)}, nil
+}
+
+// AccountProof returns a Merkle proof of addr's balances and nonce against
+// the state root of the block at height.
+func (c *Chain) AccountProof(ctx context.Context, addr string, height uint64) (*proof.AccountProof, error) {
+	st, err := c.committedStateAt(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	var accounts []Account
+	for _, sec := range st.sections {
+		if sec.Name == StateSectionAccounts {
+			if err := json.Unmarshal(sec.Data, &accounts); err != nil {
+				return nil, err
+			}
+		}
+	}
+	idx := sort.Search(len(accounts), func(i int) bool { return accounts[i].Address >= addr })
+	if idx == len(accounts) || accounts[idx].Address != addr {
+		return nil, ErrAccountNotFound
+	}
+	leaves, err := accountLeaves(accounts)
+	if err != nil {
+		return nil, err
+	}
+	steps, err := proof.MerkleProof(leaves, idx)
+	if err != nil {
+		return nil, err
+	}
+	return &proof.AccountProof{
+		Height:    st.block.Height,
+		BlockHash: st.block.Hash,
+		StateRoot: st.block.StateRoot,
+		Account:   leaves[idx],
+		Steps:     steps,
+		Sections:  consensusHashes(st.sections),
+	}, nil
+}
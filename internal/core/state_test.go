@@ -0,0 +1,115 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func testSnapshot(t *testing.T) StateSnapshot {
+	t.Helper()
+	acc, err := newAccountsSection([]Account{
+		{Address: "rc:a", Balances: Balances{"GRC": 10}, Nonce: 1},
+		{Address: "rc:b", Balances: Balances{"USDC": 5}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	staking, err := newStateSection(StateSectionStaking, true, stakingState{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pop, err := newStateSection(StateSectionPoP, true, popState{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	econ, err := newStateSection("econ", false, map[string]float64{"nav": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections := []StateSection{acc, staking, pop, econ}
+	return StateSnapshot{Height: 10, BlockHash: "00ab", StateRoot: ComputeStateRoot(sections), Sections: sections}
+}
+
+func TestComputeStateRoot(t *testing.T) {
+	snap := testSnapshot(t)
+	root := ComputeStateRoot(snap.Sections)
+
+	reversed := make([]StateSection, len(snap.Sections))
+	for i, sec := range snap.Sections {
+		reversed[len(reversed)-1-i] = sec
+	}
+	if got := ComputeStateRoot(reversed); got != root {
+		t.Errorf("root depends on section order: %s vs %s", got, root)
+	}
+	if got := ComputeStateRoot(consensusSections(snap.Sections)); got != root {
+		t.Errorf("node-local sections change the root: %s vs %s", got, root)
+	}
+	changed := append([]StateSection(nil), snap.Sections...)
+	changed[1].Hash = strings.Repeat("0", 64)
+	if ComputeStateRoot(changed) == root {
+		t.Error("changing a consensus section hash keeps the root")
+	}
+}
+
+func TestVerifyStateSnapshot(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(s *StateSnapshot)
+		wantErr string
+	}{
+		{"valid", func(s *StateSnapshot) {}, ""},
+		{"account balance edited", func(s *StateSnapshot) {
+			s.Sections[0].Data = json.RawMessage(`[{"address":"rc:a","balances":{"GRC":1000},"nonce":1},{"address":"rc:b","balances":{"USDC":5},"nonce":0}]`)
+		}, "accounts: hash mismatch"},
+		{"account section rehashed", func(s *StateSnapshot) {
+			sec, _ := newAccountsSection([]Account{{Address: "rc:a", Balances: Balances{"GRC": 1000}}})
+			s.Sections[0] = sec
+		}, "state root mismatch"},
+		{"staking data edited", func(s *StateSnapshot) {
+			s.Sections[1].Data = json.RawMessage(`{"stakes":[{"staker_wallet":"rc:x"}]}`)
+		}, "staking: hash mismatch"},
+		{"wrong root", func(s *StateSnapshot) { s.StateRoot = strings.Repeat("0", 64) }, "state root mismatch"},
+		{"pop missing", func(s *StateSnapshot) { s.Sections = append(s.Sections[:2], s.Sections[3]) }, "pop missing"},
+		{"consensus section marked local", func(s *StateSnapshot) { s.Sections[2].Consensus = false }, "pop missing"},
+		{"node-local section edited", func(s *StateSnapshot) {
+			s.Sections[3].Data = json.RawMessage(`{"nav":2}`)
+		}, "econ: hash mismatch"},
+		{"accounts not JSON", func(s *StateSnapshot) { s.Sections[0].Data = json.RawMessage(`{`) }, "section accounts"},
+	}
+	for _, tt := range tests {
+		snap := testSnapshot(t)
+		tt.tamper(&snap)
+		err := VerifyStateSnapshot(&snap)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// BenchmarkAccountsStateRoot measures the accounts part of the work
+// appendBlockLocked does under c.mu for every sealed block: export every
+// account, encode the section and fold it into the state root.
+func BenchmarkAccountsStateRoot(b *testing.B) {
+	for _, n := range []int{1_000, 10_000, 100_000} {
+		s := NewAccountStore()
+		for i := 0; i < n; i++ {
+			addr := fmt.Sprintf("rc:%08d", i)
+			s.Credit(addr, "GRC", float64(i))
+			s.Credit(addr, "USDC", float64(i)/2)
+		}
+		b.Run(fmt.Sprintf("accounts=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sec, err := newAccountsSection(s.ExportAccounts())
+				if err != nil {
+					b.Fatal(err)
+				}
+				ComputeStateRoot([]StateSection{sec})
+			}
+		})
+	}
+}
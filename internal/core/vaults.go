@@ -1,6 +1,7 @@
 package core
 
 import (
+    "context"
     "fmt"
     "time"
 )
@@ -22,7 +23,7 @@ type TxVaultCreate struct {
 
 // ApplyVaultCreate appends a TX_VAULT_CREATE block. Actual vault balances
 // and policies are stored in the PHP-side vault_state.json for now.
-func (c *Chain) ApplyVaultCreate(tx TxVaultCreate) (*Block, string, error) {
+func (c *Chain) ApplyVaultCreate(ctx context.Context, tx TxVaultCreate) (*Block, string, error) {
     c.mu.Lock()
     defer c.mu.Unlock()
 
@@ -36,7 +37,7 @@ func (c *Chain) ApplyVaultCreate(tx TxVaultCreate) (*Block, string, error) {
         tx.Timestamp = time.Now().UTC().Unix()
     }
 
-    blk := c.appendBlockLocked("TX_VAULT_CREATE", tx)
+    blk := c.appendBlockLocked(ctx, "TX_VAULT_CREATE", tx)
     return blk, blk.Hash, nil
 }
 
@@ -75,7 +76,7 @@ type TxVaultTransfer struct {
 }
 
 // ApplyVaultDeposit debits the user's wallet and credits the vault pseudo-address.
-func (c *Chain) ApplyVaultDeposit(tx TxVaultDeposit) (*Block, string, error) {
+func (c *Chain) ApplyVaultDeposit(ctx context.Context, tx TxVaultDeposit) (*Block, string, error) {
     c.mu.Lock()
     defer c.mu.Unlock()
 
@@ -100,12 +101,12 @@ func (c *Chain) ApplyVaultDeposit(tx TxVaultDeposit) (*Block, string, error) {
     }
     c.store.Credit(vaddr, tx.Asset, tx.Amount)
 
-    blk := c.appendBlockLocked("TX_VAULT_DEPOSIT", tx)
+    blk := c.appendBlockLocked(ctx, "TX_VAULT_DEPOSIT", tx)
     return blk, blk.Hash, nil
 }
 
 // ApplyVaultWithdraw debits the vault pseudo-address and credits the user's wallet.
-func (c *Chain) ApplyVaultWithdraw(tx TxVaultWithdraw) (*Block, string, error) {
+func (c *Chain) ApplyVaultWithdraw(ctx context.Context, tx TxVaultWithdraw) (*Block, string, error) {
     c.mu.Lock()
     defer c.mu.Unlock()
 
@@ -130,12 +131,12 @@ func (c *Chain) ApplyVaultWithdraw(tx TxVaultWithdraw) (*Block, string, error) {
     }
     c.store.Credit(tx.To, tx.Asset, tx.Amount)
 
-    blk := c.appendBlockLocked("TX_VAULT_WITHDRAW", tx)
+    blk := c.appendBlockLocked(ctx, "TX_VAULT_WITHDRAW", tx)
     return blk, blk.Hash, nil
 }
 
 // ApplyVaultTransfer moves funds between two vault pseudo-addresses.
-func (c *Chain) ApplyVaultTransfer(tx TxVaultTransfer) (*Block, string, error) {
+func (c *Chain) ApplyVaultTransfer(ctx context.Context, tx TxVaultTransfer) (*Block, string, error) {
     c.mu.Lock()
     defer c.mu.Unlock()
 
@@ -165,7 +166,7 @@ func (c *Chain) ApplyVaultTransfer(tx TxVaultTransfer) (*Block, string, error) {
     }
     c.store.Credit(toAddr, tx.Asset, tx.Amount)
 
-    blk := c.appendBlockLocked("TX_VAULT_TRANSFER", tx)
+    blk := c.appendBlockLocked(ctx, "TX_VAULT_TRANSFER", tx)
     return blk, blk.Hash, nil
 }
 
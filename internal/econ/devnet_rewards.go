@@ -1,10 +1,10 @@
 package econ
 
 import (
-    "log"
     "time"
 
     "reservechain/internal/core"
+    "reservechain/internal/logging"
 )
 
 // DevnetRewardLoopConfig controls the behaviour of the standalone
@@ -55,7 +55,7 @@ func RunDevnetRewardLoop(chain *core.Chain, nodeID string, stopCh <-chan struct{
     ticker := time.NewTicker(time.Duration(cfg.EpochSeconds) * time.Second)
     defer ticker.Stop()
 
-    log.Printf("[devnet-rewards] starting reward loop: epoch=%ds treasury=%q", cfg.EpochSeconds, cfg.TreasuryAddr)
+    rewardsLog.Info("starting reward loop", "epoch_seconds", cfg.EpochSeconds, "treasury", cfg.TreasuryAddr)
 
     var epochIndex uint64
     var lastEpochEnd int64 = time.Now().Unix()
@@ -63,7 +63,7 @@ func RunDevnetRewardLoop(chain *core.Chain, nodeID string, stopCh <-chan struct{
     for {
         select {
         case <-stopCh:
-            log.Printf("[devnet-rewards] stopping reward loop")
+            rewardsLog.Info("stopping reward loop")
             return
         case now := <-ticker.C:
             epochIndex++
@@ -116,12 +116,12 @@ func RunDevnetRewardLoop(chain *core.Chain, nodeID string, stopCh <-chan struct{
                 treasuryBudget,
             )
             if err != nil {
-                log.Printf("[devnet-rewards] ApplyRewardTx failed: %v", err)
+                rewardsLog.Error("apply reward tx failed", "epoch", epochIndex, logging.Err(err))
                 continue
             }
 
-            log.Printf("[devnet-rewards] epoch=%d total=%.4f op=%.4f treasury=%.4f block=%s logIndex=%d",
-                epochIndex, totalBudget, opBudget, treasuryBudget, hash, blk.Index)
+            rewardsLog.Info("epoch rewarded", "epoch", epochIndex, "total", totalBudget,
+                "operators", opBudget, "treasury", treasuryBudget, "tx_hash", hash, "log_index", blk.Index)
         }
     }
 }
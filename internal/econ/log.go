@@ -0,0 +1,8 @@
+package econ
+
+import "reservechain/internal/logging"
+
+var (
+	econLog    = logging.For("econ")
+	rewardsLog = logging.For("devnet-rewards")
+)
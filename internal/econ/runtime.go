@@ -24,12 +24,16 @@ var (
 )
 
 // SetRuntime wires the chain + DB into the econ package so that
-// epoch settlement helpers can apply staking + PoP payouts.
+// epoch settlement helpers can apply staking + PoP payouts. It also
+// registers the econ state as a snapshot section on the chain.
 func SetRuntime(chain *core.Chain, db *store.DB) {
 	rtMu.Lock()
 	defer rtMu.Unlock()
 	rtChain = chain
 	rtDB = db
+	if chain != nil {
+		chain.RegisterStateSection("econ", econStateSection())
+	}
 }
 
 func runtimeChain() *core.Chain {
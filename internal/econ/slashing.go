@@ -194,7 +194,7 @@ func RecordPoPSlashingEvent(ctx context.Context, db *store.DB, epoch int64, node
 		sev = "critical"
 		status = "applied"
 	}
-	_ = db.InsertSlashingEvent(ctx, store.SlashingEvent{
+	store.LogWriteError(ctx, "insert_slashing_event", db.InsertSlashingEvent(ctx, store.SlashingEvent{
 		Epoch:         epoch,
 		SubjectType:   "pop_node",
 		SubjectID:     nodeID,
@@ -205,5 +205,5 @@ func RecordPoPSlashingEvent(ctx context.Context, db *store.DB, epoch int64, node
 		ReasonDetail:  r.ReasonDetail,
 		Evidence:      evJSON,
 		Status:        status,
-	})
+	}))
 }
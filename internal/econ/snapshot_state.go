@@ -0,0 +1,39 @@
+package econ
+
+import (
+	"encoding/json"
+
+	"reservechain/internal/core"
+)
+
+// econSnapshot is the econ state carried in chain snapshots. It is
+// node-local (advanced by leader ticks, not by blocks), so it travels as a
+// non-consensus section and is not covered by the block state root.
+type econSnapshot struct {
+	DevnetEpoch int64                `json:"devnet_epoch"`
+	Mainnet     MainnetMonetaryState `json:"mainnet"`
+}
+
+func econStateSection() core.ExtraStateSection {
+	return core.ExtraStateSection{
+		Export: func() (json.RawMessage, error) {
+			return json.Marshal(econSnapshot{
+				DevnetEpoch: CurrentDevnetEpoch(),
+				Mainnet:     SnapshotMainnetState(),
+			})
+		},
+		Import: func(data json.RawMessage) error {
+			var s econSnapshot
+			if err := json.Unmarshal(data, &s); err != nil {
+				return err
+			}
+			SetMainnetState(s.Mainnet)
+			if s.DevnetEpoch > 0 {
+				redeemMu.Lock()
+				devnetCurrentEpoch = s.DevnetEpoch
+				redeemMu.Unlock()
+			}
+			return nil
+		},
+	}
+}
@@ -2,7 +2,6 @@ package econ
 
 import (
 	"context"
-	"log"
 	"math"
 	"time"
 
@@ -10,6 +9,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reservechain/internal/core"
+	"reservechain/internal/logging"
 	"reservechain/internal/store"
 	"sort"
 )
@@ -33,14 +33,14 @@ func SettleEpochRewardsDevnet(epochIndex uint64, stakeBudgetGRC, popBudgetGRC, t
 	// by total delegated RSX, then apply validator commission and pay delegators.
 	if stakeBudgetGRC > 0 {
 		if err := applyStakeRewards(ctx, chain, db, int64(epochIndex), stakeBudgetGRC); err != nil {
-			log.Printf("[econ] stake settle epoch=%d failed: %v", epochIndex, err)
+			econLog.ErrorContext(ctx, "stake settle failed", "epoch", epochIndex, logging.Err(err))
 		}
 	}
 
 	// 2) PoP rewards: compute node work score for the epoch and split popBudget across nodes.
 	if popBudgetGRC > 0 {
 		if err := applyPoPRewards(ctx, chain, db, int64(epochIndex), popBudgetGRC); err != nil {
-			log.Printf("[econ] pop settle epoch=%d failed: %v", epochIndex, err)
+			econLog.ErrorContext(ctx, "pop settle failed", "epoch", epochIndex, logging.Err(err))
 		}
 	}
 
@@ -49,7 +49,7 @@ func SettleEpochRewardsDevnet(epochIndex uint64, stakeBudgetGRC, popBudgetGRC, t
 		// Treasury address is hard-coded to "treasury" in devnet.
 		chain.Store().Credit("treasury", "GRC", treasuryBudgetGRC)
 		if db != nil {
-			_ = db.InsertEpochPayout(ctx, store.EpochPayout{
+			store.LogWriteError(ctx, "insert_epoch_payout", db.InsertEpochPayout(ctx, store.EpochPayout{
 				Epoch:     int64(epochIndex),
 				Kind:      "treasury",
 				Recipient: "treasury",
@@ -59,14 +59,17 @@ func SettleEpochRewardsDevnet(epochIndex uint64, stakeBudgetGRC, popBudgetGRC, t
 					"note": "devnet issuance treasury share",
 				},
 				CreatedAt: time.Now().UTC(),
-			})
+			}))
 
 			// 4) Record an on-chain commitment to the payout ledger for auditability.
 			if db != nil {
-				if payoutHash, nPayouts, err := computeEpochPayoutCommit(ctx, db, int64(epochIndex)); err == nil && payoutHash != "" {
+				payoutHash, nPayouts, err := computeEpochPayoutCommit(ctx, db, int64(epochIndex))
+				if err != nil {
+					econLog.ErrorContext(ctx, "payout commitment failed", "epoch", epochIndex, logging.Err(err))
+				} else if payoutHash != "" {
 					author := "econ"
 					nonce := chain.Store().GetNonce(author) + 1
-					_, _, _ = chain.ApplyEpochPayoutCommit(core.EpochPayoutCommitTx{
+					_, txHash, err := chain.ApplyEpochPayoutCommit(ctx, core.EpochPayoutCommitTx{
 						EpochIndex:        epochIndex,
 						Author:            author,
 						PayoutHashHex:     payoutHash,
@@ -76,6 +79,12 @@ func SettleEpochRewardsDevnet(epochIndex uint64, stakeBudgetGRC, popBudgetGRC, t
 						TreasuryBudgetGRC: treasuryBudgetGRC,
 						Nonce:             nonce,
 					})
+					if err != nil {
+						econLog.ErrorContext(ctx, "payout commitment tx rejected", "epoch", epochIndex, logging.Err(err))
+					} else {
+						econLog.InfoContext(logging.WithTx(ctx, txHash), "payout commitment recorded",
+							"epoch", epochIndex, "payouts", nPayouts)
+					}
 				}
 			}
 		}
@@ -153,11 +162,11 @@ func applyStakeRewards(ctx context.Context, chain *core.Chain, db *store.DB, epo
 		// Pay commission to operator wallet if present.
 		if v.OperatorWallet != "" && commission > 0 {
 			chain.Store().Credit(v.OperatorWallet, "GRC", commission)
-			_ = db.InsertEpochPayout(ctx, store.EpochPayout{
+			store.LogWriteError(ctx, "insert_epoch_payout", db.InsertEpochPayout(ctx, store.EpochPayout{
 				Epoch: epoch, Kind: "stake", Recipient: v.OperatorWallet, AssetCode: "GRC", Amount: commission,
 				Meta:      map[string]any{"validator_id": vid, "role": "commission", "commission_bps": commissionBps},
 				CreatedAt: time.Now().UTC(),
-			})
+			}))
 		}
 
 		// Pay delegators (including validator self, if they stake via same wallet) proportional to RSX.
@@ -186,11 +195,11 @@ func applyStakeRewards(ctx context.Context, chain *core.Chain, db *store.DB, epo
 				continue
 			}
 			chain.Store().Credit(s.StakerWallet, "GRC", amt)
-			_ = db.InsertEpochPayout(ctx, store.EpochPayout{
+			store.LogWriteError(ctx, "insert_epoch_payout", db.InsertEpochPayout(ctx, store.EpochPayout{
 				Epoch: epoch, Kind: "stake", Recipient: s.StakerWallet, AssetCode: "GRC", Amount: amt,
 				Meta:      map[string]any{"validator_id": vid, "role": "delegator", "staked_rsx": s.AmountRSX},
 				CreatedAt: time.Now().UTC(),
-			})
+			}))
 		}
 	}
 	return nil
@@ -321,7 +330,7 @@ func applyPoPRewards(ctx context.Context, chain *core.Chain, db *store.DB, epoch
 		if slashed > 0 {
 			chain.Store().Credit("treasury", "GRC", slashed)
 		}
-		_ = db.InsertEpochPayout(ctx, store.EpochPayout{
+		store.LogWriteError(ctx, "insert_epoch_payout", db.InsertEpochPayout(ctx, store.EpochPayout{
 			Epoch: epoch, Kind: "pop", Recipient: node.OperatorWallet, AssetCode: "GRC", Amount: reward,
 			Meta: map[string]any{
 				"node_id":           n.NodeID,
@@ -333,7 +342,7 @@ func applyPoPRewards(ctx context.Context, chain *core.Chain, db *store.DB, epoch
 				"anomaly_code":      an.ReasonCode,
 			},
 			CreatedAt: time.Now().UTC(),
-		})
+		}))
 	}
 	return nil
 }
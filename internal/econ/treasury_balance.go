@@ -125,6 +125,12 @@ func getDevnetPriceMap() map[CryptoAssetKind]float64 {
     }
 }
 
+// DevnetPriceUSD returns the DevNet mark price of asset, if it has one.
+func DevnetPriceUSD(asset string) (float64, bool) {
+    p, ok := getDevnetPriceMap()[CryptoAssetKind(asset)]
+    return p, ok
+}
+
 // SnapshotTreasury builds a point-in-time balance sheet using the
 // current in-memory DevNet model plus mark-to-market pricing.
 func SnapshotTreasury() TreasuryBalanceSheet {
@@ -0,0 +1,142 @@
+package identity
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NodeIDPrefix marks node identities derived from an ed25519 public key.
+const NodeIDPrefix = "rcn1"
+
+// NodeKey is a node's persisted ed25519 keypair. The node ID is derived
+// from the public key so it is stable across restarts and cannot be claimed
+// by a process that does not hold the private key.
+type NodeKey struct {
+	priv ed25519.PrivateKey
+	pub  ed25519.PublicKey
+	id   string
+}
+
+type nodeKeyFile struct {
+	NodeID     string `json:"node_id"`
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key"` // hex ed25519 seed
+}
+
+// LoadOrCreateNodeKey reads the keypair at path, generating and writing a
+// new one (mode 0600) if the file does not exist.
+func LoadOrCreateNodeKey(path string) (*NodeKey, error) {
+	k, err := LoadNodeKey(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return GenerateNodeKey(path)
+	}
+	return k, err
+}
+
+// LoadNodeKey reads the keypair at path. A missing file is reported as an
+// error wrapping os.ErrNotExist.
+func LoadNodeKey(path string) (*NodeKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f nodeKeyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("node key %s: %w", path, err)
+	}
+	seed, err := hex.DecodeString(f.PrivateKey)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("node key %s: invalid private_key", path)
+	}
+	return newNodeKey(ed25519.NewKeyFromSeed(seed)), nil
+}
+
+// GenerateNodeKey generates a keypair and writes it to path (mode 0600),
+// replacing any key already there.
+func GenerateNodeKey(path string) (*NodeKey, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	k := newNodeKey(priv)
+	out, _ := json.MarshalIndent(nodeKeyFile{
+		NodeID:     k.id,
+		PublicKey:  k.PublicKeyHex(),
+		PrivateKey: hex.EncodeToString(priv.Seed()),
+	}, "", "  ")
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.WriteFile(path, out, 0o600); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func newNodeKey(priv ed25519.PrivateKey) *NodeKey {
+	pub := priv.Public().(ed25519.PublicKey)
+	return &NodeKey{priv: priv, pub: pub, id: nodeIDFromPub(pub)}
+}
+
+// ID returns the node ID derived from the public key.
+func (k *NodeKey) ID() string { return k.id }
+
+// PublicKeyHex returns the hex-encoded ed25519 public key.
+func (k *NodeKey) PublicKeyHex() string { return hex.EncodeToString(k.pub) }
+
+// Sign signs msg and returns the hex-encoded signature.
+func (k *NodeKey) Sign(msg []byte) string {
+	return hex.EncodeToString(ed25519.Sign(k.priv, msg))
+}
+
+// NodeIDFromPublicKey derives the node ID for a hex-encoded public key.
+func NodeIDFromPublicKey(pubHex string) (string, error) {
+	pub, err := decodePub(pubHex)
+	if err != nil {
+		return "", err
+	}
+	return nodeIDFromPub(pub), nil
+}
+
+// VerifyNodeSignature checks that sigHex is a valid signature of msg by the
+// key pubHex, and that the key derives nodeID.
+func VerifyNodeSignature(nodeID, pubHex string, msg []byte, sigHex string) error {
+	pub, err := decodePub(pubHex)
+	if err != nil {
+		return err
+	}
+	if nodeIDFromPub(pub) != nodeID {
+		return errors.New("node_id does not match public key")
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(sigHex, "0x"))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return errors.New("invalid node signature encoding")
+	}
+	if !ed25519.Verify(pub, msg, sig) {
+		return errors.New("invalid node signature")
+	}
+	return nil
+}
+
+func decodePub(pubHex string) (ed25519.PublicKey, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(pubHex, "0x"))
+	if err != nil || len(b) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid node public key")
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+func nodeIDFromPub(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return NodeIDPrefix + hex.EncodeToString(sum[:20])
+}
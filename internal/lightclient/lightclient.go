@@ -0,0 +1,259 @@
+// Package lightclient verifies data served by an untrusted ReserveChain
+// node. It keeps a chain of block headers whose PoW and linkage it checked
+// itself, and only accepts account and tx proofs that resolve to one of
+// those headers.
+package lightclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"reservechain/internal/proof"
+)
+
+// Account is a verified account state.
+type Account struct {
+	Address  string             `json:"address"`
+	Balances map[string]float64 `json:"balances"`
+	Nonce    uint64             `json:"nonce"`
+}
+
+// VerifyHeaders checks the PoW of every header and that each one links to
+// the one before it, starting from prev (nil when headers start at the
+// first block the client knows). Headers claiming less than minDifficulty
+// are rejected whatever their own claim, so a peer cannot pass off a
+// cheaply mined chain; zero means proof.MinDifficulty.
+func VerifyHeaders(prev *proof.BlockHeader, headers []proof.BlockHeader, minDifficulty uint32) error {
+	for i := range headers {
+		h := headers[i]
+		if err := verifyHeader(h, minDifficulty); err != nil {
+			return err
+		}
+		if prev != nil {
+			if h.Height != prev.Height+1 {
+				return fmt.Errorf("block %d: expected height %d", h.Height, prev.Height+1)
+			}
+			if h.PrevHash != prev.Hash {
+				return fmt.Errorf("block %d: prev_hash does not link to %s", h.Height, prev.Hash)
+			}
+		}
+		prev = &headers[i]
+	}
+	return nil
+}
+
+func verifyHeader(h proof.BlockHeader, minDifficulty uint32) error {
+	if minDifficulty < proof.MinDifficulty {
+		minDifficulty = proof.MinDifficulty
+	}
+	if h.Difficulty < minDifficulty {
+		return fmt.Errorf("block %d: difficulty %d below the required %d", h.Height, h.Difficulty, minDifficulty)
+	}
+	return h.VerifyPoW()
+}
+
+// VerifyAccountProof checks p against a verified header and decodes the
+// proven account.
+func VerifyAccountProof(h proof.BlockHeader, p proof.AccountProof) (*Account, error) {
+	if p.Height != h.Height || p.BlockHash != h.Hash {
+		return nil, errors.New("proof is for a different block")
+	}
+	if h.StateRoot == "" || p.StateRoot != h.StateRoot {
+		return nil, errors.New("proof state root not committed by header")
+	}
+	if err := p.Verify(); err != nil {
+		return nil, err
+	}
+	var acc Account
+	if err := json.Unmarshal(p.Account, &acc); err != nil {
+		return nil, fmt.Errorf("decode account: %w", err)
+	}
+	return &acc, nil
+}
+
+// VerifyTxProof checks p and that its block is the verified header h.
+func VerifyTxProof(h proof.BlockHeader, p proof.TxProof) error {
+	if err := p.Verify(); err != nil {
+		return err
+	}
+	if p.Block.Height != h.Height || p.Block.Hash != h.Hash {
+		return errors.New("tx block is not in the verified header chain")
+	}
+	return nil
+}
+
+// Config pins what the client trusts before it has seen any header.
+type Config struct {
+	// GenesisHash is the hash of block 0. Syncing from genesis is refused
+	// without it; a client that only syncs from a Trust checkpoint may
+	// leave it empty.
+	GenesisHash string
+	// MinDifficulty is the lowest difficulty any header may claim. Zero
+	// means proof.MinDifficulty.
+	MinDifficulty uint32
+}
+
+// Client talks to a single node and trusts nothing it returns without
+// checking it against the locally verified header chain.
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+	Config  Config
+
+	mu      sync.RWMutex
+	headers map[uint64]proof.BlockHeader
+	tip     *proof.BlockHeader
+}
+
+// New creates a client for the node at baseURL (e.g. "http://127.0.0.1:8080").
+func New(baseURL string, cfg Config) *Client {
+	return &Client{
+		BaseURL: baseURL,
+		HTTP:    &http.Client{Timeout: 10 * time.Second},
+		Config:  cfg,
+		headers: make(map[uint64]proof.BlockHeader),
+	}
+}
+
+// Trust sets a checkpoint header obtained out of band (another node, a
+// snapshot anchor, a config file). Header sync continues from it instead of
+// from genesis.
+func (c *Client) Trust(h proof.BlockHeader) error {
+	if err := verifyHeader(h, c.Config.MinDifficulty); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.headers[h.Height] = h
+	c.tip = &h
+	return nil
+}
+
+// Tip returns the highest verified header.
+func (c *Client) Tip() (proof.BlockHeader, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.tip == nil {
+		return proof.BlockHeader{}, false
+	}
+	return *c.tip, true
+}
+
+// Header returns the verified header at height.
+func (c *Client) Header(height uint64) (proof.BlockHeader, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	h, ok := c.headers[height]
+	return h, ok
+}
+
+// SyncHeaders downloads and verifies headers after the current tip and
+// returns the new tip height. Without a tip it needs a trust anchor: a
+// Trust checkpoint, or Config.GenesisHash to check block 0 against.
+func (c *Client) SyncHeaders(ctx context.Context) (uint64, error) {
+	if _, ok := c.Tip(); !ok && c.Config.GenesisHash == "" {
+		return 0, errors.New("no trust anchor; set Config.GenesisHash or call Trust first")
+	}
+	for {
+		c.mu.RLock()
+		var prev *proof.BlockHeader
+		from := uint64(0)
+		if c.tip != nil {
+			t := *c.tip
+			prev = &t
+			from = t.Height + 1
+		}
+		c.mu.RUnlock()
+
+		var page struct {
+			Blocks []proof.BlockHeader `json:"blocks"`
+		}
+		q := url.Values{"from_height": {strconv.FormatUint(from, 10)}, "limit": {"500"}}
+		if err := c.get(ctx, "/api/chain/blocks?"+q.Encode(), &page); err != nil {
+			return from, err
+		}
+		if len(page.Blocks) == 0 {
+			if prev == nil {
+				return 0, errors.New("node returned no headers")
+			}
+			return prev.Height, nil
+		}
+		if prev == nil {
+			if g := page.Blocks[0]; g.Height != 0 || g.Hash != c.Config.GenesisHash {
+				return 0, errors.New("node's first header is not the pinned genesis")
+			}
+		}
+		if err := VerifyHeaders(prev, page.Blocks, c.Config.MinDifficulty); err != nil {
+			return from, err
+		}
+
+		c.mu.Lock()
+		for _, h := range page.Blocks {
+			c.headers[h.Height] = h
+		}
+		last := page.Blocks[len(page.Blocks)-1]
+		c.tip = &last
+		c.mu.Unlock()
+	}
+}
+
+// Account fetches and verifies an account proof at height; height 0 means
+// the current verified tip.
+func (c *Client) Account(ctx context.Context, addr string, height uint64) (*Account, error) {
+	if height == 0 {
+		tip, ok := c.Tip()
+		if !ok {
+			return nil, errors.New("no verified headers; call SyncHeaders first")
+		}
+		height = tip.Height
+	}
+	h, ok := c.Header(height)
+	if !ok {
+		return nil, fmt.Errorf("header %d not verified", height)
+	}
+	var p proof.AccountProof
+	q := url.Values{"address": {addr}, "height": {strconv.FormatUint(height, 10)}}
+	if err := c.get(ctx, "/api/proof/account?"+q.Encode(), &p); err != nil {
+		return nil, err
+	}
+	return VerifyAccountProof(h, p)
+}
+
+// Tx fetches and verifies the inclusion proof of the tx with hash.
+func (c *Client) Tx(ctx context.Context, hash string) (*proof.TxProof, error) {
+	var p proof.TxProof
+	if err := c.get(ctx, "/api/proof/tx?hash="+url.QueryEscape(hash), &p); err != nil {
+		return nil, err
+	}
+	h, ok := c.Header(p.Block.Height)
+	if !ok {
+		return nil, fmt.Errorf("header %d not verified", p.Block.Height)
+	}
+	if err := VerifyTxProof(h, p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,334 @@
+// Package logging configures the node's structured logging on log/slog.
+//
+// Every subsystem logs through a component logger (For("chain"),
+// For("p2p"), ...) whose records carry a "component" attribute. Records
+// logged with a context also carry the request ID and tx hash stored in
+// it, so a write can be traced from the HTTP request through the chain to
+// the store. Setup picks the level (node-wide and per component) and the
+// sinks; loggers created before Setup pick up the new settings.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// Sink is one log destination. Output is "stderr" (default), "stdout" or a
+// file path, which is appended to. Format is "text" (default) or "json".
+// Level, when set, drops records below it for this sink only.
+type Sink struct {
+	Output string
+	Format string
+	Level  string
+}
+
+// Options configures Setup. Level is the node-wide minimum level
+// ("debug", "info", "warn", "error"; default info) and Components
+// overrides it per component.
+type Options struct {
+	Level      string
+	Components map[string]string
+	Sinks      []Sink
+}
+
+// config is the active configuration; component loggers read it on every
+// record so Setup applies to loggers that already exist.
+type config struct {
+	handler    slog.Handler
+	level      slog.Level
+	components map[string]slog.Level
+	closers    []io.Closer
+}
+
+var active atomic.Pointer[config]
+
+func init() {
+	active.Store(&config{
+		handler: slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		level:   slog.LevelInfo,
+	})
+}
+
+// Setup applies opts and makes the result the slog default, which also
+// routes the standard library logger through it. Files opened by a
+// previous Setup are closed.
+func Setup(opts Options) error {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return err
+	}
+	cfg := &config{level: level, components: make(map[string]slog.Level, len(opts.Components))}
+	for comp, l := range opts.Components {
+		if cfg.components[comp], err = ParseLevel(l); err != nil {
+			return fmt.Errorf("component %s: %w", comp, err)
+		}
+	}
+
+	sinks := opts.Sinks
+	if len(sinks) == 0 {
+		sinks = []Sink{{}}
+	}
+	handlers := make([]slog.Handler, 0, len(sinks))
+	for _, s := range sinks {
+		h, c, err := openSink(s)
+		if err != nil {
+			closeAll(cfg.closers)
+			return err
+		}
+		handlers = append(handlers, h)
+		if c != nil {
+			cfg.closers = append(cfg.closers, c)
+		}
+	}
+	cfg.handler = handlers[0]
+	if len(handlers) > 1 {
+		cfg.handler = fanout(handlers)
+	}
+
+	old := active.Swap(cfg)
+	slog.SetDefault(slog.New(&handler{}))
+	log.SetFlags(0)
+	closeAll(old.closers)
+	return nil
+}
+
+// Close flushes and closes the file sinks. Records logged afterwards go
+// to stderr.
+func Close() error {
+	old := active.Swap(&config{
+		handler: slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		level:   slog.LevelInfo,
+	})
+	return closeAll(old.closers)
+}
+
+func closeAll(cs []io.Closer) error {
+	var first error
+	for _, c := range cs {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func openSink(s Sink) (slog.Handler, io.Closer, error) {
+	floor := slog.LevelDebug
+	if s.Level != "" {
+		l, err := ParseLevel(s.Level)
+		if err != nil {
+			return nil, nil, fmt.Errorf("sink %s: %w", s.Output, err)
+		}
+		floor = l
+	}
+	var w io.Writer
+	var c io.Closer
+	switch s.Output {
+	case "", "stderr":
+		w = os.Stderr
+	case "stdout":
+		w = os.Stdout
+	default:
+		if err := os.MkdirAll(filepath.Dir(s.Output), 0o755); err != nil {
+			return nil, nil, err
+		}
+		f, err := os.OpenFile(s.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		w, c = f, f
+	}
+	ho := &slog.HandlerOptions{Level: floor}
+	switch strings.ToLower(s.Format) {
+	case "", "text":
+		return slog.NewTextHandler(w, ho), c, nil
+	case "json":
+		return slog.NewJSONHandler(w, ho), c, nil
+	}
+	if c != nil {
+		c.Close()
+	}
+	return nil, nil, fmt.Errorf("sink %s: unknown format %q", s.Output, s.Format)
+}
+
+// ParseLevel parses a level name; the empty string is info.
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", s)
+	}
+	return l, nil
+}
+
+// For returns the logger of a component. It is cheap and safe to call at
+// package initialisation.
+func For(component string) *slog.Logger {
+	return slog.New(&handler{component: component})
+}
+
+// handler applies the active configuration at log time: the component's
+// level, the attributes and groups added with With/WithGroup, and the
+// request ID and tx hash carried by the context.
+type handler struct {
+	component string
+	ops       []handlerOp
+}
+
+// handlerOp is one With (attrs) or WithGroup (group) call, replayed on the
+// active sink handler in order.
+type handlerOp struct {
+	group string
+	attrs []slog.Attr
+}
+
+func (h *handler) Enabled(ctx context.Context, l slog.Level) bool {
+	cfg := active.Load()
+	floor, ok := cfg.components[h.component]
+	if !ok {
+		floor = cfg.level
+	}
+	return l >= floor && cfg.handler.Enabled(ctx, l)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	out := active.Load().handler
+	if h.component != "" {
+		out = out.WithAttrs([]slog.Attr{slog.String("component", h.component)})
+	}
+	for _, op := range h.ops {
+		if op.group != "" {
+			out = out.WithGroup(op.group)
+		} else {
+			out = out.WithAttrs(op.attrs)
+		}
+	}
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if tx := TxHash(ctx); tx != "" {
+		r.AddAttrs(slog.String("tx_hash", tx))
+	}
+	return out.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(handlerOp{attrs: attrs})
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(handlerOp{group: name})
+}
+
+func (h *handler) with(op handlerOp) *handler {
+	ops := make([]handlerOp, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &handler{component: h.component, ops: append(ops, op)}
+}
+
+// fanout sends every record to each sink that accepts its level.
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, l slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, r slog.Record) error {
+	var first error
+	for _, h := range f {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(fanout, len(f))
+	for i, h := range f {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	out := make(fanout, len(f))
+	for i, h := range f {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	txHashKey
+)
+
+// WithRequestID returns ctx carrying a request ID for log correlation.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the request ID carried by ctx, if any.
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithTx returns ctx carrying the hash of the tx being processed.
+func WithTx(ctx context.Context, hash string) context.Context {
+	if hash == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, txHashKey, hash)
+}
+
+// TxHash returns the tx hash carried by ctx, if any.
+func TxHash(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	h, _ := ctx.Value(txHashKey).(string)
+	return h
+}
+
+// NewRequestID returns a random 16-hex-digit request ID.
+func NewRequestID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "0000000000000000"
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// Err is the conventional attribute for an error.
+func Err(err error) slog.Attr {
+	return slog.Any("err", err)
+}
@@ -0,0 +1,289 @@
+// Package metrics is a small Prometheus registry: counters, gauges and
+// histograms with fixed label names, served in the text exposition format
+// (version 0.0.4). Metrics are package-level variables of the package that
+// owns the measured code and register themselves with Default.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Default is the registry served on /metrics.
+var Default = NewRegistry()
+
+// Registry holds metric families by name.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]family
+}
+
+type family interface {
+	write(w *bufio.Writer)
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]family)}
+}
+
+func (r *Registry) register(name string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.families[name]; dup {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.families[name] = f
+}
+
+// WriteText writes every family in the text exposition format, sorted by
+// name.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for n := range r.families {
+		names = append(names, n)
+	}
+	fams := make([]family, 0, len(names))
+	sort.Strings(names)
+	for _, n := range names {
+		fams = append(fams, r.families[n])
+	}
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range fams {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler serves r in the text exposition format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = r.WriteText(w)
+	})
+}
+
+// desc is the name, help and label names shared by every metric kind.
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d desc) header(w *bufio.Writer, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, typ)
+}
+
+// key joins label values into a map key; \xff never appears in UTF-8.
+func (d desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelString renders {a="x",b="y"} for the values of key plus extra
+// pairs (used for histogram le).
+func (d desc) labelString(key string, extra ...string) string {
+	var parts []string
+	if len(d.labels) > 0 {
+		for i, v := range strings.Split(key, "\xff") {
+			parts = append(parts, d.labels[i]+`="`+escapeLabel(v)+`"`)
+		}
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		parts = append(parts, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// valueVec is a set of float samples keyed by label values, shared by
+// counters and gauges.
+type valueVec struct {
+	desc
+	typ    string
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func (v *valueVec) write(w *bufio.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.header(w, v.typ)
+	for _, k := range sortedKeys(v.values) {
+		fmt.Fprintf(w, "%s%s %s\n", v.name, v.labelString(k), formatFloat(v.values[k]))
+	}
+}
+
+func (v *valueVec) add(delta float64, values []string) {
+	k := v.key(values)
+	v.mu.Lock()
+	v.values[k] += delta
+	v.mu.Unlock()
+}
+
+func (v *valueVec) set(val float64, values []string) {
+	k := v.key(values)
+	v.mu.Lock()
+	v.values[k] = val
+	v.mu.Unlock()
+}
+
+// CounterVec is a monotonically increasing counter per label set.
+type CounterVec struct{ v *valueVec }
+
+// NewCounterVec registers a counter with Default.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &valueVec{desc: desc{name, help, labels}, typ: "counter", values: map[string]float64{}}
+	Default.register(name, v)
+	return &CounterVec{v}
+}
+
+// Inc adds one.
+func (c *CounterVec) Inc(labelValues ...string) { c.v.add(1, labelValues) }
+
+// Add adds delta, which must not be negative.
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counter " + c.v.name + " decreased")
+	}
+	c.v.add(delta, labelValues)
+}
+
+// Set mirrors a cumulative count kept elsewhere (e.g. an atomic in the
+// instrumented type), refreshed at scrape time.
+func (c *CounterVec) Set(total float64, labelValues ...string) { c.v.set(total, labelValues) }
+
+// GaugeVec is a value that can go up and down, per label set.
+type GaugeVec struct{ v *valueVec }
+
+// NewGaugeVec registers a gauge with Default.
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	v := &valueVec{desc: desc{name, help, labels}, typ: "gauge", values: map[string]float64{}}
+	Default.register(name, v)
+	return &GaugeVec{v}
+}
+
+// Set sets the gauge.
+func (g *GaugeVec) Set(val float64, labelValues ...string) { g.v.set(val, labelValues) }
+
+// Add adds delta (which may be negative).
+func (g *GaugeVec) Add(delta float64, labelValues ...string) { g.v.add(delta, labelValues) }
+
+// Reset drops every label set, for gauges rebuilt at scrape time.
+func (g *GaugeVec) Reset() {
+	g.v.mu.Lock()
+	g.v.values = map[string]float64{}
+	g.v.mu.Unlock()
+}
+
+// HistogramVec counts observations into cumulative buckets per label set.
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histSeries
+}
+
+type histSeries struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec registers a histogram with Default. buckets are upper
+// bounds in increasing order; +Inf is implicit.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		desc:    desc{name, help, labels},
+		buckets: append([]float64(nil), buckets...),
+		series:  map[string]*histSeries{},
+	}
+	sort.Float64s(h.buckets)
+	Default.register(name, h)
+	return h
+}
+
+// Observe records one value.
+func (h *HistogramVec) Observe(val float64, labelValues ...string) {
+	k := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.series[k]
+	if s == nil {
+		s = &histSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[k] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, val); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += val
+	s.count++
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w, "histogram")
+	keys := make([]string, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := h.series[k]
+		var cum uint64
+		for i, ub := range h.buckets {
+			cum += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(k, "le", formatFloat(ub)), cum)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(k, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelString(k), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelString(k), s.count)
+	}
+}
+
+// DefBuckets suits request latencies in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
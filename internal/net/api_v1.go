@@ -0,0 +1,924 @@
+package net
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	stdnet "net"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"reservechain/internal/core"
+	"reservechain/internal/store"
+	rpcecon "reservechain/rpc/econ"
+)
+
+// v1Prefix is where the versioned API is mounted.
+const v1Prefix = "/api/v1"
+
+// v1MaxBody bounds request bodies validated by the v1 router.
+const v1MaxBody = 1 << 20
+
+// v1Param documents a query parameter.
+type v1Param struct {
+	Name        string
+	Type        string // string | integer | boolean
+	Required    bool
+	Description string
+}
+
+// v1Route is one method+path of the versioned API. The table drives the
+// router, the deprecated aliases and the OpenAPI document alike.
+type v1Route struct {
+	Method  string
+	Path    string   // below /api/v1
+	Legacy  []string // unversioned paths served as deprecated aliases
+	Tag     string
+	Summary string
+
+	// Role guards the route with requireRole; otherwise a non-empty Scope
+	// guards it with requireSession. Guarded routes enforce Role
+	// themselves (rpc/econ) and the router only documents it.
+	Role    string
+	Scope   string
+	Guarded bool
+	// Stream marks long-lived responses, which are left out of the
+	// request latency histogram.
+	Stream bool
+
+	Query []v1Param
+	// Body is a zero value of the request type. The router rejects bodies
+	// that do not decode into it (unknown fields included) or that leave
+	// a Required field (dotted JSON path) missing or empty.
+	Body     interface{}
+	Required []string
+	Response interface{}
+
+	Handler http.HandlerFunc
+}
+
+// Documentation-only bodies of the rpc/econ treasury endpoints.
+type econAmountRequest struct {
+	Amount  float64 `json:"amount"`
+	Account string  `json:"account"`
+	Asset   string  `json:"asset,omitempty"`
+}
+
+type econReasonRequest struct {
+	Amount float64 `json:"amount"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+// v1Routes is the route table of the versioned API.
+func (api *HTTPAPI) v1Routes() []v1Route {
+	econMux := http.NewServeMux()
+	rpcecon.AttachHTTP(econMux, v1Prefix+"/econ", api.requireRole)
+	econRPC := econMux.ServeHTTP
+
+	get, post, del := http.MethodGet, http.MethodPost, http.MethodDelete
+	limit := v1Param{Name: "limit", Type: "integer", Description: "Maximum number of rows."}
+
+	return []v1Route{
+		// Auth and sessions
+		{Method: post, Path: "/auth/nonce", Legacy: []string{"/api/auth/nonce"}, Tag: "auth",
+			Summary: "Issue a login challenge for a wallet",
+			Body:    AuthNonceRequest{}, Required: []string{"wallet_type", "address"},
+			Handler: api.authNonceHandler},
+		{Method: post, Path: "/auth/wallet-login", Legacy: []string{"/api/auth/wallet-login"}, Tag: "auth",
+			Summary: "Exchange a signed challenge for a session",
+			Body:    WalletLoginRequest{}, Required: []string{"wallet_type", "address", "challenge"},
+			Handler: api.authWalletLoginHandler},
+		{Method: get, Path: "/session", Legacy: []string{"/api/session"}, Tag: "auth",
+			Summary: "Describe the current session", Handler: api.sessionGetHandler},
+		{Method: post, Path: "/auth/logout", Legacy: []string{"/api/auth/logout"}, Tag: "auth",
+			Summary: "End the current session", Handler: api.authLogoutHandler},
+		{Method: post, Path: "/auth/refresh", Legacy: []string{"/api/auth/refresh"}, Tag: "auth",
+			Summary: "Rotate the session token", Handler: api.authRefreshHandler},
+		{Method: get, Path: "/auth/sessions", Legacy: []string{"/api/auth/sessions"}, Tag: "auth",
+			Summary: "List the caller's sessions", Handler: api.authSessionsHandler},
+		{Method: post, Path: "/auth/sessions/revoke", Legacy: []string{"/api/auth/sessions/revoke"}, Tag: "auth",
+			Summary: "Revoke one of the caller's sessions",
+			Body:    SessionRevokeRequest{}, Required: []string{"session_id"},
+			Handler: api.authRevokeSessionHandler},
+		{Method: post, Path: "/auth/sessions/revoke-all", Legacy: []string{"/api/auth/sessions/revoke-all"}, Tag: "auth",
+			Summary: "Revoke every session of the caller",
+			Body:    SessionRevokeAllRequest{}, Handler: api.authRevokeAllSessionsHandler},
+		{Method: get, Path: "/auth/api-keys", Legacy: []string{"/api/auth/api-keys"}, Tag: "auth",
+			Summary: "List the caller's API keys", Handler: api.apiKeysHandler},
+		{Method: post, Path: "/auth/api-keys", Legacy: []string{"/api/auth/api-keys"}, Tag: "auth",
+			Summary: "Create an API key; the secret is returned once",
+			Body:    APIKeyCreateRequest{}, Required: []string{"name", "scopes"},
+			Handler: api.apiKeysHandler},
+		{Method: post, Path: "/auth/api-keys/revoke", Legacy: []string{"/api/auth/api-keys/revoke"}, Tag: "auth",
+			Summary: "Revoke one of the caller's API keys",
+			Body:    APIKeyRevokeRequest{}, Required: []string{"id"},
+			Handler: api.apiKeyRevokeHandler},
+
+		// Administration
+		{Method: get, Path: "/admin/roles", Legacy: []string{"/api/admin/roles"}, Tag: "admin",
+			Summary: "List role grants", Role: RoleAdmin,
+			Query:   []v1Param{{Name: "role", Type: "string", Description: "Only grants of this role."}},
+			Handler: api.adminRolesHandler},
+		{Method: post, Path: "/admin/roles", Legacy: []string{"/api/admin/roles"}, Tag: "admin",
+			Summary: "Grant a role", Role: RoleAdmin,
+			Body: RoleGrantRequest{}, Required: []string{"identity", "role"},
+			Handler: api.adminRolesHandler},
+		{Method: del, Path: "/admin/roles", Legacy: []string{"/api/admin/roles"}, Tag: "admin",
+			Summary: "Revoke a role", Role: RoleAdmin,
+			Body: RoleGrantRequest{}, Required: []string{"identity", "role"},
+			Handler: api.adminRolesHandler},
+		{Method: get, Path: "/admin/audit", Legacy: []string{"/api/admin/audit"}, Tag: "admin",
+			Summary: "Read the audit log", Role: RoleAdmin,
+			Query: []v1Param{
+				{Name: "entity_type", Type: "string", Description: "Only events of this entity type."},
+				limit,
+			},
+			Response: []store.AuditEvent{}, Handler: api.adminAuditHandler},
+		{Method: get, Path: "/admin/rate-limits", Legacy: []string{"/api/admin/rate-limits"}, Tag: "admin",
+			Summary: "Rate limit counters per route class", Role: RoleAdmin,
+			Handler: api.adminRateLimitsHandler},
+		{Method: get, Path: "/profile", Legacy: []string{"/api/profile/get"}, Tag: "econ",
+			Summary: "Active econ profile", Response: ProfileResponse{}, Handler: api.getProfileHandler},
+		{Method: post, Path: "/profile", Legacy: []string{"/api/profile/set"}, Tag: "econ",
+			Summary: "Switch the econ profile", Role: RoleAdmin,
+			Body: ProfileSetRequest{}, Required: []string{"profile"},
+			Handler: api.setProfileHandler},
+
+		// Events
+		{Method: get, Path: "/events", Legacy: []string{"/api/events"}, Tag: "events",
+			Summary: "Replay journaled events",
+			Query: []v1Param{
+				{Name: "after", Type: "integer", Description: "Return events with seq above this."},
+				limit,
+			},
+			Handler: api.eventsListHandler},
+		{Method: get, Path: "/events/stream", Legacy: []string{"/api/events/stream"}, Tag: "events",
+			Summary: "Server-sent event stream",
+			Query: []v1Param{
+				{Name: "last_seq", Type: "integer", Description: "Resume after this seq (Last-Event-ID also works)."},
+				{Name: "types", Type: "string", Description: "Comma-separated event types."},
+				{Name: "address", Type: "string", Description: "Only events touching this address."},
+				{Name: "vault_id", Type: "string"},
+				{Name: "validator", Type: "string"},
+			},
+			Stream: true, Handler: api.eventsStreamHandler},
+		{Method: get, Path: "/events/schema", Legacy: []string{"/api/events/schema"}, Tag: "events",
+			Summary: "JSON Schema of event payloads", Handler: api.eventsSchemaHandler},
+
+		// Accounts and transactions
+		{Method: get, Path: "/balances", Legacy: []string{"/api/balances"}, Tag: "accounts",
+			Summary: "Account balances, now or at a past height or epoch",
+			Query: append([]v1Param{
+				{Name: "address", Type: "string", Description: "Only this account."},
+			}, stateHistoryParams...),
+			Response: BalancesResponse{}, Handler: api.balancesHandler},
+		{Method: get, Path: "/account/nonce", Legacy: []string{"/api/account/nonce"}, Tag: "accounts",
+			Summary:  "Next nonce of an address",
+			Query:    []v1Param{{Name: "address", Type: "string", Required: true}},
+			Response: AccountNonceResponse{}, Handler: api.accountNonceHandler},
+		{Method: get, Path: "/address/{addr}/txs", Legacy: []string{"/api/address/{addr}/txs"}, Tag: "accounts",
+			Summary: "Transactions touching an address, newest first",
+			Query: []v1Param{
+				{Name: "types", Type: "string", Description: "Comma-separated tx types, e.g. transfer,vault_deposit."},
+				{Name: "role", Type: "string", Description: "Comma-separated roles: sender, receiver, vault, staker, operator."},
+				{Name: "cursor", Type: "string", Description: "next_cursor of the previous page."},
+				{Name: "limit", Type: "integer", Description: "1-200, default 50."},
+			},
+			Response: AddressTxsResponse{}, Handler: api.addressTxsHandler},
+		{Method: post, Path: "/mint", Legacy: []string{"/api/mint"}, Tag: "accounts",
+			Summary: "Mint GRC against a deposited asset", Scope: ScopeTrade,
+			Body: MintRequest{}, Required: []string{"asset", "amount"},
+			Handler: api.mintHandler},
+		{Method: post, Path: "/redeem", Legacy: []string{"/api/redeem"}, Tag: "accounts",
+			Summary: "Redeem GRC for an asset", Scope: ScopeTrade,
+			Body: RedeemRequest{}, Required: []string{"asset", "amount"},
+			Handler: api.redeemHandler},
+		{Method: post, Path: "/tx/transfer", Legacy: []string{"/api/tx/transfer"}, Tag: "tx",
+			Summary: "Submit a TX_TRANSFER", Scope: ScopeTransfer,
+			Body:     TransferRequest{},
+			Required: []string{"type", "tx.from", "tx.to", "tx.asset", "tx.amount"},
+			Response: TxSubmitResponse{}, Handler: api.transferHandler},
+		{Method: post, Path: "/tx/vault-create", Legacy: []string{"/api/tx/vault_create"}, Tag: "tx",
+			Summary: "Submit a TX_VAULT_CREATE", Scope: ScopeTrade,
+			Body:     VaultCreateRequest{},
+			Required: []string{"type", "tx.vault_id", "tx.owner", "tx.type"},
+			Response: TxSubmitResponse{}, Handler: api.vaultCreateHandler},
+		{Method: post, Path: "/tx/simulate", Legacy: []string{"/api/tx/simulate"}, Tag: "tx",
+			Summary:  "Dry-run any tx type against the current state",
+			Body:     TxSimulateRequest{},
+			Required: []string{"type", "tx"},
+			Response: core.SimulationResult{}, Handler: api.txSimulateHandler},
+		{Method: post, Path: "/tier/renew", Legacy: []string{"/api/tier/renew"}, Tag: "tx",
+			Summary: "Submit a TX_TIER_RENEW", Scope: ScopeTrade,
+			Body:     TierRenewRequest{},
+			Required: []string{"type", "tx.sender", "tx.tier"},
+			Response: TxSubmitResponse{}, Handler: api.tierRenewHandler},
+
+		// Chain
+		{Method: get, Path: "/chain/head", Legacy: []string{"/api/chain/head"}, Tag: "chain",
+			Summary: "Current head block", Response: ChainHeadResponse{}, Handler: api.chainHeadHandler},
+		{Method: get, Path: "/chain/blocks", Legacy: []string{"/api/chain/blocks"}, Tag: "chain",
+			Summary: "Page through blocks by height",
+			Query: []v1Param{
+				{Name: "from_height", Type: "integer"},
+				{Name: "to_height", Type: "integer", Description: "Inclusive; default the tip."},
+				{Name: "limit", Type: "integer", Description: "1-500, default 50."},
+			},
+			Response: ChainBlocksResponse{}, Handler: api.chainBlocksHandler},
+		{Method: get, Path: "/chain/block", Legacy: []string{"/api/chain/block"}, Tag: "chain",
+			Summary: "Block by height or hash",
+			Query: []v1Param{
+				{Name: "height", Type: "integer"},
+				{Name: "hash", Type: "string", Description: "Takes precedence over height."},
+			},
+			Response: ChainBlockResponse{}, Handler: api.chainBlockByHeightHandler},
+		{Method: get, Path: "/tx/{hash}", Legacy: []string{"/api/tx/{hash}"}, Tag: "chain",
+			Summary:  "Decoded transaction by hash",
+			Response: TxResponse{}, Handler: api.txByHashHandler},
+		{Method: get, Path: "/search", Legacy: []string{"/api/search"}, Tag: "chain",
+			Summary:  "Find blocks, transactions, addresses, vaults, validators and nodes",
+			Query:    []v1Param{{Name: "q", Type: "string", Required: true}},
+			Response: SearchResponse{}, Handler: api.searchHandler},
+		{Method: get, Path: "/chain/mempool", Legacy: []string{"/api/chain/mempool"}, Tag: "chain",
+			Summary: "Pending transactions", Handler: api.mempoolHandler},
+		{Method: get, Path: "/chain/mining/status", Legacy: []string{"/api/chain/mining/status"}, Tag: "chain",
+			Summary: "Miner status", Handler: api.miningStatusHandler},
+		{Method: post, Path: "/chain/mining/start", Legacy: []string{"/api/chain/mining/start"}, Tag: "chain",
+			Summary: "Start the miner", Role: RoleOperator, Handler: api.miningStartHandler},
+		{Method: post, Path: "/chain/mining/stop", Legacy: []string{"/api/chain/mining/stop"}, Tag: "chain",
+			Summary: "Stop the miner", Role: RoleOperator, Handler: api.miningStopHandler},
+		{Method: get, Path: "/proof/account", Legacy: []string{"/api/proof/account"}, Tag: "chain",
+			Summary: "Merkle proof of an account against a block's state root",
+			Query: []v1Param{
+				{Name: "address", Type: "string", Required: true},
+				{Name: "height", Type: "integer", Description: "Defaults to the head."},
+			},
+			Handler: api.proofAccountHandler},
+		{Method: get, Path: "/proof/tx", Legacy: []string{"/api/proof/tx"}, Tag: "chain",
+			Summary: "Merkle inclusion proof of a transaction",
+			Query:   []v1Param{{Name: "hash", Type: "string", Required: true}},
+			Handler: api.proofTxHandler},
+
+		// Analytics
+		{Method: get, Path: "/analytics/nav", Legacy: []string{"/api/analytics/nav"}, Tag: "analytics",
+			Summary: "Latest NAV", Handler: api.analyticsNAVHandler},
+		{Method: get, Path: "/analytics/windows", Legacy: []string{"/api/analytics/windows"}, Tag: "analytics",
+			Summary: "Settlement window snapshot", Handler: api.analyticsWindowsHandler},
+		{Method: get, Path: "/analytics/treasury", Legacy: []string{"/api/analytics/treasury"}, Tag: "analytics",
+			Summary: "Tiered treasury snapshot",
+			Query:   []v1Param{{Name: "detail", Type: "string"}},
+			Handler: api.analyticsTreasuryHandler},
+		{Method: get, Path: "/valuation/latest", Legacy: []string{"/api/valuation/latest"}, Tag: "analytics",
+			Summary: "Reserve, supply, NAV and corridor status, now or at a past height or epoch",
+			Query:   stateHistoryParams, Handler: api.valuationLatestHandler},
+		{Method: post, Path: "/sim", Legacy: []string{"/api/sim"}, Tag: "analytics",
+			Summary: "Run the policy simulator from the live state",
+			Body:    SimRequest{}, Handler: api.econSimHandler},
+
+		// Econ
+		{Method: get, Path: "/econ/live", Legacy: []string{"/econ/live"}, Tag: "econ",
+			Summary: "Live econ dashboard feed (WebSocket)", Stream: true, Handler: api.econLiveHandler},
+		{Method: get, Path: "/econ/epoch-commit", Legacy: []string{"/api/econ/epoch-commit"}, Tag: "econ",
+			Summary: "Payout commitment of an epoch",
+			Query:   []v1Param{{Name: "epoch", Type: "integer", Required: true}},
+			Handler: api.econEpochCommitHandler},
+		{Method: get, Path: "/econ/leader", Legacy: []string{"/api/econ/leader"}, Tag: "econ",
+			Summary: "Econ leader election status", Handler: api.econLeaderHandler},
+		{Method: post, Path: "/econ/heartbeat", Legacy: []string{"/api/econ/heartbeat"}, Tag: "econ",
+			Summary: "Signed leader heartbeat from a peer",
+			Body:    LeaderHeartbeat{}, Required: []string{"node_id", "pubkey", "signature"},
+			Handler: api.econHeartbeatHandler},
+		{Method: post, Path: "/econ/tick", Legacy: []string{"/api/econ/tick"}, Tag: "econ",
+			Summary: "Signed econ tick from the leader",
+			Body:    SignedTick{}, Required: []string{"leader_id", "pubkey", "signature"},
+			Handler: api.econTickHandler},
+		{Method: get, Path: "/econ/ping", Tag: "econ", Summary: "Econ RPC liveness", Guarded: true, Handler: econRPC},
+		{Method: get, Path: "/econ/treasury", Tag: "econ", Summary: "Mark-to-market treasury balance sheet", Guarded: true, Handler: econRPC},
+		{Method: get, Path: "/econ/coverage", Tag: "econ", Summary: "Reserve coverage snapshot", Guarded: true, Handler: econRPC},
+		{Method: get, Path: "/econ/redemptions", Tag: "econ", Summary: "Redemption queue", Guarded: true, Handler: econRPC},
+		{Method: get, Path: "/econ/mints", Tag: "econ", Summary: "Mint queue", Guarded: true, Handler: econRPC},
+		{Method: get, Path: "/econ/grc-issuance", Tag: "econ", Summary: "GRC issuance state", Guarded: true, Handler: econRPC},
+		{Method: get, Path: "/econ/mainnet-state", Tag: "econ", Summary: "Mainnet monetary state", Guarded: true, Handler: econRPC},
+		{Method: get, Path: "/econ/history", Tag: "econ", Summary: "Monetary state history",
+			Query: []v1Param{limit}, Guarded: true, Handler: econRPC},
+		{Method: post, Path: "/econ/advance-epoch", Tag: "econ", Summary: "Advance the devnet epoch",
+			Role: rpcecon.RoleOperator, Guarded: true, Handler: econRPC},
+		{Method: post, Path: "/econ/settle-mainnet-epoch", Tag: "econ", Summary: "Settle the mainnet epoch",
+			Role: rpcecon.RoleTreasury, Guarded: true, Handler: econRPC},
+		{Method: post, Path: "/econ/mint-usdr", Tag: "econ", Summary: "Mint USDR",
+			Role: rpcecon.RoleTreasury, Guarded: true,
+			Body: econAmountRequest{}, Required: []string{"amount", "account"}, Handler: econRPC},
+		{Method: post, Path: "/econ/redeem-usdr", Tag: "econ", Summary: "Redeem USDR",
+			Role: rpcecon.RoleTreasury, Guarded: true,
+			Body: econAmountRequest{}, Required: []string{"amount", "account"}, Handler: econRPC},
+		{Method: post, Path: "/econ/issue-grc", Tag: "econ", Summary: "Issue GRC",
+			Role: rpcecon.RoleTreasury, Guarded: true,
+			Body: econReasonRequest{}, Required: []string{"amount"}, Handler: econRPC},
+		{Method: post, Path: "/econ/burn-grc", Tag: "econ", Summary: "Burn GRC",
+			Role: rpcecon.RoleTreasury, Guarded: true,
+			Body: econReasonRequest{}, Required: []string{"amount"}, Handler: econRPC},
+		{Method: get, Path: "/slashing/events", Legacy: []string{"/api/slashing/events"}, Tag: "staking",
+			Summary: "Slashing events",
+			Query: []v1Param{
+				{Name: "epoch", Type: "integer"},
+				{Name: "subject_type", Type: "string"},
+				{Name: "subject_id", Type: "string"},
+				{Name: "status", Type: "string"},
+				limit,
+			},
+			Handler: api.slashingEventsHandler},
+
+		// Staking and PoP
+		{Method: get, Path: "/staking/validators", Legacy: []string{"/api/staking/validators"}, Tag: "staking",
+			Summary: "List validators", Response: []store.Validator{}, Handler: api.stakingValidatorsHandler},
+		{Method: post, Path: "/staking/validators", Legacy: []string{"/api/staking/validators"}, Tag: "staking",
+			Summary: "Create or update a validator", Role: RoleOperator,
+			Body: store.Validator{}, Handler: api.stakingValidatorsHandler},
+		{Method: post, Path: "/staking/lock", Legacy: []string{"/api/staking/lock", "/api/staking/stake"}, Tag: "staking",
+			Summary: "Lock RSX with a validator", Scope: ScopeStaking,
+			Body: core.StakeLockTx{}, Required: []string{"staker_wallet", "validator_id", "amount_rsx"},
+			Handler: api.stakingLockHandler},
+		{Method: post, Path: "/staking/unlock", Legacy: []string{"/api/staking/unlock"}, Tag: "staking",
+			Summary: "Unlock RSX from a validator", Scope: ScopeStaking,
+			Body: core.StakeUnlockTx{}, Required: []string{"staker_wallet", "validator_id", "amount_rsx"},
+			Handler: api.stakingUnlockHandler},
+		{Method: get, Path: "/staking/state", Legacy: []string{"/api/staking/state"}, Tag: "staking",
+			Summary: "Staking state", Handler: api.stakingStateHandler},
+		{Method: post, Path: "/pop/register-node", Legacy: []string{"/api/pop/register-node"}, Tag: "pop",
+			Summary: "Register a PoP node", Scope: ScopeOperator,
+			Body: core.PoPRegisterNodeTx{}, Required: []string{"operator_wallet", "node_id"},
+			Handler: api.popRegisterNodeHandler},
+		{Method: post, Path: "/pop/submit-caps", Legacy: []string{"/api/pop/submit-caps"}, Tag: "pop",
+			Summary: "Submit node capability scores", Scope: ScopeOperator,
+			Body: core.PoPSetCapsTx{}, Required: []string{"operator_wallet", "node_id"},
+			Handler: api.popSubmitCapsHandler},
+		{Method: post, Path: "/pop/claim-work", Legacy: []string{"/api/pop/claim-work", "/api/pop/submit-metrics"}, Tag: "pop",
+			Summary: "Claim PoP work for an epoch", Scope: ScopeOperator,
+			Body: PoPClaimWorkRequest{}, Required: []string{"node_id"},
+			Handler: api.popClaimWorkHandler},
+		{Method: get, Path: "/pop/payouts", Legacy: []string{"/api/pop/payouts"}, Tag: "pop",
+			Summary: "PoP payouts of an epoch",
+			Query:   []v1Param{{Name: "epoch", Type: "integer", Description: "Defaults to the current epoch."}},
+			Handler: api.popPayoutsHandler},
+
+		// Peer-to-peer
+		{Method: get, Path: "/p2p/handshake", Legacy: []string{"/api/p2p/handshake"}, Tag: "p2p",
+			Summary: "Signed node handshake", Response: Handshake{}, Handler: api.p2pHandshakeHandler},
+		{Method: post, Path: "/p2p/register", Legacy: []string{"/api/p2p/register"}, Tag: "p2p",
+			Summary: "Register with a seed",
+			Body:    RegisterRequest{}, Required: []string{"addr", "node_id", "pubkey", "signature"},
+			Handler: api.p2pRegisterHandler},
+		{Method: get, Path: "/p2p/peers", Legacy: []string{"/api/p2p/peers"}, Tag: "p2p",
+			Summary: "Peers known to a seed",
+			Query:   []v1Param{{Name: "detail", Type: "string", Description: "1 to include persisted records."}},
+			Handler: api.p2pPeersHandler},
+		{Method: get, Path: "/snapshot/manifest", Legacy: []string{"/api/snapshot/manifest"}, Tag: "p2p",
+			Summary: "State snapshot manifest",
+			Query:   []v1Param{{Name: "height", Type: "integer"}},
+			Handler: api.snapshotManifestHandler},
+		{Method: get, Path: "/snapshot/chunk", Legacy: []string{"/api/snapshot/chunk"}, Tag: "p2p",
+			Summary: "One chunk of a state snapshot",
+			Query: []v1Param{
+				{Name: "height", Type: "integer", Required: true},
+				{Name: "index", Type: "integer", Required: true},
+			},
+			Handler: api.snapshotChunkHandler},
+	}
+}
+
+// mountV1 registers the versioned routes, their OpenAPI document and the
+// deprecated unversioned aliases on mux.
+func (api *HTTPAPI) mountV1(mux *http.ServeMux) {
+	routes := api.v1Routes()
+
+	v1 := map[string]map[string]http.HandlerFunc{}
+	legacy := map[string]map[string]http.HandlerFunc{}
+	successor := map[string]string{}
+	for _, rt := range routes {
+		guarded := api.v1Guard(rt, rt.Handler)
+		addMethod(v1, v1Prefix+rt.Path, rt.Method,
+			instrumentRoute(rt, v1Prefix+rt.Path, api.v1Guard(rt, v1Validate(rt, rt.Handler))))
+		for _, p := range rt.Legacy {
+			addMethod(legacy, p, rt.Method, instrumentRoute(rt, p, guarded))
+			if _, ok := successor[p]; !ok {
+				successor[p] = v1Prefix + rt.Path
+			}
+		}
+	}
+
+	templates := map[string][]pathTemplate{}
+	handle := func(path string, h http.Handler) {
+		i := strings.Index(path, "{")
+		if i < 0 {
+			mux.Handle(path, h)
+			return
+		}
+		templates[path[:i]] = append(templates[path[:i]], pathTemplate{pattern: path, h: h})
+	}
+	for path, methods := range v1 {
+		handle(path, v1Envelope(methodRouter(methods)))
+	}
+	for path, methods := range legacy {
+		s := successor[path]
+		if strings.Contains(path, "{") {
+			s = ""
+		}
+		handle(path, deprecatedAlias(s, methodRouter(methods)))
+	}
+	for prefix, list := range templates {
+		mux.Handle(prefix, templateRouter(list))
+	}
+
+	doc := &openAPIDoc{routes: routes}
+	mux.Handle(v1Prefix+"/openapi.json", v1Envelope(methodRouter(map[string]http.HandlerFunc{
+		http.MethodGet: doc.serve,
+	})))
+	mux.Handle(v1Prefix+"/", v1Envelope(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, http.StatusNotFound, "not_found", "no route "+r.URL.Path)
+	})))
+
+	// The econ RPC views keep their unversioned /econ/* paths too.
+	legacyEcon := http.NewServeMux()
+	rpcecon.AttachHTTP(legacyEcon, "/econ", api.requireRole)
+	mux.HandleFunc("/econ/", deprecatedAlias("", legacyEcon.ServeHTTP))
+}
+
+// pathTemplate is a route whose path has {name} segments.
+type pathTemplate struct {
+	pattern string
+	h       http.Handler
+}
+
+type pathParamsKey struct{}
+
+// templateRouter serves every template sharing a static prefix, putting
+// the matched segments into the request context for pathParam.
+func templateRouter(list []pathTemplate) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, t := range list {
+			if params, ok := matchPathTemplate(t.pattern, r.URL.Path); ok {
+				t.h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), pathParamsKey{}, params)))
+				return
+			}
+		}
+		writeAPIError(w, http.StatusNotFound, "not_found", "no route "+r.URL.Path)
+	})
+}
+
+func matchPathTemplate(pattern, path string) (map[string]string, bool) {
+	want := strings.Split(pattern, "/")
+	got := strings.Split(path, "/")
+	if len(want) != len(got) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, seg := range want {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			if got[i] == "" {
+				return nil, false
+			}
+			params[seg[1:len(seg)-1]] = got[i]
+			continue
+		}
+		if seg != got[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// pathParam returns the {name} segment matched for r, or "".
+func pathParam(r *http.Request, name string) string {
+	params, _ := r.Context().Value(pathParamsKey{}).(map[string]string)
+	return params[name]
+}
+
+func addMethod(m map[string]map[string]http.HandlerFunc, path, method string, h http.HandlerFunc) {
+	if m[path] == nil {
+		m[path] = map[string]http.HandlerFunc{}
+	}
+	m[path][method] = h
+}
+
+// v1Guard applies the route's role or scope requirement.
+func (api *HTTPAPI) v1Guard(rt v1Route, next http.HandlerFunc) http.HandlerFunc {
+	switch {
+	case rt.Guarded:
+		return next
+	case rt.Role != "":
+		return api.requireRole(rt.Role, next)
+	case rt.Scope != "":
+		return api.requireSession(rt.Scope, next)
+	}
+	return next
+}
+
+// methodRouter dispatches on the request method and answers 405 with an
+// Allow header for anything else. HEAD falls back to GET.
+func methodRouter(methods map[string]http.HandlerFunc) http.HandlerFunc {
+	allowed := make([]string, 0, len(methods))
+	for m := range methods {
+		allowed = append(allowed, m)
+	}
+	sort.Strings(allowed)
+	allow := strings.Join(allowed, ", ")
+	return func(w http.ResponseWriter, r *http.Request) {
+		h, ok := methods[r.Method]
+		if !ok && r.Method == http.MethodHead {
+			h, ok = methods[http.MethodGet]
+		}
+		if !ok {
+			w.Header().Set("Allow", allow)
+			writeAPIError(w, http.StatusMethodNotAllowed, "method_not_allowed", r.Method+" is not allowed on "+r.URL.Path)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// deprecatedAlias marks responses of an unversioned path as deprecated and
+// links the /api/v1 successor. An empty successor maps the request path
+// under /api/v1 (/api/x and /x both become /api/v1/x).
+func deprecatedAlias(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s := successor
+		if s == "" {
+			s = v1Prefix + strings.TrimPrefix(r.URL.Path, "/api")
+		}
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+s+`>; rel="successor-version"`)
+		next(w, r)
+	}
+}
+
+// v1Validate checks the request body against rt.Body and rt.Required
+// before next runs, then hands next an unread copy of the body.
+func v1Validate(rt v1Route, next http.HandlerFunc) http.HandlerFunc {
+	if rt.Body == nil {
+		return next
+	}
+	t := reflect.TypeOf(rt.Body)
+	return func(w http.ResponseWriter, r *http.Request) {
+		raw, err := io.ReadAll(http.MaxBytesReader(w, r.Body, v1MaxBody))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeAPIError(w, http.StatusRequestEntityTooLarge, "request_too_large", "request body exceeds 1 MiB")
+				return
+			}
+			writeAPIError(w, http.StatusBadRequest, "bad_request", "could not read request body")
+			return
+		}
+		var doc map[string]any
+		if len(bytes.TrimSpace(raw)) > 0 {
+			dec := json.NewDecoder(bytes.NewReader(raw))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(reflect.New(t).Interface()); err != nil {
+				writeAPIError(w, http.StatusBadRequest, "invalid_body", err.Error())
+				return
+			}
+			_ = json.Unmarshal(raw, &doc)
+		}
+		for _, field := range rt.Required {
+			if !jsonFieldPresent(doc, field) {
+				writeAPIError(w, http.StatusBadRequest, "missing_field", field+" is required")
+				return
+			}
+		}
+		r.Body = io.NopCloser(bytes.NewReader(raw))
+		r.ContentLength = int64(len(raw))
+		next(w, r)
+	}
+}
+
+// jsonFieldPresent reports whether the dotted path exists in doc with a
+// non-null, non-empty value.
+func jsonFieldPresent(doc map[string]any, path string) bool {
+	var v any = doc
+	for _, part := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return false
+		}
+		if v, ok = obj[part]; !ok {
+			return false
+		}
+	}
+	switch x := v.(type) {
+	case nil:
+		return false
+	case string:
+		return x != ""
+	case []any:
+		return len(x) > 0
+	case map[string]any:
+		return len(x) > 0
+	}
+	return true
+}
+
+// v1Envelope rewrites every error response of next into the APIError
+// envelope, keeping the status and, where the handler sent one, its error
+// code and message. Successful responses pass through untouched.
+func v1Envelope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ew := &v1Writer{ResponseWriter: w}
+		next.ServeHTTP(ew, r)
+		ew.finish()
+	})
+}
+
+type v1Writer struct {
+	http.ResponseWriter
+	status   int
+	buf      bytes.Buffer
+	hijacked bool
+}
+
+func (w *v1Writer) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	if status < http.StatusBadRequest {
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *v1Writer) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.status >= http.StatusBadRequest {
+		return w.buf.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush keeps server-sent event streams working through the envelope.
+func (w *v1Writer) Flush() {
+	if w.status >= http.StatusBadRequest {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets WebSocket routes upgrade through the envelope. Once the
+// connection is taken over there is no response left to rewrite.
+func (w *v1Writer) Hijack() (stdnet.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijack not supported")
+	}
+	conn, rw, err := hj.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *v1Writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *v1Writer) finish() {
+	if w.hijacked || w.status < http.StatusBadRequest {
+		return
+	}
+	code, msg := apiErrorFrom(w.status, w.buf.Bytes())
+	w.Header().Del("Content-Length")
+	w.Header().Del("X-Content-Type-Options")
+	writeAPIError(w.ResponseWriter, w.status, code, msg)
+}
+
+var errorCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+var statusErrorCodes = map[int]string{
+	http.StatusBadRequest:            "bad_request",
+	http.StatusUnauthorized:          "unauthenticated",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusConflict:              "conflict",
+	http.StatusRequestEntityTooLarge: "request_too_large",
+	http.StatusTooManyRequests:       "rate_limited",
+	http.StatusInternalServerError:   "internal_error",
+	http.StatusServiceUnavailable:    "unavailable",
+}
+
+// apiErrorFrom derives an error code and message from whatever a handler
+// wrote: an {"error","message"} object, a plain-text http.Error body, or
+// nothing at all.
+func apiErrorFrom(status int, body []byte) (string, string) {
+	code, ok := statusErrorCodes[status]
+	if !ok {
+		code = "bad_request"
+		if status >= http.StatusInternalServerError {
+			code = "internal_error"
+		}
+	}
+	body = bytes.TrimSpace(body)
+	var obj map[string]any
+	if err := json.Unmarshal(body, &obj); err == nil {
+		msg, _ := obj["message"].(string)
+		if e, _ := obj["error"].(string); e != "" {
+			if errorCodePattern.MatchString(e) {
+				code = e
+			} else if msg == "" {
+				msg = e
+			}
+		}
+		return code, msg
+	}
+	if len(body) == 0 {
+		return code, http.StatusText(status)
+	}
+	return code, string(body)
+}
+
+// openAPIDoc renders the route table as an OpenAPI 3.1 document once.
+type openAPIDoc struct {
+	routes []v1Route
+	once   sync.Once
+	raw    []byte
+}
+
+func (d *openAPIDoc) serve(w http.ResponseWriter, r *http.Request) {
+	d.once.Do(func() {
+		d.raw, _ = json.MarshalIndent(openAPIDocument(d.routes), "", "  ")
+	})
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(d.raw)
+}
+
+// openAPIDocument describes routes as an OpenAPI 3.1 document. Request and
+// response schemas are derived from the Go types in the route table.
+func openAPIDocument(routes []v1Route) map[string]interface{} {
+	paths := map[string]interface{}{}
+	for _, rt := range routes {
+		item, _ := paths[rt.Path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[rt.Path] = item
+		}
+		item[strings.ToLower(rt.Method)] = openAPIOperation(rt)
+	}
+	errorRef := map[string]interface{}{"$ref": "#/components/schemas/Error"}
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":   "ReserveChain node API",
+			"version": NodeVersion,
+		},
+		"servers": []interface{}{map[string]interface{}{"url": v1Prefix}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Error": typeSchema(reflect.TypeOf(APIError{})),
+			},
+			"responses": map[string]interface{}{
+				"Error": map[string]interface{}{
+					"description": "Error envelope",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": errorRef},
+					},
+				},
+			},
+			"securitySchemes": map[string]interface{}{
+				"session": map[string]interface{}{"type": "apiKey", "in": "cookie", "name": "rc_session"},
+				"bearer": map[string]interface{}{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "Session token or rck_ API key.",
+				},
+			},
+		},
+	}
+}
+
+func openAPIOperation(rt v1Route) map[string]interface{} {
+	op := map[string]interface{}{
+		"operationId": openAPIOperationID(rt),
+		"summary":     rt.Summary,
+		"tags":        []string{rt.Tag},
+	}
+	var params []interface{}
+	for _, seg := range strings.Split(rt.Path, "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			params = append(params, map[string]interface{}{
+				"name":     seg[1 : len(seg)-1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+	}
+	if len(rt.Query) > 0 {
+		for _, q := range rt.Query {
+			p := map[string]interface{}{
+				"name":     q.Name,
+				"in":       "query",
+				"required": q.Required,
+				"schema":   map[string]interface{}{"type": q.Type},
+			}
+			if q.Description != "" {
+				p["description"] = q.Description
+			}
+			params = append(params, p)
+		}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	if rt.Body != nil {
+		schema := typeSchema(reflect.TypeOf(rt.Body))
+		stripRequired(schema)
+		for _, field := range rt.Required {
+			requireField(schema, strings.Split(field, "."))
+		}
+		op["requestBody"] = map[string]interface{}{
+			"required": len(rt.Required) > 0,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schema},
+			},
+		}
+	}
+	ok := map[string]interface{}{"description": "OK"}
+	if rt.Response != nil {
+		ok["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": typeSchema(reflect.TypeOf(rt.Response))},
+		}
+	}
+	op["responses"] = map[string]interface{}{
+		"200":     ok,
+		"default": map[string]interface{}{"$ref": "#/components/responses/Error"},
+	}
+	if rt.Role != "" || rt.Scope != "" {
+		op["security"] = []interface{}{
+			map[string]interface{}{"session": []string{}},
+			map[string]interface{}{"bearer": []string{}},
+		}
+	}
+	if rt.Role != "" {
+		op["x-required-role"] = rt.Role
+	}
+	if rt.Scope != "" {
+		op["x-api-key-scope"] = rt.Scope
+	}
+	return op
+}
+
+// openAPIOperationID turns GET /chain/head into get_chain_head.
+func openAPIOperationID(rt v1Route) string {
+	id := strings.ToLower(rt.Method) + "_" + strings.Trim(rt.Path, "/")
+	return strings.NewReplacer("/", "_", "-", "_", "{", "", "}", "").Replace(id)
+}
+
+// stripRequired drops the encoding/json-derived "required" lists; request
+// bodies are lenient and only rt.Required is enforced.
+func stripRequired(schema map[string]interface{}) {
+	delete(schema, "required")
+	if props, ok := schema["properties"].(map[string]interface{}); ok {
+		for _, p := range props {
+			if ps, ok := p.(map[string]interface{}); ok {
+				stripRequired(ps)
+			}
+		}
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		stripRequired(items)
+	}
+}
+
+func requireField(schema map[string]interface{}, path []string) {
+	props, ok := schema["properties"].(map[string]interface{})
+	if !ok || len(path) == 0 {
+		return
+	}
+	required, _ := schema["required"].([]string)
+	if !containsString(required, path[0]) {
+		schema["required"] = append(required, path[0])
+	}
+	if next, ok := props[path[0]].(map[string]interface{}); ok {
+		requireField(next, path[1:])
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package net
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func newTestMux(t *testing.T) (*HTTPAPI, *http.ServeMux) {
+	t.Helper()
+	api := NewHTTPAPI(NewWSHub(), nil, nil, nil, nil, nil)
+	mux := http.NewServeMux()
+	api.mountV1(mux)
+	return api, mux
+}
+
+func TestValidatorUpsertRequiresOperator(t *testing.T) {
+	_, mux := newTestMux(t)
+	for _, path := range []string{"/api/v1/staking/validators", "/api/staking/validators"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"id":"v1","operator_wallet":"rc:abc"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized && rec.Code != http.StatusForbidden {
+			t.Errorf("POST %s without a session: status %d, want 401 or 403", path, rec.Code)
+		}
+	}
+
+	// Listing stays public.
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/staking/validators", nil))
+	if rec.Code == http.StatusUnauthorized || rec.Code == http.StatusForbidden {
+		t.Errorf("GET /api/v1/staking/validators: status %d, want no auth check", rec.Code)
+	}
+}
+
+func TestV1WebSocketUpgrade(t *testing.T) {
+	_, mux := newTestMux(t)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, path := range []string{"/api/v1/econ/live", "/econ/live"} {
+		url := "ws" + strings.TrimPrefix(srv.URL, "http") + path
+		conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			status := 0
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			t.Fatalf("dial %s: %v (status %d)", path, err, status)
+		}
+		if resp.StatusCode != http.StatusSwitchingProtocols {
+			t.Errorf("dial %s: status %d, want 101", path, resp.StatusCode)
+		}
+		conn.Close()
+	}
+}
@@ -0,0 +1,195 @@
+package net
+
+import (
+	"encoding/json"
+	"time"
+
+	"reservechain/internal/core"
+	"reservechain/internal/store"
+)
+
+// Request and response bodies of the /api/v1 routes. Handlers decode into
+// these types and the OpenAPI document is generated from them, so a field
+// added here shows up in both places.
+
+// APIError is the envelope every /api/v1 error response uses.
+type APIError struct {
+	OK      bool   `json:"ok"`
+	Error   string `json:"error"`
+	Message string `json:"message,omitempty"`
+}
+
+// AuthNonceRequest asks for a login challenge for a wallet.
+type AuthNonceRequest struct {
+	WalletType string `json:"wallet_type"`
+	Address    string `json:"address"`
+}
+
+// WalletLoginRequest exchanges a signed challenge for a session.
+type WalletLoginRequest struct {
+	WalletType   string         `json:"wallet_type"`
+	Address      string         `json:"address"`
+	Pub          map[string]any `json:"pub,omitempty"`
+	Challenge    string         `json:"challenge"`
+	SignatureB64 string         `json:"signature_b64,omitempty"`
+	SignatureHex string         `json:"signature_hex,omitempty"`
+	Role         string         `json:"role,omitempty"`
+	DeviceName   string         `json:"device_name,omitempty"`
+}
+
+// SessionRevokeRequest revokes one of the caller's sessions.
+type SessionRevokeRequest struct {
+	SessionID string `json:"session_id"`
+}
+
+// SessionRevokeAllRequest revokes every session of the caller, optionally
+// keeping the one making the request.
+type SessionRevokeAllRequest struct {
+	KeepCurrent bool `json:"keep_current,omitempty"`
+}
+
+// APIKeyCreateRequest creates a scoped API key for the caller.
+type APIKeyCreateRequest struct {
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// APIKeyRevokeRequest revokes one of the caller's API keys.
+type APIKeyRevokeRequest struct {
+	ID string `json:"id"`
+}
+
+// RoleGrantRequest grants (POST) or revokes (DELETE) a role.
+type RoleGrantRequest struct {
+	Identity string `json:"identity"`
+	Role     string `json:"role"`
+}
+
+// ProfileSetRequest switches the active econ profile.
+type ProfileSetRequest struct {
+	Profile string `json:"profile"`
+}
+
+// VaultCreateRequest submits a TX_VAULT_CREATE.
+type VaultCreateRequest struct {
+	Type string             `json:"type"`
+	Tx   core.TxVaultCreate `json:"tx"`
+}
+
+// TierRenewRequest submits a TX_TIER_RENEW.
+type TierRenewRequest struct {
+	Type string           `json:"type"`
+	Tx   core.TxTierRenew `json:"tx"`
+}
+
+// SimOverrides replaces individual parameters of the live econ state for
+// a simulation run.
+type SimOverrides struct {
+	Alpha             *float64 `json:"alpha,omitempty"`
+	CorridorFloor     *float64 `json:"corridor_floor,omitempty"`
+	CorridorTarget    *float64 `json:"corridor_target,omitempty"`
+	CorridorCeiling   *float64 `json:"corridor_ceiling,omitempty"`
+	IssuanceHalfLife  *float64 `json:"issuance_half_life,omitempty"`
+	TreasurySmoothing *float64 `json:"treasury_smoothing,omitempty"`
+	PopShare          *float64 `json:"pop_share,omitempty"`
+}
+
+// SimRequest runs the econ simulator from the live state.
+type SimRequest struct {
+	NumEpochs      int          `json:"num_epochs,omitempty"`
+	Mode           string       `json:"mode,omitempty"`
+	PreferredMerge string       `json:"preferred_merge,omitempty"`
+	Overrides      SimOverrides `json:"overrides,omitempty"`
+}
+
+// TxSimulateRequest dry-runs a transaction. Type is a TX_* name (the
+// prefix may be omitted); Tx is its body as the submit endpoint takes it.
+type TxSimulateRequest struct {
+	Type string          `json:"type"`
+	Tx   json.RawMessage `json:"tx"`
+}
+
+// PoPClaimWorkRequest submits a PoP work claim. operator_wallet may be
+// omitted when the node is registered; epoch defaults to the current one.
+type PoPClaimWorkRequest struct {
+	OperatorWallet string  `json:"operator_wallet,omitempty"`
+	Nonce          uint64  `json:"nonce,omitempty"`
+	Epoch          int64   `json:"epoch,omitempty"`
+	NodeID         string  `json:"node_id"`
+	UptimeScore    float64 `json:"uptime_score"`
+	RequestsServed float64 `json:"requests_served"`
+	BlocksRelayed  float64 `json:"blocks_relayed"`
+	StorageIO      float64 `json:"storage_io"`
+	LatencyScore   float64 `json:"latency_score"`
+}
+
+// TxSubmitResponse is returned by the endpoints that apply a transaction.
+type TxSubmitResponse struct {
+	Success bool   `json:"success"`
+	TxHash  string `json:"tx_hash"`
+	Height  uint64 `json:"height,omitempty"`
+}
+
+// ChainHeadResponse carries the current head block.
+type ChainHeadResponse struct {
+	Head *core.Block `json:"head"`
+}
+
+// ChainBlocksResponse is one page of blocks in ascending height.
+type ChainBlocksResponse struct {
+	Blocks         []*core.Block `json:"blocks"`
+	NextFromHeight uint64        `json:"next_from_height"`
+}
+
+// ChainBlockResponse carries a single block.
+type ChainBlockResponse struct {
+	Block *core.Block `json:"block"`
+}
+
+// BalancesResponse lists account balances at the tip or, when AtHeight is
+// set, as of that height.
+type BalancesResponse struct {
+	Accounts []*core.Account `json:"accounts"`
+	AtHeight *uint64         `json:"at_height,omitempty"`
+	AtEpoch  *int64          `json:"at_epoch,omitempty"`
+}
+
+// AccountNonceResponse is the next-nonce view of an address.
+type AccountNonceResponse struct {
+	Address string `json:"address"`
+	Nonce   uint64 `json:"nonce"`
+}
+
+// ProfileResponse names the active econ profile.
+type ProfileResponse struct {
+	Profile string `json:"profile"`
+}
+
+// AddressTxsResponse is one page of an address's transaction history.
+type AddressTxsResponse struct {
+	Address    string            `json:"address"`
+	Txs        []store.AddressTx `json:"txs"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// TxResponse carries a decoded transaction.
+type TxResponse struct {
+	Tx core.DecodedTx `json:"tx"`
+}
+
+// SearchMatch is one thing a search query resolved to. Path is the
+// /api/v1 route serving it, when there is one.
+type SearchMatch struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+	Path string `json:"path,omitempty"`
+}
+
+// SearchResponse lists every interpretation of a search query that
+// exists on this node.
+type SearchResponse struct {
+	Query   string        `json:"query"`
+	Matches []SearchMatch `json:"matches"`
+}
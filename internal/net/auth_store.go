@@ -0,0 +1,225 @@
+package net
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"reservechain/internal/logging"
+	"reservechain/internal/store"
+)
+
+// authStore holds login challenges and sessions. *store.DB implements it
+// so sessions survive restarts and can be shared by nodes on one database;
+// memAuthStore is the fallback when the node runs without SQLite.
+type authStore interface {
+	PutAuthChallenge(ctx context.Context, c store.AuthChallenge) error
+	GetAuthChallenge(ctx context.Context, identity string) (store.AuthChallenge, error)
+	ConsumeAuthChallenge(ctx context.Context, identity, challenge string) (bool, error)
+	InsertAuthSession(ctx context.Context, s store.AuthSession) error
+	GetAuthSession(ctx context.Context, id string) (store.AuthSession, error)
+	TouchAuthSession(ctx context.Context, id string, seen, expires time.Time) error
+	ListAuthSessions(ctx context.Context, identity string) ([]store.AuthSession, error)
+	DeleteAuthSession(ctx context.Context, id string) (bool, error)
+	DeleteAuthSessionsFor(ctx context.Context, identity, except string) (int64, error)
+	PruneAuth(ctx context.Context, now time.Time) (int64, error)
+}
+
+func newAuthStore(db *store.DB) authStore {
+	if db != nil {
+		return db
+	}
+	authLog.Warn("no database; sessions are kept in memory only")
+	return &memAuthStore{
+		challenges: make(map[string]store.AuthChallenge),
+		sessions:   make(map[string]store.AuthSession),
+	}
+}
+
+// Session lifetime. A session expires after sessionIdleTTL without use and
+// never outlives sessionMaxTTL from login, however often it is refreshed.
+var (
+	sessionIdleTTL atomic.Int64
+	sessionMaxTTL  atomic.Int64
+)
+
+const (
+	defaultSessionIdleTTL = 24 * time.Hour
+	defaultSessionMaxTTL  = 30 * 24 * time.Hour
+
+	// sessionTouchInterval bounds how often use of a session is written
+	// back to the store.
+	sessionTouchInterval = time.Minute
+)
+
+func init() { SetSessionPolicy(0, 0) }
+
+// SetSessionPolicy sets the idle and absolute session lifetimes. Zero
+// selects the defaults (24h idle, 30 days absolute).
+func SetSessionPolicy(idle, max time.Duration) {
+	if idle <= 0 {
+		idle = defaultSessionIdleTTL
+	}
+	if max <= 0 {
+		max = defaultSessionMaxTTL
+	}
+	if idle > max {
+		idle = max
+	}
+	sessionIdleTTL.Store(int64(idle))
+	sessionMaxTTL.Store(int64(max))
+}
+
+// slidingExpiry is the expiry of a session used at now.
+func slidingExpiry(now, maxExpires time.Time) time.Time {
+	exp := now.Add(time.Duration(sessionIdleTTL.Load()))
+	if exp.After(maxExpires) {
+		return maxExpires
+	}
+	return exp
+}
+
+// sessionIDFor derives the stored session ID from a session token.
+func sessionIDFor(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// runAuthPruner deletes expired challenges and sessions every interval
+// until ctx is done.
+func (api *HTTPAPI) runAuthPruner(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		n, err := api.auth.PruneAuth(ctx, time.Now().UTC())
+		if err != nil {
+			authLog.ErrorContext(ctx, "prune failed", logging.Err(err))
+		} else if n > 0 {
+			authLog.InfoContext(ctx, "pruned expired challenges and sessions", "count", n)
+		}
+	}
+}
+
+// memAuthStore is an in-process authStore.
+type memAuthStore struct {
+	mu         sync.Mutex
+	challenges map[string]store.AuthChallenge // key: identity
+	sessions   map[string]store.AuthSession   // key: session id
+}
+
+func (m *memAuthStore) PutAuthChallenge(_ context.Context, c store.AuthChallenge) error {
+	m.mu.Lock()
+	m.challenges[c.Identity] = c
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *memAuthStore) GetAuthChallenge(_ context.Context, identity string) (store.AuthChallenge, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.challenges[identity]
+	if !ok {
+		return store.AuthChallenge{}, store.ErrNotFound
+	}
+	return c, nil
+}
+
+func (m *memAuthStore) ConsumeAuthChallenge(_ context.Context, identity, challenge string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.challenges[identity]
+	if !ok || c.Challenge != challenge {
+		return false, nil
+	}
+	delete(m.challenges, identity)
+	return true, nil
+}
+
+func (m *memAuthStore) InsertAuthSession(_ context.Context, s store.AuthSession) error {
+	m.mu.Lock()
+	m.sessions[s.ID] = s
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *memAuthStore) GetAuthSession(_ context.Context, id string) (store.AuthSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return store.AuthSession{}, store.ErrNotFound
+	}
+	return s, nil
+}
+
+func (m *memAuthStore) TouchAuthSession(_ context.Context, id string, seen, expires time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.sessions[id]; ok {
+		s.LastSeenAt, s.ExpiresAt = seen, expires
+		m.sessions[id] = s
+	}
+	return nil
+}
+
+func (m *memAuthStore) ListAuthSessions(_ context.Context, identity string) ([]store.AuthSession, error) {
+	m.mu.Lock()
+	out := []store.AuthSession{}
+	for _, s := range m.sessions {
+		if s.Identity == identity {
+			out = append(out, s)
+		}
+	}
+	m.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].LastSeenAt.After(out[j].LastSeenAt) })
+	return out, nil
+}
+
+func (m *memAuthStore) DeleteAuthSession(_ context.Context, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.sessions[id]
+	delete(m.sessions, id)
+	return ok, nil
+}
+
+func (m *memAuthStore) DeleteAuthSessionsFor(_ context.Context, identity, except string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for id, s := range m.sessions {
+		if s.Identity == identity && id != except {
+			delete(m.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memAuthStore) PruneAuth(_ context.Context, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for id, c := range m.challenges {
+		if now.After(c.ExpiresAt) {
+			delete(m.challenges, id)
+			n++
+		}
+	}
+	for id, s := range m.sessions {
+		if now.After(s.ExpiresAt) {
+			delete(m.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
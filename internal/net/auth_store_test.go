@@ -0,0 +1,105 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"reservechain/internal/store"
+)
+
+func TestSlidingExpiry(t *testing.T) {
+	SetSessionPolicy(time.Hour, 24*time.Hour)
+	t.Cleanup(func() { SetSessionPolicy(0, 0) })
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		maxExpires time.Time
+		want       time.Time
+	}{
+		{"far from cap", now.Add(20 * time.Hour), now.Add(time.Hour)},
+		{"cap inside idle window", now.Add(30 * time.Minute), now.Add(30 * time.Minute)},
+		{"cap exactly at idle window", now.Add(time.Hour), now.Add(time.Hour)},
+		{"cap already passed", now.Add(-time.Minute), now.Add(-time.Minute)},
+	}
+	for _, tt := range tests {
+		if got := slidingExpiry(now, tt.maxExpires); !got.Equal(tt.want) {
+			t.Errorf("%s: expiry %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetSessionPolicy(t *testing.T) {
+	t.Cleanup(func() { SetSessionPolicy(0, 0) })
+	tests := []struct {
+		idle, max         time.Duration
+		wantIdle, wantMax time.Duration
+	}{
+		{0, 0, defaultSessionIdleTTL, defaultSessionMaxTTL},
+		{time.Hour, 0, time.Hour, defaultSessionMaxTTL},
+		{2 * time.Hour, time.Hour, time.Hour, time.Hour}, // idle never exceeds max
+		{-time.Hour, 48 * time.Hour, defaultSessionIdleTTL, 48 * time.Hour},
+	}
+	for _, tt := range tests {
+		SetSessionPolicy(tt.idle, tt.max)
+		idle, max := time.Duration(sessionIdleTTL.Load()), time.Duration(sessionMaxTTL.Load())
+		if idle != tt.wantIdle || max != tt.wantMax {
+			t.Errorf("SetSessionPolicy(%s, %s): idle %s max %s, want %s %s", tt.idle, tt.max, idle, max, tt.wantIdle, tt.wantMax)
+		}
+	}
+}
+
+func TestSessionSlidesOnUse(t *testing.T) {
+	SetSessionPolicy(time.Hour, 24*time.Hour)
+	t.Cleanup(func() { SetSessionPolicy(0, 0) })
+	api := NewHTTPAPI(NewWSHub(), nil, nil, nil, nil, nil)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	tests := []struct {
+		name     string
+		lastSeen time.Duration // relative to now
+		expires  time.Duration
+		max      time.Duration
+		ok       bool
+		want     time.Duration // expected expiry after the request
+	}{
+		{"recently touched", -10 * time.Second, 50 * time.Minute, 20 * time.Hour, true, 50 * time.Minute},
+		{"slides on use", -5 * time.Minute, 55 * time.Minute, 20 * time.Hour, true, time.Hour},
+		{"capped by max", -5 * time.Minute, 10 * time.Minute, 20 * time.Minute, true, 20 * time.Minute},
+		{"idle expired", -2 * time.Hour, -time.Second, 20 * time.Hour, false, -time.Second},
+	}
+	for i, tt := range tests {
+		token := "token-" + string(rune('a'+i))
+		row := store.AuthSession{
+			ID:           sessionIDFor(token),
+			Identity:     "rc:user",
+			Role:         RoleClient,
+			CreatedAt:    now.Add(-time.Hour),
+			LastSeenAt:   now.Add(tt.lastSeen),
+			ExpiresAt:    now.Add(tt.expires),
+			MaxExpiresAt: now.Add(tt.max),
+		}
+		if err := api.auth.InsertAuthSession(ctx, row); err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/session", nil)
+		req.AddCookie(&http.Cookie{Name: "rc_session", Value: token})
+		s, ok := api.sessionFromRequest(req)
+		if ok != tt.ok {
+			t.Fatalf("%s: ok = %v, want %v", tt.name, ok, tt.ok)
+		}
+		stored, err := api.auth.GetAuthSession(ctx, row.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := now.Add(tt.want)
+		if d := stored.ExpiresAt.Sub(want); d < -time.Second || d > time.Second {
+			t.Errorf("%s: stored expiry %s, want %s", tt.name, stored.ExpiresAt, want)
+		}
+		if ok && !s.ExpiresAt.Equal(stored.ExpiresAt) {
+			t.Errorf("%s: session expiry %s, stored %s", tt.name, s.ExpiresAt, stored.ExpiresAt)
+		}
+	}
+}
@@ -2,11 +2,14 @@ package net
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"log"
 	"net/http"
 	"strings"
 	"time"
+
+	"reservechain/internal/logging"
+	"reservechain/internal/store"
 )
 
 // DiscoverPeersFromSeeds contacts the configured seed HTTP endpoints, registers
@@ -15,7 +18,11 @@ import (
 //
 // seeds should be host:port, "http://host:port", etc. selfAddr should be the
 // HTTP base URL for this node's /api endpoints (e.g. "http://127.0.0.1:8080").
-func DiscoverPeersFromSeeds(seeds []string, selfAddr string, maxPeers int) []string {
+//
+// Seeds and discovered peers are persisted in db. If no seed answers, the
+// persisted peer list is probed instead so the node can still bootstrap.
+func DiscoverPeersFromSeeds(db *store.DB, seeds []string, selfAddr string, maxPeers int) []string {
+	ctx := context.Background()
 	client := &http.Client{Timeout: 3 * time.Second}
 	seen := make(map[string]struct{})
 	peers := make([]string, 0, maxPeers)
@@ -25,17 +32,26 @@ func DiscoverPeersFromSeeds(seeds []string, selfAddr string, maxPeers int) []str
 			continue
 		}
 		base := normaliseSeedBase(raw)
+		if err := db.UpsertPeer(ctx, base, store.PeerSourceSeed); err != nil {
+			p2pLog.ErrorContext(ctx, "persist seed failed", "seed", base, logging.Err(err))
+		}
 
-		// Best-effort registration; failure here is non-fatal for discovery.
+		// Best-effort signed registration; failure here is non-fatal for discovery.
 		if selfAddr != "" {
-			payload := map[string]string{"addr": selfAddr}
-			buf, _ := json.Marshal(payload)
-			req, err := http.NewRequest(http.MethodPost, base+"/api/p2p/register", bytes.NewReader(buf))
-			if err == nil {
-				req.Header.Set("Content-Type", "application/json")
-				resp, err := client.Do(req)
+			if rr, err := newRegisterRequest(selfAddr); err != nil {
+				p2pLog.InfoContext(ctx, "seed register skipped", "seed", base, logging.Err(err))
+			} else {
+				buf, _ := json.Marshal(rr)
+				req, err := http.NewRequest(http.MethodPost, base+"/api/p2p/register", bytes.NewReader(buf))
 				if err == nil {
-					_ = resp.Body.Close()
+					req.Header.Set("Content-Type", "application/json")
+					resp, err := client.Do(req)
+					if err == nil {
+						if resp.StatusCode != http.StatusOK {
+							p2pLog.WarnContext(ctx, "seed register rejected", "seed", base, "status", resp.Status)
+						}
+						_ = resp.Body.Close()
+					}
 				}
 			}
 		}
@@ -43,12 +59,12 @@ func DiscoverPeersFromSeeds(seeds []string, selfAddr string, maxPeers int) []str
 		// Pull peer list from this seed.
 		resp, err := client.Get(base + "/api/p2p/peers")
 		if err != nil {
-			log.Printf("[p2p] seed %s peers error: %v", base, err)
+			p2pLog.WarnContext(ctx, "seed peers fetch failed", "seed", base, logging.Err(err))
 			continue
 		}
 		if resp.StatusCode != http.StatusOK {
 			_ = resp.Body.Close()
-			log.Printf("[p2p] seed %s peers status: %s", base, resp.Status)
+			p2pLog.WarnContext(ctx, "seed peers fetch failed", "seed", base, "status", resp.Status)
 			continue
 		}
 		var payload struct {
@@ -56,7 +72,7 @@ func DiscoverPeersFromSeeds(seeds []string, selfAddr string, maxPeers int) []str
 		}
 		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
 			_ = resp.Body.Close()
-			log.Printf("[p2p] seed %s peers decode error: %v", base, err)
+			p2pLog.WarnContext(ctx, "seed peers decode failed", "seed", base, logging.Err(err))
 			continue
 		}
 		_ = resp.Body.Close()
@@ -75,6 +91,9 @@ func DiscoverPeersFromSeeds(seeds []string, selfAddr string, maxPeers int) []str
 				continue
 			}
 			seen[p] = struct{}{}
+			if err := db.UpsertPeer(ctx, p, store.PeerSourceDiscovered); err != nil {
+				p2pLog.ErrorContext(ctx, "persist peer failed", "peer", p, logging.Err(err))
+			}
 			peers = append(peers, p)
 			if maxPeers > 0 && len(peers) >= maxPeers {
 				return peers
@@ -82,6 +101,41 @@ func DiscoverPeersFromSeeds(seeds []string, selfAddr string, maxPeers int) []str
 		}
 	}
 
+	if len(peers) == 0 {
+		peers = bootstrapFromPersisted(ctx, db, client, selfAddr, maxPeers)
+	}
+	return peers
+}
+
+// bootstrapFromPersisted probes the peers remembered from earlier runs and
+// returns the ones that still answer a signed handshake with the same node
+// ID and a matching chain ID.
+func bootstrapFromPersisted(ctx context.Context, db *store.DB, client *http.Client, selfAddr string, maxPeers int) []string {
+	recs, err := db.ListPeers(ctx, "", "", 0)
+	if err != nil {
+		p2pLog.ErrorContext(ctx, "load persisted peers failed", logging.Err(err))
+		return nil
+	}
+	var peers []string
+	for _, rec := range recs {
+		if rec.Source == store.PeerSourceSeed || rec.Addr == selfAddr {
+			continue
+		}
+		now := time.Now().UTC()
+		hs, err := probeHandshake(ctx, client, rec.Addr, rec.NodeID)
+		if err != nil {
+			store.LogWriteError(ctx, "mark_peer_failed", db.MarkPeerFailed(ctx, rec.Addr, err.Error(), now))
+			continue
+		}
+		store.LogWriteError(ctx, "mark_peer_live", db.MarkPeerLive(ctx, rec.Addr, hs.NodeID, hs.PubKey, hs.Version, hs.ChainID, now))
+		peers = append(peers, rec.Addr)
+		if maxPeers > 0 && len(peers) >= maxPeers {
+			break
+		}
+	}
+	if len(peers) > 0 {
+		p2pLog.InfoContext(ctx, "seeds unavailable, bootstrapped from persisted registry", "peers", len(peers))
+	}
 	return peers
 }
 
@@ -0,0 +1,149 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"reservechain/internal/logging"
+	"reservechain/internal/store"
+)
+
+// maxReplayEvents bounds how many missed events one resume may replay.
+const maxReplayEvents = 10000
+
+// EventJournal assigns every broadcast event a monotonically increasing
+// sequence number and keeps it for the retention window: the newest events
+// in a memory ring, all of them in the event_journal table when a DB is
+// attached. Reconnecting clients pass the last seq they saw to resume.
+type EventJournal struct {
+	db        *store.DB
+	retention time.Duration
+
+	mu      sync.RWMutex
+	nextSeq uint64
+	ring    []Event // circular buffer of the newest events
+	start   int     // index of the oldest event in ring
+	count   int
+}
+
+// NewEventJournal creates a journal keeping events for retention and the
+// newest bufferSize of them in memory. Sequence numbers continue from the
+// highest one already persisted.
+func NewEventJournal(db *store.DB, retention time.Duration, bufferSize int) *EventJournal {
+	if bufferSize <= 0 {
+		bufferSize = 4096
+	}
+	if retention <= 0 {
+		retention = 24 * time.Hour
+	}
+	j := &EventJournal{db: db, retention: retention, ring: make([]Event, bufferSize), nextSeq: 1}
+	if _, hi, err := db.EventSeqRange(context.Background()); err != nil {
+		eventsLog.Error("read journal range failed", logging.Err(err))
+	} else if hi > 0 {
+		j.nextSeq = hi + 1
+	}
+	return j
+}
+
+// Append assigns ev the next sequence number and records it.
+func (j *EventJournal) Append(ev Event) Event {
+	j.mu.Lock()
+	ev.Seq = j.nextSeq
+	j.nextSeq++
+	if j.count < len(j.ring) {
+		j.ring[(j.start+j.count)%len(j.ring)] = ev
+		j.count++
+	} else {
+		j.ring[j.start] = ev
+		j.start = (j.start + 1) % len(j.ring)
+	}
+	j.mu.Unlock()
+
+	if j.db != nil {
+		payload, err := json.Marshal(ev.Payload)
+		if err == nil {
+			err = j.db.AppendEvent(context.Background(), store.EventRow{
+				Seq:       ev.Seq,
+				EventID:   ev.ID,
+				EventType: string(ev.Type),
+				Version:   ev.Version,
+				Payload:   payload,
+				CreatedAt: ev.Timestamp,
+			})
+		}
+		if err != nil {
+			eventsLog.Error("persist event failed", "seq", ev.Seq, logging.Err(err))
+		}
+	}
+	return ev
+}
+
+// LastSeq returns the sequence number of the newest event (0 if none).
+func (j *EventJournal) LastSeq() uint64 {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.nextSeq - 1
+}
+
+// Since returns events with seq > after, oldest first, up to limit.
+// truncated is set when events after `after` have already been pruned, so
+// the caller cannot be brought fully up to date.
+func (j *EventJournal) Since(ctx context.Context, after uint64, limit int) (events []Event, truncated bool, err error) {
+	if limit <= 0 || limit > maxReplayEvents {
+		limit = maxReplayEvents
+	}
+	j.mu.RLock()
+	if j.count > 0 && j.ring[j.start].Seq <= after+1 {
+		for i := 0; i < j.count && len(events) < limit; i++ {
+			if ev := j.ring[(j.start+i)%len(j.ring)]; ev.Seq > after {
+				events = append(events, ev)
+			}
+		}
+		j.mu.RUnlock()
+		return events, false, nil
+	}
+	last := j.nextSeq - 1
+	j.mu.RUnlock()
+	if after >= last {
+		return nil, false, nil
+	}
+
+	rows, err := j.db.ListEventsSince(ctx, after, limit)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, r := range rows {
+		events = append(events, Event{
+			ID:        r.EventID,
+			Seq:       r.Seq,
+			Type:      EventType(r.EventType),
+			Version:   r.Version,
+			Payload:   json.RawMessage(r.Payload),
+			Timestamp: r.CreatedAt,
+		})
+	}
+	truncated = len(events) == 0 || events[0].Seq > after+1
+	return events, truncated, nil
+}
+
+// RunPruner deletes persisted events older than the retention window
+// every interval until ctx is done.
+func (j *EventJournal) RunPruner(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n, err := j.db.PruneEvents(ctx, time.Now().Add(-j.retention))
+		if err != nil {
+			eventsLog.ErrorContext(ctx, "prune failed", logging.Err(err))
+		} else if n > 0 {
+			eventsLog.InfoContext(ctx, "pruned journal events", "count", n)
+		}
+	}
+}
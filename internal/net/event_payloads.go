@@ -0,0 +1,256 @@
+package net
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"reservechain/internal/core"
+	"reservechain/internal/econ"
+)
+
+// EventPayload is implemented by every typed event payload. The type and
+// version it reports are the ones the event is published under, so a
+// payload struct and its registry entry cannot drift apart.
+//
+// A change that renames, removes or retypes a field must ship as a new
+// struct under a bumped version; adding an optional field does not.
+type EventPayload interface {
+	EventType() EventType
+	EventVersion() string
+}
+
+// NewEvent wraps a typed payload in an Event envelope.
+func NewEvent(id string, p EventPayload, ts time.Time) Event {
+	return Event{
+		ID:        id,
+		Type:      p.EventType(),
+		Version:   p.EventVersion(),
+		Payload:   p,
+		Timestamp: ts,
+	}
+}
+
+// NewBlockPayload is a sealed or accepted block.
+type NewBlockPayload core.Block
+
+func (NewBlockPayload) EventType() EventType { return EventNewBlock }
+func (NewBlockPayload) EventVersion() string { return "v1" }
+
+// TransferPayload is a TX_TRANSFER applied to the ledger.
+type TransferPayload struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Asset  string  `json:"asset"`
+	Amount float64 `json:"amount"`
+	TxHash string  `json:"tx_hash"`
+	Height uint64  `json:"height"`
+}
+
+func (TransferPayload) EventType() EventType { return EventTransfer }
+func (TransferPayload) EventVersion() string { return "v1" }
+
+// MintPayload records Deposit of Asset taken in from Address and the
+// Minted amount of Token (GRC) credited to it at the NAV of the mint.
+type MintPayload struct {
+	Address string  `json:"address"`
+	Asset   string  `json:"asset"`
+	Token   string  `json:"token"`
+	Deposit float64 `json:"deposit"`
+	Minted  float64 `json:"minted"`
+}
+
+func (MintPayload) EventType() EventType { return EventMint }
+func (MintPayload) EventVersion() string { return "v2" }
+
+// RedeemPayload records Burned Token (GRC) taken from Address and the
+// Payout of Asset paid to it at the NAV of the redemption.
+type RedeemPayload struct {
+	Address string  `json:"address"`
+	Asset   string  `json:"asset"`
+	Token   string  `json:"token"`
+	Burned  float64 `json:"burned"`
+	Payout  float64 `json:"payout"`
+}
+
+func (RedeemPayload) EventType() EventType { return EventRedeem }
+func (RedeemPayload) EventVersion() string { return "v2" }
+
+// legacyConversionPayload is the v1 Mint/Redeem shape, where from/to held
+// asset symbols. It is kept so journalled v1 events still decode.
+type legacyConversionPayload struct {
+	Address string  `json:"address"`
+	From    string  `json:"from"`
+	To      string  `json:"to"`
+	Amount  float64 `json:"amount"`
+}
+
+type mintPayloadV1 legacyConversionPayload
+
+func (mintPayloadV1) EventType() EventType { return EventMint }
+func (mintPayloadV1) EventVersion() string { return "v1" }
+
+type redeemPayloadV1 legacyConversionPayload
+
+func (redeemPayloadV1) EventType() EventType { return EventRedeem }
+func (redeemPayloadV1) EventVersion() string { return "v1" }
+
+// ValuationTickPayload is the leader-signed valuation snapshot.
+type ValuationTickPayload econ.ValuationTick
+
+func (ValuationTickPayload) EventType() EventType { return EventValuationTick }
+func (ValuationTickPayload) EventVersion() string { return "v1" }
+
+// WindowUpdatePayload is the state of a settlement window.
+type WindowUpdatePayload econ.WindowSnapshot
+
+func (WindowUpdatePayload) EventType() EventType { return EventWindowUpdate }
+func (WindowUpdatePayload) EventVersion() string { return "v1" }
+
+// TreasuryUpdatePayload is a treasury balance sheet snapshot.
+type TreasuryUpdatePayload econ.TreasuryBalanceSheet
+
+func (TreasuryUpdatePayload) EventType() EventType { return EventTreasuryUpdate }
+func (TreasuryUpdatePayload) EventVersion() string { return "v1" }
+
+// NodeStatusPayload summarises this node's view of the chain.
+type NodeStatusPayload struct {
+	NodeID  string `json:"node_id"`
+	ChainID string `json:"chain_id"`
+	Height  uint64 `json:"height"`
+	Head    string `json:"head"`
+	Peers   int    `json:"peers"`
+}
+
+func (NodeStatusPayload) EventType() EventType { return EventNodeStatus }
+func (NodeStatusPayload) EventVersion() string { return "v1" }
+
+// ProfileChangePayload announces a new economic profile.
+type ProfileChangePayload struct {
+	Profile string `json:"profile"`
+}
+
+func (ProfileChangePayload) EventType() EventType { return EventProfileChange }
+func (ProfileChangePayload) EventVersion() string { return "v1" }
+
+// TierRenewPayload is a TX_TIER_RENEW applied to the ledger.
+type TierRenewPayload struct {
+	Sender string  `json:"sender"`
+	Tier   string  `json:"tier"`
+	Amount float64 `json:"amount"`
+}
+
+func (TierRenewPayload) EventType() EventType { return EventTierRenew }
+func (TierRenewPayload) EventVersion() string { return "v1" }
+
+// VaultCreatePayload is a TX_VAULT_CREATE applied to the ledger.
+type VaultCreatePayload struct {
+	VaultID string `json:"vault_id"`
+	Owner   string `json:"owner"`
+	Type    string `json:"type"`
+}
+
+func (VaultCreatePayload) EventType() EventType { return EventVaultCreate }
+func (VaultCreatePayload) EventVersion() string { return "v1" }
+
+// EventSchema is one registered (type, version) pair.
+type EventSchema struct {
+	Type        EventType
+	Version     string
+	Description string
+	Deprecated  bool
+	payload     reflect.Type
+}
+
+type eventKey struct {
+	typ     EventType
+	version string
+}
+
+var eventRegistry = map[eventKey]EventSchema{}
+
+func registerEvent(p EventPayload, desc string, deprecated bool) {
+	k := eventKey{p.EventType(), p.EventVersion()}
+	if _, dup := eventRegistry[k]; dup {
+		panic(fmt.Sprintf("event %s/%s registered twice", k.typ, k.version))
+	}
+	eventRegistry[k] = EventSchema{
+		Type:        k.typ,
+		Version:     k.version,
+		Description: desc,
+		Deprecated:  deprecated,
+		payload:     reflect.TypeOf(p),
+	}
+}
+
+func init() {
+	registerEvent(NewBlockPayload{}, "A block was sealed locally or accepted from a peer.", false)
+	registerEvent(TransferPayload{}, "A transfer was applied to the ledger.", false)
+	registerEvent(MintPayload{}, "GRC was minted against a deposited asset.", false)
+	registerEvent(RedeemPayload{}, "GRC was redeemed for an asset.", false)
+	registerEvent(mintPayloadV1{}, "Superseded by Mint v2: from/to held asset symbols.", true)
+	registerEvent(redeemPayloadV1{}, "Superseded by Redeem v2: from/to held asset symbols.", true)
+	registerEvent(ValuationTickPayload{}, "The elected leader published a valuation tick.", false)
+	registerEvent(WindowUpdatePayload{}, "A settlement window changed state.", false)
+	registerEvent(TreasuryUpdatePayload{}, "The treasury balance sheet was recomputed.", false)
+	registerEvent(NodeStatusPayload{}, "Node status summary.", false)
+	registerEvent(ProfileChangePayload{}, "The economic profile was changed.", false)
+	registerEvent(TierRenewPayload{}, "A tier renewal was applied to the ledger.", false)
+	registerEvent(VaultCreatePayload{}, "A vault was created on-chain.", false)
+}
+
+// LookupEventSchema returns the registry entry for t at version.
+func LookupEventSchema(t EventType, version string) (EventSchema, bool) {
+	s, ok := eventRegistry[eventKey{t, version}]
+	return s, ok
+}
+
+// EventSchemas returns every registered event, ordered by type and version.
+func EventSchemas() []EventSchema {
+	out := make([]EventSchema, 0, len(eventRegistry))
+	for _, s := range eventRegistry {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Type != out[j].Type {
+			return out[i].Type < out[j].Type
+		}
+		return out[i].Version < out[j].Version
+	})
+	return out
+}
+
+// DecodeEventPayload returns ev's payload as its registered struct. It
+// accepts payloads that are already typed as well as raw JSON replayed
+// from the journal or relayed by a peer.
+func DecodeEventPayload(ev Event) (EventPayload, error) {
+	s, ok := LookupEventSchema(ev.Type, ev.Version)
+	if !ok {
+		return nil, fmt.Errorf("unregistered event %s/%s", ev.Type, ev.Version)
+	}
+	if p, ok := ev.Payload.(EventPayload); ok && reflect.TypeOf(p) == s.payload {
+		return p, nil
+	}
+	raw, ok := ev.Payload.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(ev.Payload); err != nil {
+			return nil, err
+		}
+	}
+	ptr := reflect.New(s.payload)
+	if err := json.Unmarshal(raw, ptr.Interface()); err != nil {
+		return nil, fmt.Errorf("decode %s/%s payload: %w", ev.Type, ev.Version, err)
+	}
+	return ptr.Elem().Interface().(EventPayload), nil
+}
+
+// checkEventRegistered logs events published under a type/version with no
+// registered schema; they still go out, but consumers have no contract.
+func checkEventRegistered(ev Event) {
+	if _, ok := LookupEventSchema(ev.Type, ev.Version); !ok {
+		eventsLog.Warn("event has no registered schema", "type", ev.Type, "version", ev.Version)
+	}
+}
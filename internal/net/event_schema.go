@@ -0,0 +1,143 @@
+package net
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// EventSchemaDocument builds a JSON Schema (draft 2020-12) for the event
+// envelope, with one $defs entry per registered type/version named
+// "<Type>.<version>" and a oneOf tying each type/version to its payload.
+func EventSchemaDocument() map[string]interface{} {
+	defs := map[string]interface{}{}
+	variants := []interface{}{}
+	for _, s := range EventSchemas() {
+		name := string(s.Type) + "." + s.Version
+		def := typeSchema(s.payload)
+		def["title"] = name
+		def["description"] = s.Description
+		if s.Deprecated {
+			def["deprecated"] = true
+		}
+		defs[name] = def
+		variants = append(variants, map[string]interface{}{
+			"properties": map[string]interface{}{
+				"type":    map[string]interface{}{"const": string(s.Type)},
+				"version": map[string]interface{}{"const": s.Version},
+				"payload": map[string]interface{}{"$ref": "#/$defs/" + name},
+			},
+		})
+	}
+	return map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"$id":         "https://reservechain.org/schemas/events.json",
+		"title":       "ReserveChain event",
+		"description": "Envelope of every event on /ws, /api/events/stream and /api/events.",
+		"type":        "object",
+		"properties": map[string]interface{}{
+			"id":        map[string]interface{}{"type": "string"},
+			"seq":       map[string]interface{}{"type": "integer", "minimum": 1},
+			"type":      map[string]interface{}{"type": "string"},
+			"version":   map[string]interface{}{"type": "string"},
+			"payload":   map[string]interface{}{},
+			"timestamp": map[string]interface{}{"type": "string", "format": "date-time"},
+		},
+		"required": []string{"id", "type", "version", "payload", "timestamp"},
+		"oneOf":    variants,
+		"$defs":    defs,
+	}
+}
+
+// typeSchema maps a Go type to JSON Schema following encoding/json rules.
+func typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawJSONType:
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		props := map[string]interface{}{}
+		required := []string{}
+		structFields(t, props, &required)
+		out := map[string]interface{}{"type": "object", "properties": props}
+		if len(required) > 0 {
+			out["required"] = required
+		}
+		return out
+	}
+	// interface{} and anything else: any JSON value.
+	return map[string]interface{}{}
+}
+
+func structFields(t reflect.Type, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				structFields(ft, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = typeSchema(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// eventsSchemaHandler serves EventSchemaDocument.
+func (api *HTTPAPI) eventsSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(EventSchemaDocument())
+}
@@ -13,10 +13,19 @@ const (
     EventWindowUpdate   EventType = "WindowUpdate"
     EventTreasuryUpdate EventType = "TreasuryUpdate"
     EventNodeStatus     EventType = "NodeStatus"
+    EventProfileChange  EventType = "ProfileChange"
+    EventTierRenew      EventType = "TierRenew"
+    EventVaultCreate    EventType = "VaultCreate"
 )
 
+// Event is one item on the /ws and SSE streams. Seq is assigned by the
+// hub's EventJournal and increases by one per event; clients resume from
+// the last seq they saw. Payload is one of the registered EventPayload
+// structs for Type/Version (see event_payloads.go), or its raw JSON once
+// replayed from the journal or relayed by a peer.
 type Event struct {
     ID        string      `json:"id"`
+    Seq       uint64      `json:"seq,omitempty"`
     Type      EventType   `json:"type"`
     Version   string      `json:"version"`
     Payload   interface{} `json:"payload"`
@@ -4,12 +4,13 @@ package net
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"reservechain/internal/core"
+	"reservechain/internal/logging"
 	"reservechain/internal/store"
 )
 
@@ -35,13 +36,19 @@ func NewChainFollower(chain *core.Chain, db *store.DB, baseURL string, poll time
 	}
 }
 
-func (f *ChainFollower) Run() {
+// Run polls the upstream every PollInterval until ctx is done.
+func (f *ChainFollower) Run(ctx context.Context) {
 	ticker := time.NewTicker(f.PollInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		if err := f.syncOnce(context.Background()); err != nil {
-			log.Printf("[follower] sync error: %v", err)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := f.syncOnce(ctx); err != nil && ctx.Err() == nil {
+			syncLog.WarnContext(ctx, "sync failed", "upstream", f.BaseURL, logging.Err(err))
 		}
 	}
 }
@@ -73,6 +80,7 @@ func (f *ChainFollower) syncOnce(ctx context.Context) error {
 		return nil
 	}
 	if headPayload.Head.Height <= localHeight {
+		recordSyncLag(f.BaseURL, 0)
 		return nil
 	}
 
@@ -103,10 +111,14 @@ func (f *ChainFollower) syncOnce(ctx context.Context) error {
 			break
 		}
 		for _, blk := range blkPayload.Blocks {
+			if err := verifySyncedBlock(f.Chain.Head(), blk); err != nil {
+				return err
+			}
+
 			// Persist to local DB if available.
 			if f.DB != nil {
-				if err := f.DB.InsertBlockAndTx(ctx, blk.Hash, blk.PrevHash, blk.Height, blk.TxType, blk.Nonce, blk.Difficulty, blk.Tx); err != nil {
-					log.Printf("[follower] insert block %d failed: %v", blk.Height, err)
+				if err := f.DB.InsertBlockAndTxAt(ctx, blk.Timestamp, blk.Hash, blk.PrevHash, blk.Height, blk.TxType, blk.Nonce, blk.Difficulty, blk.StateRoot, blk.Tx); err != nil {
+					syncLog.ErrorContext(ctx, "insert block failed", "height", blk.Height, logging.Err(err))
 				}
 			}
 
@@ -123,15 +135,16 @@ func (f *ChainFollower) syncOnce(ctx context.Context) error {
 						Timestamp: blk.Timestamp,
 					}
 					if err := f.Chain.ReplayFromTxRows([]store.ChainTxRow{row}); err != nil {
-						log.Printf("[follower] replay block %d failed: %v", blk.Height, err)
+						syncLog.ErrorContext(ctx, "replay block failed", "height", blk.Height, logging.Err(err))
 					}
 				}
 			}
 
 			// Append to in-memory chain header list.
-			f.Chain.AppendRemoteBlock(blk)
+			f.Chain.AppendRemoteBlock(ctx, blk)
 			next = blk.Height + 1
 		}
+		recordSyncLag(f.BaseURL, lagBehind(headPayload.Head.Height, f.Chain.Head()))
 
 		if blkPayload.NextFromHeight <= next {
 			break
@@ -140,3 +153,29 @@ func (f *ChainFollower) syncOnce(ctx context.Context) error {
 	}
 	return nil
 }
+
+// verifySyncedBlock checks that a block from a sync source extends the
+// local tip (nil for an empty chain) and carries a valid proof of work.
+func verifySyncedBlock(tip, blk *core.Block) error {
+	if tip != nil {
+		if blk.Height != tip.Height+1 {
+			return fmt.Errorf("block %d does not follow local tip %d", blk.Height, tip.Height)
+		}
+		if blk.PrevHash != tip.Hash {
+			return fmt.Errorf("block %d: prev_hash %s does not match local tip %s", blk.Height, blk.PrevHash, tip.Hash)
+		}
+	}
+	return core.VerifyBlockPoW(blk)
+}
+
+// lagBehind is how far the local tip is behind head.
+func lagBehind(head uint64, tip *core.Block) float64 {
+	var h uint64
+	if tip != nil {
+		h = tip.Height
+	}
+	if head <= h {
+		return 0
+	}
+	return float64(head - h)
+}
@@ -0,0 +1,72 @@
+package net
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"reservechain/internal/store"
+)
+
+// addressTxsHandler serves GET /api/v1/address/{addr}/txs: the
+// transactions touching addr, newest first, from the chain_tx_addresses
+// index. types and role are comma-separated filters ("transfer" and
+// "TX_TRANSFER" are equivalent); cursor is next_cursor of the previous
+// page.
+func (api *HTTPAPI) addressTxsHandler(w http.ResponseWriter, r *http.Request) {
+	if api.DB == nil {
+		writeAPIError(w, http.StatusServiceUnavailable, "unavailable", "address index requires a database")
+		return
+	}
+	addr := pathParam(r, "addr")
+	q := r.URL.Query()
+
+	query := store.AddressTxQuery{Address: addr, Limit: 50}
+	if c := q.Get("cursor"); c != "" {
+		id, err := strconv.ParseInt(c, 10, 64)
+		if err != nil || id <= 0 {
+			writeAPIError(w, http.StatusBadRequest, "bad_cursor", "cursor must come from next_cursor")
+			return
+		}
+		query.Before = id
+	}
+	if l := q.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 || n > 200 {
+			writeAPIError(w, http.StatusBadRequest, "bad_limit", "limit must be 1-200")
+			return
+		}
+		query.Limit = n
+	}
+	for _, t := range splitList(q.Get("types")) {
+		query.Types = append(query.Types, normalizeTxType(t))
+	}
+	query.Roles = splitList(q.Get("role"))
+
+	txs, err := api.DB.ListAddressTxs(r.Context(), query)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	resp := AddressTxsResponse{Address: addr, Txs: txs}
+	if resp.Txs == nil {
+		resp.Txs = []store.AddressTx{}
+	}
+	if len(txs) == query.Limit {
+		resp.NextCursor = strconv.FormatInt(txs[len(txs)-1].ID, 10)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// splitList splits a comma-separated query value, dropping blanks.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
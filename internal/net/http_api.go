@@ -1,12 +1,14 @@
 package net
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
-	"sync"
 	"time"
 
 	"reservechain/internal/analytics"
@@ -15,61 +17,6 @@ import (
 	"reservechain/internal/store"
 )
 
-// -----------------------------------------------------------------------------
-// DevNet Seed Registry (in-memory, TTL-based)
-// -----------------------------------------------------------------------------
-
-var (
-	seedMode     bool
-	seedRegistry = newSeedRegistry(5 * time.Minute)
-)
-
-// EnableSeedMode toggles whether this node behaves as a seed for simple
-// HTTP-based peer discovery.
-func EnableSeedMode(on bool) {
-	seedMode = on
-}
-
-type SeedRegistry struct {
-	mu    sync.Mutex
-	peers map[string]time.Time
-	ttl   time.Duration
-}
-
-func newSeedRegistry(ttl time.Duration) *SeedRegistry {
-	return &SeedRegistry{
-		peers: make(map[string]time.Time),
-		ttl:   ttl,
-	}
-}
-
-func (r *SeedRegistry) Add(addr string) {
-	if addr == "" {
-		return
-	}
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	r.peers[addr] = time.Now().UTC()
-}
-
-func (r *SeedRegistry) List() []string {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	now := time.Now().UTC()
-	for addr, ts := range r.peers {
-		if now.Sub(ts) > r.ttl {
-			delete(r.peers, addr)
-		}
-	}
-
-	out := make([]string, 0, len(r.peers))
-	for addr := range r.peers {
-		out = append(out, addr)
-	}
-	return out
-}
-
 // HTTPAPI bundles dependencies for HTTP handlers.
 type HTTPAPI struct {
 	Hub   *WSHub
@@ -82,10 +29,8 @@ type HTTPAPI struct {
 	// Workstation portal static build (Vite dist)
 	WorkstationDist string
 
-	// Auth/session (in-memory for now; can be backed by DB later)
-	authMu   sync.Mutex
-	nonces   map[string]nonceEntry   // key: address
-	sessions map[string]sessionEntry // key: session id
+	// Login challenges and sessions (SQLite, or memory without a DB)
+	auth authStore
 }
 
 func defaultWorkstationDist() string {
@@ -106,8 +51,7 @@ func NewHTTPAPI(hub *WSHub, store *core.AccountStore, wm *econ.WindowManager, db
 		Chain:           chain,
 		Miner:           miner,
 		WorkstationDist: defaultWorkstationDist(),
-		nonces:          make(map[string]nonceEntry),
-		sessions:        make(map[string]sessionEntry),
+		auth:            newAuthStore(db),
 	}
 }
 
@@ -142,11 +86,30 @@ func (api *HTTPAPI) balancesHandler(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	accounts := api.Store.SnapshotAll()
+	point, ok := api.historicalPoint(w, r)
+	if !ok {
+		return
+	}
+	addr := r.URL.Query().Get("address")
+	resp := BalancesResponse{}
+	switch {
+	case point != nil:
+		accounts, err := api.accountsAt(r.Context(), addr, point.Height)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		resp.Accounts, resp.AtHeight, resp.AtEpoch = accounts, &point.Height, point.Epoch
+	case addr != "":
+		resp.Accounts = []*core.Account{api.Store.Snapshot(addr)}
+	default:
+		resp.Accounts = api.Store.SnapshotAll()
+	}
+	if resp.Accounts == nil {
+		resp.Accounts = []*core.Account{}
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"accounts": accounts,
-	})
+	json.NewEncoder(w).Encode(resp)
 }
 
 // MintRequest describes a request to mint GRC against a backing asset (DevNet: USDC).
@@ -169,8 +132,14 @@ func (api *HTTPAPI) mintHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if req.Address == "" {
+		req.Address = sessionAddress(r)
+	}
+	if req.Address == "" && devnetOpenWrites.Load() {
 		req.Address = "demo-user"
 	}
+	if !authorizeActor(w, r, req.Address) {
+		return
+	}
 	if req.Asset == "" {
 		req.Asset = "USDC"
 	}
@@ -180,37 +149,26 @@ func (api *HTTPAPI) mintHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Delegate the balance changes + block creation to the Chain engine.
-	blk, _, err := api.Chain.ApplyMint(req.Address, req.Asset, req.Amount)
+	blk, _, err := api.Chain.ApplyMint(r.Context(), req.Address, req.Asset, req.Amount)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Emit existing mint event for frontends.
-	ev := Event{
-		ID:      "mint-" + time.Now().Format(time.RFC3339Nano),
-		Type:    EventMint,
-		Version: "v1",
-		Payload: map[string]interface{}{
-			"address": req.Address,
-			"from":    req.Asset,
-			"to":      "GRC",
-			"amount":  req.Amount,
-		},
-		Timestamp: time.Now().UTC(),
+	// Emit the mint event for frontends, with the amounts the chain applied.
+	if tx, ok := blk.Tx.(core.MintTx); ok {
+		api.Hub.Broadcast(NewEvent("mint-"+time.Now().Format(time.RFC3339Nano), MintPayload{
+			Address: tx.Address,
+			Asset:   tx.Asset,
+			Token:   "GRC",
+			Deposit: tx.Deposit,
+			Minted:  tx.Amount,
+		}, time.Now().UTC()))
 	}
-	api.Hub.Broadcast(ev)
 
 	// Also broadcast a NewBlock event so explorers / dashboards can follow the chain.
 	if blk != nil {
-		bev := Event{
-			ID:        fmt.Sprintf("block-%d", blk.Height),
-			Type:      EventNewBlock,
-			Version:   "v1",
-			Payload:   blk,
-			Timestamp: blk.Timestamp,
-		}
-		api.Hub.Broadcast(bev)
+		api.Hub.Broadcast(NewEvent(fmt.Sprintf("block-%d", blk.Height), NewBlockPayload(*blk), blk.Timestamp))
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -235,8 +193,14 @@ func (api *HTTPAPI) redeemHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if req.Address == "" {
+		req.Address = sessionAddress(r)
+	}
+	if req.Address == "" && devnetOpenWrites.Load() {
 		req.Address = "demo-user"
 	}
+	if !authorizeActor(w, r, req.Address) {
+		return
+	}
 	if req.Asset == "" {
 		req.Asset = "USDC"
 	}
@@ -245,7 +209,7 @@ func (api *HTTPAPI) redeemHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	blk, _, err := api.Chain.ApplyRedeem(req.Address, req.Asset, req.Amount)
+	blk, _, err := api.Chain.ApplyRedeem(r.Context(), req.Address, req.Asset, req.Amount)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -254,29 +218,18 @@ func (api *HTTPAPI) redeemHandler(w http.ResponseWriter, r *http.Request) {
 	// Record corridor volume in the window manager.
 	api.WMgr.RecordVolume(req.Amount)
 
-	ev := Event{
-		ID:      "redeem-" + time.Now().Format(time.RFC3339Nano),
-		Type:    EventRedeem,
-		Version: "v1",
-		Payload: map[string]interface{}{
-			"address": req.Address,
-			"from":    "GRC",
-			"to":      req.Asset,
-			"amount":  req.Amount,
-		},
-		Timestamp: time.Now().UTC(),
+	if tx, ok := blk.Tx.(core.RedeemTx); ok {
+		api.Hub.Broadcast(NewEvent("redeem-"+time.Now().Format(time.RFC3339Nano), RedeemPayload{
+			Address: tx.Address,
+			Asset:   tx.Asset,
+			Token:   "GRC",
+			Burned:  tx.Burned,
+			Payout:  tx.Amount,
+		}, time.Now().UTC()))
 	}
-	api.Hub.Broadcast(ev)
 
 	if blk != nil {
-		bev := Event{
-			ID:        fmt.Sprintf("block-%d", blk.Height),
-			Type:      EventNewBlock,
-			Version:   "v1",
-			Payload:   blk,
-			Timestamp: blk.Timestamp,
-		}
-		api.Hub.Broadcast(bev)
+		api.Hub.Broadcast(NewEvent(fmt.Sprintf("block-%d", blk.Height), NewBlockPayload(*blk), blk.Timestamp))
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -303,38 +256,42 @@ func (api *HTTPAPI) transferHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid type", http.StatusBadRequest)
 		return
 	}
+	if !authorizeActor(w, r, req.Tx.From) {
+		return
+	}
 
-	blk, hash, err := api.Chain.ApplyTransfer(req.Tx)
+	blk, hash, err := api.applyTransfer(r.Context(), req.Tx)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Broadcast a Transfer event for frontends.
-	ev := Event{
-		ID:      "transfer-" + time.Now().Format(time.RFC3339Nano),
-		Type:    EventType("Transfer"),
-		Version: "v1",
-		Payload: map[string]interface{}{
-			"from":    req.Tx.From,
-			"to":      req.Tx.To,
-			"asset":   req.Tx.Asset,
-			"amount":  req.Tx.Amount,
-			"tx_hash": hash,
-			"height":  blk.Height,
-		},
-		Timestamp: time.Now().UTC(),
-	}
-	api.Hub.Broadcast(ev)
-
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"tx_hash": hash,
-		"height":  blk.Height,
+	_ = json.NewEncoder(w).Encode(TxSubmitResponse{
+		Success: true,
+		TxHash:  hash,
+		Height:  blk.Height,
 	})
 }
 
+// applyTransfer applies a TX_TRANSFER and announces it to event clients.
+func (api *HTTPAPI) applyTransfer(ctx context.Context, tx core.TransferTx) (*core.Block, string, error) {
+	blk, hash, err := api.Chain.ApplyTransfer(ctx, tx)
+	if err != nil {
+		return nil, "", err
+	}
+	// Broadcast a Transfer event for frontends.
+	api.Hub.Broadcast(NewEvent("transfer-"+time.Now().Format(time.RFC3339Nano), TransferPayload{
+		From:   tx.From,
+		To:     tx.To,
+		Asset:  tx.Asset,
+		Amount: tx.Amount,
+		TxHash: hash,
+		Height: blk.Height,
+	}, time.Now().UTC()))
+	return blk, hash, nil
+}
+
 // vaultCreateHandler records a TxVaultCreate on-chain so that L1 history
 // knows about vault creation events and multi-sig parameters.
 func (api *HTTPAPI) vaultCreateHandler(w http.ResponseWriter, r *http.Request) {
@@ -342,10 +299,7 @@ func (api *HTTPAPI) vaultCreateHandler(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	var req struct {
-		Type string             `json:"type"`
-		Tx   core.TxVaultCreate `json:"tx"`
-	}
+	var req VaultCreateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -354,44 +308,40 @@ func (api *HTTPAPI) vaultCreateHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid type", http.StatusBadRequest)
 		return
 	}
+	if !authorizeActor(w, r, req.Tx.Owner) {
+		return
+	}
 
-	blk, hash, err := api.Chain.ApplyVaultCreate(req.Tx)
+	blk, hash, err := api.applyVaultCreate(r.Context(), req.Tx)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Broadcast a lightweight event for UIs that care about vault creation.
-	ev := Event{
-		ID:      "vault-create-" + time.Now().Format(time.RFC3339Nano),
-		Type:    EventType("VaultCreate"),
-		Version: "v1",
-		Payload: map[string]interface{}{
-			"vault_id": req.Tx.VaultID,
-			"owner":    req.Tx.Owner,
-			"type":     req.Tx.Type,
-		},
-		Timestamp: time.Now().UTC(),
-	}
-	api.Hub.Broadcast(ev)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TxSubmitResponse{
+		Success: true,
+		TxHash:  hash,
+		Height:  blk.Height,
+	})
+}
 
+// applyVaultCreate applies a TX_VAULT_CREATE and announces it to event clients.
+func (api *HTTPAPI) applyVaultCreate(ctx context.Context, tx core.TxVaultCreate) (*core.Block, string, error) {
+	blk, hash, err := api.Chain.ApplyVaultCreate(ctx, tx)
+	if err != nil {
+		return nil, "", err
+	}
+	// Broadcast a lightweight event for UIs that care about vault creation.
+	api.Hub.Broadcast(NewEvent("vault-create-"+time.Now().Format(time.RFC3339Nano), VaultCreatePayload{
+		VaultID: tx.VaultID,
+		Owner:   tx.Owner,
+		Type:    tx.Type,
+	}, time.Now().UTC()))
 	if blk != nil {
-		bev := Event{
-			ID:        fmt.Sprintf("block-%d", blk.Height),
-			Type:      EventNewBlock,
-			Version:   "v1",
-			Payload:   blk,
-			Timestamp: blk.Timestamp,
-		}
-		api.Hub.Broadcast(bev)
+		api.Hub.Broadcast(NewEvent(fmt.Sprintf("block-%d", blk.Height), NewBlockPayload(*blk), blk.Timestamp))
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"tx_hash": hash,
-		"height":  blk.Height,
-	})
+	return blk, hash, nil
 }
 
 // redeemHandler burns GRC and credits USD back to the user.
@@ -406,8 +356,14 @@ func (api *HTTPAPI) redeemHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if req.Address == "" {
+		req.Address = sessionAddress(r)
+	}
+	if req.Address == "" && devnetOpenWrites.Load() {
 		req.Address = "demo-user"
 	}
+	if !authorizeActor(w, r, req.Address) {
+		return
+	}
 	if req.Asset == "" {
 		req.Asset = "USDC"
 	}
@@ -430,19 +386,13 @@ func (api *HTTPAPI) redeemHandler(w http.ResponseWriter, r *http.Request) {
 	// Record corridor volume in the window manager.
 	api.WMgr.RecordVolume(req.Amount)
 
-	ev := Event{
-		ID:      "redeem-" + time.Now().Format(time.RFC3339Nano),
-		Type:    EventRedeem,
-		Version: "v1",
-		Payload: map[string]interface{}{
-			"address": req.Address,
-			"from":    "GRC",
-			"to":      req.Asset,
-			"amount":  req.Amount,
-		},
-		Timestamp: time.Now().UTC(),
-	}
-	api.Hub.Broadcast(ev)
+	api.Hub.Broadcast(NewEvent("redeem-"+time.Now().Format(time.RFC3339Nano), RedeemPayload{
+		Address: req.Address,
+		Asset:   req.Asset,
+		Token:   "GRC",
+		Burned:  req.Amount,
+		Payout:  req.Amount,
+	}, time.Now().UTC()))
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -456,9 +406,9 @@ func (api *HTTPAPI) accountNonceHandler(w http.ResponseWriter, r *http.Request)
 	}
 	nonce := api.Store.GetNonce(addr)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"address": addr,
-		"nonce":   nonce,
+	json.NewEncoder(w).Encode(AccountNonceResponse{
+		Address: addr,
+		Nonce:   nonce,
 	})
 }
 
@@ -466,84 +416,75 @@ func (api *HTTPAPI) accountNonceHandler(w http.ResponseWriter, r *http.Request)
 func (api *HTTPAPI) chainHeadHandler(w http.ResponseWriter, r *http.Request) {
 	head := api.Chain.Head()
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"head": head,
-	})
+	json.NewEncoder(w).Encode(ChainHeadResponse{Head: head})
 }
 
 func (api *HTTPAPI) chainBlocksHandler(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 	fromHeight := uint64(0)
-	limit := uint64(50)
+	toHeight := uint64(0)
+	limit := 50
 
 	if fh := q.Get("from_height"); fh != "" {
 		if v, err := strconv.ParseUint(fh, 10, 64); err == nil {
 			fromHeight = v
 		}
 	}
+	if th := q.Get("to_height"); th != "" {
+		if v, err := strconv.ParseUint(th, 10, 64); err == nil {
+			toHeight = v
+		}
+	}
 	if lim := q.Get("limit"); lim != "" {
-		if v, err := strconv.ParseUint(lim, 10, 64); err == nil && v > 0 && v <= 500 {
+		if v, err := strconv.Atoi(lim); err == nil && v > 0 && v <= 500 {
 			limit = v
 		}
 	}
 
-	blocks := api.Chain.Blocks()
-	var out []*core.Block
-	for _, blk := range blocks {
-		if blk.Height < fromHeight {
-			continue
-		}
-		if uint64(len(out)) >= limit {
-			break
-		}
-		out = append(out, blk)
-	}
+	out := api.Chain.BlockRange(fromHeight, toHeight, limit)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"blocks": out,
-		"next_from_height": func() uint64 {
-			if len(out) == 0 {
-				return fromHeight
-			}
-			return out[len(out)-1].Height + 1
-		}(),
-	})
+	next := fromHeight
+	if len(out) > 0 {
+		next = out[len(out)-1].Height + 1
+	}
+	json.NewEncoder(w).Encode(ChainBlocksResponse{Blocks: out, NextFromHeight: next})
 }
 
+// chainBlockByHeightHandler looks a block up by ?height= or ?hash=.
 func (api *HTTPAPI) chainBlockByHeightHandler(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
-	fh := q.Get("height")
-	if fh == "" {
-		http.Error(w, "missing height", http.StatusBadRequest)
+	var (
+		blk *core.Block
+		ok  bool
+	)
+	switch {
+	case q.Get("hash") != "":
+		blk, ok = api.Chain.BlockByHash(normalizeHash(q.Get("hash")))
+	case q.Get("height") != "":
+		height, err := strconv.ParseUint(q.Get("height"), 10, 64)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid_height", "height must be an unsigned integer")
+			return
+		}
+		blk, ok = api.Chain.BlockByHeight(height)
+	default:
+		writeAPIError(w, http.StatusBadRequest, "missing_field", "height or hash is required")
 		return
 	}
-	height, err := strconv.ParseUint(fh, 10, 64)
-	if err != nil {
-		http.Error(w, "invalid height", http.StatusBadRequest)
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "block_not_found", "no such block")
 		return
 	}
-
-	blocks := api.Chain.Blocks()
-	for _, blk := range blocks {
-		if blk.Height == height {
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"block": blk,
-			})
-			return
-		}
-	}
-	http.Error(w, "not found", http.StatusNotFound)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ChainBlockResponse{Block: blk})
 }
 
 // getProfileHandler exposes the active econ profile.
 func (api *HTTPAPI) getProfileHandler(w http.ResponseWriter, r *http.Request) {
 	prof := econ.GetProfile()
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"profile": string(prof),
-	})
+	json.NewEncoder(w).Encode(ProfileResponse{Profile: string(prof)})
 }
 
 // setProfileHandler changes the active econ profile (DevNet only).
@@ -552,23 +493,14 @@ func (api *HTTPAPI) setProfileHandler(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	var body struct {
-		Profile string `json:"profile"`
-	}
+	var body ProfileSetRequest
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Profile == "" {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 	econ.SetProfile(econ.ProfileMode(body.Profile))
 
-	ev := Event{
-		ID:        "profile-change",
-		Type:      EventType("ProfileChange"),
-		Version:   "v1",
-		Payload:   map[string]string{"profile": body.Profile},
-		Timestamp: time.Now().UTC(),
-	}
-	api.Hub.Broadcast(ev)
+	api.Hub.Broadcast(NewEvent("profile-change", ProfileChangePayload{Profile: body.Profile}, time.Now().UTC()))
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -587,14 +519,37 @@ func (api *HTTPAPI) analyticsNAVHandler(w http.ResponseWriter, r *http.Request)
 // derived from in-memory account state. It reports total reserve backing
 // held on the treasury account (USDC/USDT/DAI), total GRC supply, the
 // implied NAV, and a trivial corridor classification around 1.0000 using
-// a ±10bps band.
+// a ±10bps band. With at_height or at_epoch the same figures are computed
+// from the balance history instead.
 
 func (api *HTTPAPI) valuationLatestHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	reserve, supply, nav := api.Chain.DevnetMonetarySnapshot()
+	point, ok := api.historicalPoint(w, r)
+	if !ok {
+		return
+	}
+	var reserve, supply, nav float64
+	var reserves core.Balances
+	if point != nil {
+		accounts, err := api.accountsAt(r.Context(), "", point.Height)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		reserve, supply, nav = core.MonetarySnapshotOf(accounts)
+		reserves = core.Balances{}
+		for _, acc := range accounts {
+			if acc.Address == "treasury" {
+				reserves = acc.Balances
+			}
+		}
+	} else {
+		reserve, supply, nav = api.Chain.DevnetMonetarySnapshot()
+		reserves = api.Store.Snapshot("treasury").Balances
+	}
 	target := 1.0
 	lower, upper := econ.ComputeCorridorBounds(target, 10)
 
@@ -609,8 +564,9 @@ func (api *HTTPAPI) valuationLatestHandler(w http.ResponseWriter, r *http.Reques
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	resp := map[string]interface{}{
 		"reserve_usd":    reserve,
+		"reserves":       reserves,
 		"supply_grc":     supply,
 		"nav":            nav,
 		"target":         target,
@@ -621,7 +577,14 @@ func (api *HTTPAPI) valuationLatestHandler(w http.ResponseWriter, r *http.Reques
 		"decimals":       4,
 		"band_bps":       10,
 		"timestamp_unix": time.Now().Unix(),
-	})
+	}
+	if point != nil {
+		resp["at_height"] = point.Height
+		if point.Epoch != nil {
+			resp["at_epoch"] = *point.Epoch
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
 }
 
 // analyticsWindowsHandler exposes the current settlement window snapshot.
@@ -655,7 +618,7 @@ func (api *HTTPAPI) analyticsTreasuryHandler(w http.ResponseWriter, r *http.Requ
 				return out
 			}(),
 		}
-		api.DB.InsertTreasurySnapshot(ss, time.Now().UTC())
+		api.DB.InsertTreasurySnapshot(r.Context(), ss, time.Now().UTC())
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -665,72 +628,56 @@ func (api *HTTPAPI) analyticsTreasuryHandler(w http.ResponseWriter, r *http.Requ
 // NewHTTPServer wires all HTTP routes for DevNet.
 func NewHTTPServer(listenAddr string, hub *WSHub, store *core.AccountStore, wm *econ.WindowManager, db *store.DB, chain *core.Chain, miner *core.Miner) *http.Server {
 	api := NewHTTPAPI(hub, store, wm, db, chain, miner)
+	seedRegistry.AttachDB(db)
+	// Housekeeping loops live as long as the server; Shutdown stops them.
+	bg, stop := context.WithCancel(context.Background())
+	go api.runAuthPruner(bg, 5*time.Minute)
+	go runRateLimitSweeper(bg, time.Minute)
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/ws", hub.HandleWS)
-	mux.HandleFunc("/api/balances", api.balancesHandler)
-	mux.HandleFunc("/api/mint", api.mintHandler)
-	mux.HandleFunc("/api/redeem", api.redeemHandler)
-	// Auth (client wallet) + session
-	mux.HandleFunc("/api/auth/nonce", api.authNonceHandler)
-	mux.HandleFunc("/api/auth/wallet-login", api.authWalletLoginHandler)
-	mux.HandleFunc("/api/session", api.sessionGetHandler)
-	mux.HandleFunc("/api/auth/logout", api.authLogoutHandler)
-
-	mux.HandleFunc("/api/profile/get", api.getProfileHandler)
-	mux.HandleFunc("/api/profile/set", api.setProfileHandler)
-	mux.HandleFunc("/api/analytics/nav", api.analyticsNAVHandler)
-	mux.HandleFunc("/api/valuation/latest", api.valuationLatestHandler)
-	mux.HandleFunc("/api/analytics/windows", api.analyticsWindowsHandler)
-	mux.HandleFunc("/api/account/nonce", api.accountNonceHandler)
-	mux.HandleFunc("/api/analytics/treasury", api.analyticsTreasuryHandler)
-	mux.HandleFunc("/api/chain/head", api.chainHeadHandler)
-	mux.HandleFunc("/api/chain/mining/status", api.miningStatusHandler)
-	mux.HandleFunc("/api/chain/mining/start", api.miningStartHandler)
-	mux.HandleFunc("/api/chain/mining/stop", api.miningStopHandler)
-	mux.HandleFunc("/api/chain/blocks", api.chainBlocksHandler)
-	mux.HandleFunc("/api/chain/block", api.chainBlockByHeightHandler)
-	mux.HandleFunc("/api/chain/mempool", api.mempoolHandler)
-	mux.HandleFunc("/api/tx/transfer", api.transferHandler)
-	mux.HandleFunc("/api/tx/vault_create", api.vaultCreateHandler)
-	mux.HandleFunc("/api/tier/renew", api.tierRenewHandler)
-	mux.HandleFunc("/api/p2p/register", api.p2pRegisterHandler)
-	mux.HandleFunc("/api/p2p/peers", api.p2pPeersHandler)
-
+	mux.HandleFunc("/ws", api.wsHandler)
+	mux.HandleFunc("/ws/terminal", api.terminalWSHandler)
+	mux.HandleFunc("/rpc", api.rpcHandler)
 	mux.HandleFunc("/workstation/", api.workstationHandler)
 	mux.HandleFunc("/workstation", api.workstationHandler)
+	mux.HandleFunc("/metrics", api.metricsHandler)
+	mux.HandleFunc("/healthz", api.healthzHandler)
+	mux.HandleFunc("/readyz", api.readyzHandler)
 
-	mux.HandleFunc("/econ/live", api.econLiveHandler)
-	mux.HandleFunc("/api/sim", api.econSimHandler)
-
-	mux.HandleFunc("/api/econ/epoch-commit", api.econEpochCommitHandler)
-	mux.HandleFunc("/api/slashing/events", api.slashingEventsHandler)
-	// RSX staking + PoP wiring (state + payouts)
-	mux.HandleFunc("/api/staking/validators", api.stakingValidatorsHandler)
-	mux.HandleFunc("/api/staking/lock", api.stakingLockHandler)
-	mux.HandleFunc("/api/staking/unlock", api.stakingUnlockHandler)
-	// backwards-compatible alias
-	mux.HandleFunc("/api/staking/stake", api.stakingLockHandler)
-	mux.HandleFunc("/api/staking/state", api.stakingStateHandler)
-	mux.HandleFunc("/api/pop/register-node", api.popRegisterNodeHandler)
-	mux.HandleFunc("/api/pop/submit-caps", api.popSubmitCapsHandler)
-	mux.HandleFunc("/api/pop/claim-work", api.popClaimWorkHandler)
-	mux.HandleFunc("/api/pop/submit-metrics", api.popSubmitMetricsHandler)
-	mux.HandleFunc("/api/pop/payouts", api.popPayoutsHandler)
+	// REST API: /api/v1 plus the unversioned paths as deprecated aliases.
+	api.mountV1(mux)
 
 	if listenAddr == "" {
 		listenAddr = ":8080"
 	}
-	return &http.Server{
+	srv := &http.Server{
 		Addr:    listenAddr,
-		Handler: mux,
+		Handler: api.requestLog(api.rateLimit(mux)),
 	}
+	srv.RegisterOnShutdown(stop)
+	return srv
+}
+
+// p2pHandshakeHandler returns this node's identity, software version, chain
+// ID and head. Seeds and peers probe it before trusting an address.
+func (api *HTTPAPI) p2pHandshakeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(localHandshake(api.Chain))
 }
 
 // p2pRegisterHandler allows a peer to register its address with this node
-// when running in seed mode. It expects a JSON body like:
+// when running in seed mode. It expects a signed RegisterRequest:
+//
+//	{ "addr": "http://127.0.0.1:8080", "node_id": "rcn1...", "pubkey": "...",
+//	  "chain_id": "...", "time": "...", "signature": "..." }
 //
-//	{ "addr": "127.0.0.1:8080" }
+// The signature is verified, then the address is persisted and probed via
+// /api/p2p/handshake; it is only advertised once the node there presents the
+// same node ID and a matching chain ID.
 func (api *HTTPAPI) p2pRegisterHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -743,29 +690,61 @@ func (api *HTTPAPI) p2pRegisterHandler(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	type payload struct {
-		Addr string `json:"addr"`
-	}
-	var p payload
-	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+	var rr RegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&rr); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(map[string]string{
 			"error": "invalid_payload",
 		})
 		return
 	}
-	if p.Addr == "" {
-		// Fallback to remote address if caller did not send one.
-		p.Addr = r.RemoteAddr
+	if _, err := url.ParseRequestURI(normaliseSeedBase(rr.Addr)); rr.Addr == "" || err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"error": "invalid_addr",
+		})
+		return
+	}
+	if err := rr.Verify(); err != nil {
+		code := "invalid_signature"
+		if errors.Is(err, errChainIDMismatch) {
+			code = "chain_id_mismatch"
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"error":  code,
+			"detail": err.Error(),
+		})
+		return
+	}
+	// The signature covers the address as sent; normalise only afterwards.
+	rr.Addr = normaliseSeedBase(rr.Addr)
+	hs, err := seedRegistry.Register(r.Context(), rr)
+	if err != nil {
+		code, status := "handshake_failed", http.StatusUnprocessableEntity
+		switch {
+		case errors.Is(err, errChainIDMismatch):
+			code = "chain_id_mismatch"
+		case errors.Is(err, errTooManyAddrs):
+			code, status = "too_many_addresses", http.StatusTooManyRequests
+		}
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"error":  code,
+			"detail": err.Error(),
+		})
+		return
 	}
-	seedRegistry.Add(p.Addr)
 	_ = json.NewEncoder(w).Encode(map[string]string{
-		"status": "ok",
+		"status":   "ok",
+		"node_id":  hs.NodeID,
+		"chain_id": hs.ChainID,
 	})
 }
 
-// p2pPeersHandler returns the current set of known peers from the in-memory
-// seed registry. Entries are TTL-aged each time this handler is invoked.
+// p2pPeersHandler returns the peers that passed a handshake within the
+// registry TTL. With ?detail=1 the persisted records (last seen, version,
+// chain ID, failures) are included as well.
 func (api *HTTPAPI) p2pPeersHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -779,7 +758,19 @@ func (api *HTTPAPI) p2pPeersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	peers := seedRegistry.List()
-	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+	resp := map[string]interface{}{
 		"peers": peers,
-	})
+	}
+	if r.URL.Query().Get("detail") == "1" {
+		recs, err := api.DB.ListPeers(r.Context(), "", "", 0)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		resp["records"] = recs
+	}
+	_ = json.NewEncoder(w).Encode(resp)
 }
@@ -0,0 +1,298 @@
+package net
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	stdnet "net"
+	"net/http"
+	"strings"
+	"time"
+
+	"reservechain/internal/logging"
+	"reservechain/internal/store"
+)
+
+// API key scopes. A key may only reach endpoints covered by its scopes,
+// on top of the ownership and role checks applied to wallet sessions.
+const (
+	ScopeRead     = "read"     // identity-gated streams and subscriptions
+	ScopeTrade    = "trade"    // mint, redeem, vault and tier transactions
+	ScopeTransfer = "transfer" // transfers
+	ScopeStaking  = "staking"  // stake lock/unlock
+	ScopeOperator = "operator" // PoP node operations; operator-role routes if the owner holds the role
+)
+
+func validScope(s string) bool {
+	switch s {
+	case ScopeRead, ScopeTrade, ScopeTransfer, ScopeStaking, ScopeOperator:
+		return true
+	}
+	return false
+}
+
+// apiKeyPrefix marks bearer tokens that are API keys: "rck_<id>_<secret>".
+const apiKeyPrefix = "rck_"
+
+// allows reports whether the session may use scope. Wallet sessions are
+// not scoped.
+func (s sessionEntry) allows(scope string) bool {
+	if s.APIKeyID == "" {
+		return true
+	}
+	for _, sc := range s.Scopes {
+		if sc == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ipAllowed checks host against an allow-list of IPs and CIDRs. An empty
+// list allows every address.
+func ipAllowed(host string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	ip := stdnet.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, a := range allowed {
+		if _, cidr, err := stdnet.ParseCIDR(a); err == nil {
+			if cidr.Contains(ip) {
+				return true
+			}
+		} else if allowedIP := stdnet.ParseIP(a); allowedIP != nil && allowedIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionFromAPIKey resolves an API key bearer token. Revoked, expired and
+// malformed keys, and calls from outside the key's IP allow-list, are
+// treated as no credentials at all.
+func (api *HTTPAPI) sessionFromAPIKey(r *http.Request, key string) (sessionEntry, bool) {
+	id, _, ok := strings.Cut(strings.TrimPrefix(key, apiKeyPrefix), "_")
+	if !ok || id == "" {
+		return sessionEntry{}, false
+	}
+	k, err := api.DB.GetAPIKey(r.Context(), id)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			authLog.ErrorContext(r.Context(), "load API key failed", logging.Err(err))
+		}
+		return sessionEntry{}, false
+	}
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(key)), []byte(k.KeyHash)) != 1 {
+		return sessionEntry{}, false
+	}
+	now := time.Now().UTC()
+	if k.RevokedAt != nil || (k.ExpiresAt != nil && now.After(*k.ExpiresAt)) {
+		return sessionEntry{}, false
+	}
+	if !ipAllowed(remoteHost(r), k.AllowedIPs) {
+		return sessionEntry{}, false
+	}
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= sessionTouchInterval {
+		if err := api.DB.TouchAPIKey(r.Context(), k.ID, now); err != nil {
+			authLog.ErrorContext(r.Context(), "touch API key failed", logging.Err(err))
+		}
+	}
+	s := sessionEntry{
+		Address:   k.Identity,
+		Role:      RoleClient,
+		CreatedAt: k.CreatedAt,
+		APIKeyID:  k.ID,
+		Scopes:    k.Scopes,
+	}
+	if k.ExpiresAt != nil {
+		s.ExpiresAt = *k.ExpiresAt
+	}
+	return s, true
+}
+
+// walletSession returns the caller's wallet session, rejecting API keys:
+// keys cannot manage sessions or other keys.
+func (api *HTTPAPI) walletSession(w http.ResponseWriter, r *http.Request) (sessionEntry, bool) {
+	s, ok := api.sessionFromRequest(r)
+	if !ok {
+		writeAPIError(w, http.StatusUnauthorized, "unauthenticated", "a wallet session is required")
+		return sessionEntry{}, false
+	}
+	if s.APIKeyID != "" {
+		writeAPIError(w, http.StatusForbidden, "wallet_session_required", "API keys cannot manage sessions or keys")
+		return sessionEntry{}, false
+	}
+	return s, true
+}
+
+// readIdentity returns the session's identity if it may read
+// identity-gated data (streams, subscriptions), or "".
+func (s sessionEntry) readIdentity() string {
+	if s.allows(ScopeRead) {
+		return s.Address
+	}
+	return ""
+}
+
+// readIdentity is sessionEntry.readIdentity for the request's credentials.
+func (api *HTTPAPI) readIdentity(r *http.Request) string {
+	s, _ := api.sessionFromRequest(r)
+	return s.readIdentity()
+}
+
+// GET  /api/auth/api-keys  lists the caller's keys (never the secrets).
+// POST /api/auth/api-keys  creates one:
+//
+//	{ "name": "rebalancer", "scopes": ["read", "transfer"],
+//	  "allowed_ips": ["10.0.0.0/8"], "expires_at": "2027-01-01T00:00:00Z" }
+//
+// The key is only ever returned in the create response.
+func (api *HTTPAPI) apiKeysHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := api.walletSession(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		keys, err := api.DB.ListAPIKeys(r.Context(), s.Address)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if keys == nil {
+			keys = []store.APIKey{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "keys": keys})
+	case http.MethodPost:
+		api.createAPIKey(w, r, s)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (api *HTTPAPI) createAPIKey(w http.ResponseWriter, r *http.Request, s sessionEntry) {
+	var req APIKeyCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "bad_request", "invalid JSON body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 64 {
+		writeAPIError(w, http.StatusBadRequest, "bad_name", "name must be 1-64 characters")
+		return
+	}
+	seen := map[string]bool{}
+	scopes := []string{}
+	for _, sc := range req.Scopes {
+		if !validScope(sc) {
+			writeAPIError(w, http.StatusBadRequest, "unknown_scope", "unknown scope "+sc)
+			return
+		}
+		if !seen[sc] {
+			seen[sc] = true
+			scopes = append(scopes, sc)
+		}
+	}
+	if len(scopes) == 0 {
+		writeAPIError(w, http.StatusBadRequest, "unknown_scope", "at least one scope is required")
+		return
+	}
+	if seen[ScopeOperator] && !holdsRole(api.rolesFor(r.Context(), s.Address), RoleOperator) {
+		writeAPIError(w, http.StatusForbidden, "role_required", "the operator scope requires the operator role")
+		return
+	}
+	ips := make([]string, 0, len(req.AllowedIPs))
+	for _, a := range req.AllowedIPs {
+		a = strings.TrimSpace(a)
+		if _, cidr, err := stdnet.ParseCIDR(a); err == nil {
+			ips = append(ips, cidr.String())
+		} else if ip := stdnet.ParseIP(a); ip != nil {
+			ips = append(ips, ip.String())
+		} else {
+			writeAPIError(w, http.StatusBadRequest, "bad_ip", "not an IP or CIDR: "+a)
+			return
+		}
+	}
+	now := time.Now().UTC()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		writeAPIError(w, http.StatusBadRequest, "bad_expiry", "expires_at must be in the future")
+		return
+	}
+
+	idb := make([]byte, 8)
+	if _, err := rand.Read(idb); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	secret, err := randTokenB64URL(32)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	k := store.APIKey{
+		ID:         hex.EncodeToString(idb),
+		Identity:   s.Address,
+		Name:       req.Name,
+		Scopes:     scopes,
+		AllowedIPs: ips,
+		CreatedAt:  now,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	key := apiKeyPrefix + k.ID + "_" + secret
+	k.KeyHash = hashAPIKey(key)
+	if err := api.DB.InsertAPIKey(r.Context(), k); err != nil {
+		authLog.ErrorContext(r.Context(), "store API key failed", logging.Err(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	api.audit(r.Context(), "api_key", k.ID, "api_key_created", s.Address, map[string]any{
+		"name":   k.Name,
+		"scopes": k.Scopes,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "key": key, "api_key": k})
+}
+
+// POST /api/auth/api-keys/revoke
+// Body: { "id": "..." } — one of the caller's keys.
+func (api *HTTPAPI) apiKeyRevokeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s, ok := api.walletSession(w, r)
+	if !ok {
+		return
+	}
+	var req APIKeyRevokeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		writeAPIError(w, http.StatusBadRequest, "bad_request", "id required")
+		return
+	}
+	revoked, err := api.DB.RevokeAPIKey(r.Context(), s.Address, req.ID, time.Now().UTC())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !revoked {
+		writeAPIError(w, http.StatusNotFound, "key_not_found", "no active key with that id")
+		return
+	}
+	api.audit(r.Context(), "api_key", req.ID, "api_key_revoked", s.Address, nil)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "id": req.ID})
+}
@@ -0,0 +1,126 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"reservechain/internal/store"
+)
+
+func TestIPAllowed(t *testing.T) {
+	tests := []struct {
+		host    string
+		allowed []string
+		want    bool
+	}{
+		{"203.0.113.7", nil, true},
+		{"203.0.113.7", []string{"203.0.113.7"}, true},
+		{"203.0.113.8", []string{"203.0.113.7"}, false},
+		{"10.1.2.3", []string{"10.0.0.0/8"}, true},
+		{"11.1.2.3", []string{"10.0.0.0/8"}, false},
+		{"10.1.2.3", []string{"192.0.2.1", "10.0.0.0/8"}, true},
+		{"2001:db8::1", []string{"2001:db8::/32"}, true},
+		{"2001:db9::1", []string{"2001:db8::/32"}, false},
+		{"not-an-ip", []string{"10.0.0.0/8"}, false},
+		{"", []string{"10.0.0.0/8"}, false},
+	}
+	for _, tt := range tests {
+		if got := ipAllowed(tt.host, tt.allowed); got != tt.want {
+			t.Errorf("ipAllowed(%q, %v) = %v, want %v", tt.host, tt.allowed, got, tt.want)
+		}
+	}
+}
+
+func TestSessionAllowsScope(t *testing.T) {
+	key := sessionEntry{Address: "rc:abc", APIKeyID: "k1", Scopes: []string{ScopeRead, ScopeTransfer}}
+	wallet := sessionEntry{Address: "rc:abc"}
+	tests := []struct {
+		name  string
+		s     sessionEntry
+		scope string
+		want  bool
+	}{
+		{"key with scope", key, ScopeTransfer, true},
+		{"key without scope", key, ScopeTrade, false},
+		{"key without operator", key, ScopeOperator, false},
+		{"key with no scopes", sessionEntry{Address: "rc:abc", APIKeyID: "k2"}, ScopeRead, false},
+		{"wallet session", wallet, ScopeOperator, true},
+	}
+	for _, tt := range tests {
+		if got := tt.s.allows(tt.scope); got != tt.want {
+			t.Errorf("%s: allows(%q) = %v, want %v", tt.name, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestAPIKeyScopeAndAllowList(t *testing.T) {
+	db, err := store.OpenSQLite(filepath.Join(t.TempDir(), "keys.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := store.EnsureSchemaFromFile(db, "../../database/schema.sql"); err != nil {
+		t.Fatal(err)
+	}
+	api := NewHTTPAPI(NewWSHub(), nil, nil, db, nil, nil)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	past := now.Add(-time.Hour)
+	newKey := func(id string, scopes, ips []string, expires *time.Time) string {
+		secret := "secret-" + id
+		k := store.APIKey{
+			ID:         id,
+			Identity:   "rc:owner",
+			Name:       id,
+			Scopes:     scopes,
+			AllowedIPs: ips,
+			CreatedAt:  now,
+			ExpiresAt:  expires,
+		}
+		token := apiKeyPrefix + id + "_" + secret
+		k.KeyHash = hashAPIKey(token)
+		if err := db.InsertAPIKey(ctx, k); err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	transfer := newKey("transfer", []string{ScopeTransfer}, nil, nil)
+	readOnly := newKey("readonly", []string{ScopeRead}, nil, nil)
+	office := newKey("office", []string{ScopeTransfer}, []string{"10.0.0.0/8", "192.0.2.1"}, nil)
+	expired := newKey("expired", []string{ScopeTransfer}, nil, &past)
+	revoked := newKey("revoked", []string{ScopeTransfer}, nil, nil)
+	if ok, err := db.RevokeAPIKey(ctx, "rc:owner", "revoked", now); err != nil || !ok {
+		t.Fatalf("revoke: %v, %v", ok, err)
+	}
+
+	h := api.requireSession(ScopeTransfer, func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		name, token, remote string
+		want                int
+	}{
+		{"scoped key", transfer, "198.51.100.1:5000", http.StatusOK},
+		{"missing scope", readOnly, "198.51.100.1:5000", http.StatusForbidden},
+		{"inside CIDR", office, "10.9.8.7:5000", http.StatusOK},
+		{"listed IP", office, "192.0.2.1:5000", http.StatusOK},
+		{"outside allow-list", office, "198.51.100.1:5000", http.StatusUnauthorized},
+		{"expired key", expired, "198.51.100.1:5000", http.StatusUnauthorized},
+		{"revoked key", revoked, "198.51.100.1:5000", http.StatusUnauthorized},
+		{"wrong secret", apiKeyPrefix + "transfer_guess", "198.51.100.1:5000", http.StatusUnauthorized},
+		{"unknown key", apiKeyPrefix + "nope_secret", "198.51.100.1:5000", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/transfer", nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		req.RemoteAddr = tt.remote
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
@@ -8,29 +8,44 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	stdnet "net"
 	"net/http"
 	"strings"
 	"time"
 
 	ethcrypto "github.com/ethereum/go-ethereum/crypto"
-)
 
-// nonceEntry tracks a login nonce issued for a given address.
-type nonceEntry struct {
-	Address   string
-	Challenge string
-	ExpiresAt time.Time
-}
+	"reservechain/internal/logging"
+	"reservechain/internal/store"
+)
 
-// sessionEntry tracks an authenticated session.
+// sessionEntry is the live session behind a request.
 type sessionEntry struct {
-	SessionID string
-	Address   string
+	SessionID string // hex sha256 of the token; safe to show to the owner
+	Address   string // canonical identity ("rc:..." / "evm:0x...")
 	Role      string
 	CreatedAt time.Time
 	ExpiresAt time.Time
+	// MaxExpiresAt caps the sliding ExpiresAt.
+	MaxExpiresAt time.Time
+	// APIKeyID and Scopes are set when the caller used an API key
+	// instead of a wallet session.
+	APIKeyID string
+	Scopes   []string
+}
+
+func sessionFromRow(s store.AuthSession) sessionEntry {
+	return sessionEntry{
+		SessionID:    s.ID,
+		Address:      s.Identity,
+		Role:         s.Role,
+		CreatedAt:    s.CreatedAt,
+		ExpiresAt:    s.ExpiresAt,
+		MaxExpiresAt: s.MaxExpiresAt,
+	}
 }
 
 // -------- helpers --------
@@ -70,17 +85,44 @@ func verifyEvmPersonalSign(address string, challenge string, sigHex string) bool
 	return strings.EqualFold(recAddr, address)
 }
 
-func (api *HTTPAPI) pruneAuthLocked(now time.Time) {
-	for addr, n := range api.nonces {
-		if now.After(n.ExpiresAt) {
-			delete(api.nonces, addr)
-		}
+// remoteHost returns the peer IP of r without its port.
+func remoteHost(r *http.Request) string {
+	host, _, err := stdnet.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
 	}
-	for sid, s := range api.sessions {
-		if now.After(s.ExpiresAt) {
-			delete(api.sessions, sid)
-		}
+	return host
+}
+
+// setSessionCookie sets the rc_session cookie; an empty token clears it.
+func setSessionCookie(w http.ResponseWriter, r *http.Request, token string, exp time.Time) {
+	c := &http.Cookie{
+		Name:     "rc_session",
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   r.TLS != nil,
+		Expires:  exp,
 	}
+	if token == "" {
+		c.Expires = time.Unix(0, 0)
+		c.MaxAge = -1
+	}
+	http.SetCookie(w, c)
+}
+
+// issueSession stores a new session for identity and returns its token.
+func (api *HTTPAPI) issueSession(r *http.Request, s store.AuthSession) (string, store.AuthSession, error) {
+	token, err := randTokenB64URL(32)
+	if err != nil {
+		return "", s, err
+	}
+	s.ID = sessionIDFor(token)
+	if err := api.auth.InsertAuthSession(r.Context(), s); err != nil {
+		return "", s, err
+	}
+	return token, s, nil
 }
 
 func randTokenB64URL(n int) (string, error) {
@@ -146,10 +188,7 @@ func (api *HTTPAPI) authNonceHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct {
-		WalletType string `json:"wallet_type"`
-		Address    string `json:"address"`
-	}
+	var req AuthNonceRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "bad_request"})
@@ -173,14 +212,15 @@ func (api *HTTPAPI) authNonceHandler(w http.ResponseWriter, r *http.Request) {
 
 	id := wt + ":" + addr
 
-	api.authMu.Lock()
-	api.pruneAuthLocked(time.Now().UTC())
-	api.nonces[id] = nonceEntry{
-		Address:   id,
+	if err := api.auth.PutAuthChallenge(r.Context(), store.AuthChallenge{
+		Identity:  id,
 		Challenge: challenge,
 		ExpiresAt: exp,
+	}); err != nil {
+		authLog.ErrorContext(r.Context(), "store challenge failed", logging.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
-	api.authMu.Unlock()
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{
@@ -210,15 +250,7 @@ func (api *HTTPAPI) authWalletLoginHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	var req struct {
-		WalletType   string         `json:"wallet_type"`
-		Address      string         `json:"address"`
-		Pub          map[string]any `json:"pub,omitempty"`
-		Challenge    string         `json:"challenge"`
-		SignatureB64 string         `json:"signature_b64,omitempty"`
-		SignatureHex string         `json:"signature_hex,omitempty"`
-		Role         string         `json:"role"`
-	}
+	var req WalletLoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "bad_request"})
@@ -227,9 +259,9 @@ func (api *HTTPAPI) authWalletLoginHandler(w http.ResponseWriter, r *http.Reques
 	if req.Role == "" {
 		req.Role = "client"
 	}
-	if req.Role != "client" {
-		w.WriteHeader(http.StatusForbidden)
-		_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "role_not_allowed"})
+	if !validRole(req.Role) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "unknown_role"})
 		return
 	}
 
@@ -242,11 +274,8 @@ func (api *HTTPAPI) authWalletLoginHandler(w http.ResponseWriter, r *http.Reques
 	id := wt + ":" + addr
 
 	// Validate nonce/challenge
-	api.authMu.Lock()
-	api.pruneAuthLocked(time.Now().UTC())
-	ne, exists := api.nonces[id]
-	api.authMu.Unlock()
-	if !exists || time.Now().UTC().After(ne.ExpiresAt) {
+	ne, err := api.auth.GetAuthChallenge(r.Context(), id)
+	if err != nil || time.Now().UTC().After(ne.ExpiresAt) {
 		w.WriteHeader(http.StatusUnauthorized)
 		_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "nonce_expired"})
 		return
@@ -304,36 +333,45 @@ func (api *HTTPAPI) authWalletLoginHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Roles other than client must be granted to the identity.
+	if !holdsRole(api.rolesFor(r.Context(), id), req.Role) {
+		api.audit(r.Context(), "session", id, "login_denied", id, map[string]any{"role": req.Role})
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "role_not_allowed"})
+		return
+	}
+
+	// One-time challenge use: only one login can consume it.
+	if ok, err := api.auth.ConsumeAuthChallenge(r.Context(), id, ne.Challenge); err != nil || !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "nonce_expired"})
+		return
+	}
+
 	// Issue session
-	sid, err := randTokenB64URL(32)
+	now := time.Now().UTC()
+	maxExp := now.Add(time.Duration(sessionMaxTTL.Load()))
+	token, sess, err := api.issueSession(r, store.AuthSession{
+		Identity:     id, // store canonical identity
+		Role:         req.Role,
+		DeviceName:   truncate(req.DeviceName, 128),
+		UserAgent:    truncate(r.UserAgent(), 256),
+		IP:           remoteHost(r),
+		CreatedAt:    now,
+		LastSeenAt:   now,
+		ExpiresAt:    slidingExpiry(now, maxExp),
+		MaxExpiresAt: maxExp,
+	})
 	if err != nil {
+		authLog.ErrorContext(r.Context(), "store session failed", logging.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	now := time.Now().UTC()
-	exp := now.Add(24 * time.Hour)
-
-	api.authMu.Lock()
-	api.sessions[sid] = sessionEntry{
-		SessionID: sid,
-		Address:   id, // store canonical identity
-		Role:      req.Role,
-		CreatedAt: now,
-		ExpiresAt: exp,
+	if req.Role != RoleClient {
+		api.audit(r.Context(), "session", id, "login", id, map[string]any{"role": req.Role})
 	}
-	// One-time nonce use
-	delete(api.nonces, id)
-	api.authMu.Unlock()
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "rc_session",
-		Value:    sid,
-		Path:     "/",
-		HttpOnly: true,
-		SameSite: http.SameSiteLaxMode,
-		Secure:   r.TLS != nil,
-		Expires:  exp,
-	})
+	setSessionCookie(w, r, token, maxExp)
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{
@@ -342,37 +380,82 @@ func (api *HTTPAPI) authWalletLoginHandler(w http.ResponseWriter, r *http.Reques
 		"address":     addr,
 		"identity":    id,
 		"role":        req.Role,
-		"expires_at":  exp.Format(time.RFC3339),
+		"session_id":  sess.ID,
+		"expires_at":  sess.ExpiresAt.Format(time.RFC3339),
 	})
 }
 
-// GET /api/session
-
-func (api *HTTPAPI) sessionGetHandler(w http.ResponseWriter, r *http.Request) {
-	c, err := r.Cookie("rc_session")
-	if err != nil || c.Value == "" {
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(map[string]any{"ok": false})
-		return
+// sessionToken returns the token of an "Authorization: Bearer" header or,
+// failing that, of the rc_session cookie.
+func sessionToken(r *http.Request) (token string, bearer bool) {
+	if t := bearerToken(r); t != "" {
+		return t, true
 	}
+	if c, err := r.Cookie("rc_session"); err == nil {
+		return c.Value, false
+	}
+	return "", false
+}
 
-	api.authMu.Lock()
-	api.pruneAuthLocked(time.Now().UTC())
-	s, ok := api.sessions[c.Value]
-	api.authMu.Unlock()
+// sessionFromRequest returns the live session named by an
+// "Authorization: Bearer <token>" header or the rc_session cookie. Each
+// use pushes the sliding expiry out, up to the session's absolute cap.
+func (api *HTTPAPI) sessionFromRequest(r *http.Request) (sessionEntry, bool) {
+	token, bearer := sessionToken(r)
+	if token == "" {
+		return sessionEntry{}, false
+	}
+	if bearer && strings.HasPrefix(token, apiKeyPrefix) {
+		return api.sessionFromAPIKey(r, token)
+	}
+	row, err := api.auth.GetAuthSession(r.Context(), sessionIDFor(token))
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			authLog.ErrorContext(r.Context(), "load session failed", logging.Err(err))
+		}
+		return sessionEntry{}, false
+	}
+	now := time.Now().UTC()
+	if now.After(row.ExpiresAt) {
+		return sessionEntry{}, false
+	}
+	if now.Sub(row.LastSeenAt) >= sessionTouchInterval {
+		row.LastSeenAt = now
+		row.ExpiresAt = slidingExpiry(now, row.MaxExpiresAt)
+		if err := api.auth.TouchAuthSession(r.Context(), row.ID, row.LastSeenAt, row.ExpiresAt); err != nil {
+			authLog.ErrorContext(r.Context(), "touch session failed", logging.Err(err))
+		}
+	}
+	return sessionFromRow(row), true
+}
 
-	if !ok || time.Now().UTC().After(s.ExpiresAt) {
+// GET /api/session
+func (api *HTTPAPI) sessionGetHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := api.sessionFromRequest(r)
+	if !ok {
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(map[string]any{"ok": false})
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	if s.APIKeyID != "" {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"ok":         true,
+			"api_key_id": s.APIKeyID,
+			"scopes":     s.Scopes,
+			"role":       s.Role,
+			"address":    s.Address,
+		})
+		return
+	}
 	_ = json.NewEncoder(w).Encode(map[string]any{
-		"ok":      true,
-		"role":    s.Role,
-		"address": s.Address,
-		"expires": s.ExpiresAt.Format(time.RFC3339),
+		"ok":          true,
+		"session_id":  s.SessionID,
+		"role":        s.Role,
+		"address":     s.Address,
+		"expires":     s.ExpiresAt.Format(time.RFC3339),
+		"max_expires": s.MaxExpiresAt.Format(time.RFC3339),
 	})
 }
 
@@ -382,24 +465,163 @@ func (api *HTTPAPI) authLogoutHandler(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	c, err := r.Cookie("rc_session")
-	if err == nil && c.Value != "" {
-		api.authMu.Lock()
-		delete(api.sessions, c.Value)
-		api.authMu.Unlock()
+	if token, _ := sessionToken(r); token != "" {
+		if _, err := api.auth.DeleteAuthSession(r.Context(), sessionIDFor(token)); err != nil {
+			authLog.ErrorContext(r.Context(), "delete session failed", logging.Err(err))
+		}
 	}
-
-	http.SetCookie(w, &http.Cookie{
-		Name:     "rc_session",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		SameSite: http.SameSiteLaxMode,
-		Secure:   r.TLS != nil,
-		Expires:  time.Unix(0, 0),
-		MaxAge:   -1,
-	})
+	setSessionCookie(w, r, "", time.Time{})
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
 }
+
+// POST /api/auth/refresh
+// Rotates the session token and pushes the sliding expiry out. The
+// absolute lifetime from login is kept. Bearer callers get the new token
+// in the response; cookie callers get a new cookie.
+func (api *HTTPAPI) authRefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	token, bearer := sessionToken(r)
+	cur, err := api.auth.GetAuthSession(r.Context(), sessionIDFor(token))
+	now := time.Now().UTC()
+	if token == "" || err != nil || now.After(cur.ExpiresAt) {
+		writeAPIError(w, http.StatusUnauthorized, "unauthenticated", "a wallet session is required")
+		return
+	}
+
+	next := cur
+	next.LastSeenAt = now
+	next.ExpiresAt = slidingExpiry(now, cur.MaxExpiresAt)
+	newToken, next, err := api.issueSession(r, next)
+	if err != nil {
+		authLog.ErrorContext(r.Context(), "store session failed", logging.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if _, err := api.auth.DeleteAuthSession(r.Context(), cur.ID); err != nil {
+		authLog.ErrorContext(r.Context(), "delete session failed", logging.Err(err))
+	}
+
+	out := map[string]any{
+		"ok":         true,
+		"session_id": next.ID,
+		"expires_at": next.ExpiresAt.Format(time.RFC3339),
+	}
+	if bearer {
+		out["token"] = newToken
+	} else {
+		setSessionCookie(w, r, newToken, next.MaxExpiresAt)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// GET /api/auth/sessions
+// Lists the caller's sessions with their device metadata.
+func (api *HTTPAPI) authSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s, ok := api.walletSession(w, r)
+	if !ok {
+		return
+	}
+	rows, err := api.auth.ListAuthSessions(r.Context(), s.Address)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	type item struct {
+		store.AuthSession
+		Current bool `json:"current"`
+	}
+	out := make([]item, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, item{AuthSession: row, Current: row.ID == s.SessionID})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "sessions": out})
+}
+
+// POST /api/auth/sessions/revoke
+// Body: { "session_id": "..." } — one of the caller's own sessions.
+func (api *HTTPAPI) authRevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s, ok := api.walletSession(w, r)
+	if !ok {
+		return
+	}
+	var req SessionRevokeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SessionID == "" {
+		writeAPIError(w, http.StatusBadRequest, "bad_request", "session_id required")
+		return
+	}
+	target, err := api.auth.GetAuthSession(r.Context(), req.SessionID)
+	if err != nil || target.Identity != s.Address {
+		writeAPIError(w, http.StatusNotFound, "session_not_found", "no such session")
+		return
+	}
+	if _, err := api.auth.DeleteAuthSession(r.Context(), target.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	api.audit(r.Context(), "session", s.Address, "session_revoked", s.Address, map[string]any{"session_id": target.ID})
+	if target.ID == s.SessionID {
+		setSessionCookie(w, r, "", time.Time{})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "revoked": 1})
+}
+
+// POST /api/auth/sessions/revoke-all
+// Body (optional): { "keep_current": true } — revokes every other session.
+func (api *HTTPAPI) authRevokeAllSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s, ok := api.walletSession(w, r)
+	if !ok {
+		return
+	}
+	var req SessionRevokeAllRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "bad_request", "invalid JSON body")
+			return
+		}
+	}
+	except := ""
+	if req.KeepCurrent {
+		except = s.SessionID
+	}
+	n, err := api.auth.DeleteAuthSessionsFor(r.Context(), s.Address, except)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	api.audit(r.Context(), "session", s.Address, "sessions_revoked", s.Address, map[string]any{
+		"count":        n,
+		"keep_current": req.KeepCurrent,
+	})
+	if !req.KeepCurrent {
+		setSessionCookie(w, r, "", time.Time{})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "revoked": n})
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
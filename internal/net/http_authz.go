@@ -0,0 +1,103 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// devnetOpenWrites lets unauthenticated callers use write endpoints. It is
+// off unless the node config opts in, and only on devnet.
+var devnetOpenWrites atomic.Bool
+
+// SetDevnetOpenWrites enables or disables unauthenticated writes.
+func SetDevnetOpenWrites(on bool) { devnetOpenWrites.Store(on) }
+
+type sessionCtxKey struct{}
+
+// sessionFromContext returns the session requireSession attached to the
+// request, if any. It is absent only for open devnet writes.
+func sessionFromContext(ctx context.Context) (sessionEntry, bool) {
+	s, ok := ctx.Value(sessionCtxKey{}).(sessionEntry)
+	return s, ok
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return ""
+}
+
+// writeAPIError sends the {"ok":false,"error":code,"message":...} envelope
+// every /api/v1 error uses.
+func writeAPIError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"ok":      false,
+		"error":   code,
+		"message": message,
+	})
+}
+
+// requireSession rejects requests without a valid session (cookie or
+// bearer token) and hands the session to next via the request context.
+// API keys must also carry scope.
+func (api *HTTPAPI) requireSession(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s, ok := api.sessionFromRequest(r); ok {
+			if !s.allows(scope) {
+				writeAPIError(w, http.StatusForbidden, "scope_required", "this API key lacks the "+scope+" scope")
+				return
+			}
+			next(w, r.WithContext(context.WithValue(r.Context(), sessionCtxKey{}, s)))
+			return
+		}
+		if devnetOpenWrites.Load() {
+			next(w, r)
+			return
+		}
+		writeAPIError(w, http.StatusUnauthorized, "unauthenticated", "a wallet session is required")
+	}
+}
+
+// sessionAddress returns the bare address of the request's session (the
+// identity without its "rc:"/"evm:" prefix), or "" when there is none.
+func sessionAddress(r *http.Request) string {
+	s, ok := sessionFromContext(r.Context())
+	if !ok {
+		return ""
+	}
+	if i := strings.IndexByte(s.Address, ':'); i >= 0 {
+		return s.Address[i+1:]
+	}
+	return s.Address
+}
+
+// authorizeActor checks that the request's session owns actor, the
+// address a write acts for. On failure it writes the error and returns
+// false. Open devnet writes without a session are let through.
+func authorizeActor(w http.ResponseWriter, r *http.Request, actor string) bool {
+	s, ok := sessionFromContext(r.Context())
+	if !ok {
+		if devnetOpenWrites.Load() {
+			return true
+		}
+		writeAPIError(w, http.StatusUnauthorized, "unauthenticated", "a wallet session is required")
+		return false
+	}
+	if actor == "" {
+		writeAPIError(w, http.StatusBadRequest, "missing_actor", "request does not name the acting address")
+		return false
+	}
+	if !sessionOwnsAddress(s.Address, actor) {
+		writeAPIError(w, http.StatusForbidden, "identity_mismatch", "session does not own "+actor)
+		return false
+	}
+	return true
+}
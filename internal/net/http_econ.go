@@ -63,24 +63,7 @@ func (api *HTTPAPI) econSimHandler(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    type overrides struct {
-        Alpha            *float64 `json:"alpha,omitempty"`
-        CorridorFloor    *float64 `json:"corridor_floor,omitempty"`
-        CorridorTarget   *float64 `json:"corridor_target,omitempty"`
-        CorridorCeiling  *float64 `json:"corridor_ceiling,omitempty"`
-        IssuanceHalfLife *float64 `json:"issuance_half_life,omitempty"`
-        TreasurySmoothing *float64 `json:"treasury_smoothing,omitempty"`
-        PopShare         *float64 `json:"pop_share,omitempty"`
-    }
-
-    type simReq struct {
-        NumEpochs int `json:"num_epochs"`
-        Mode string `json:"mode"`
-        PreferredMerge string `json:"preferred_merge"`
-        Overrides overrides `json:"overrides"`
-    }
-
-    var req simReq
+    var req SimRequest
     _ = json.NewDecoder(r.Body).Decode(&req)
     if req.NumEpochs <= 0 {
         req.NumEpochs = 500
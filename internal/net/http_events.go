@@ -0,0 +1,128 @@
+package net
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// sseKeepAlive is how often an idle SSE stream gets a comment line.
+const sseKeepAlive = 15 * time.Second
+
+// eventsStreamHandler serves the event stream as Server-Sent Events. It
+// takes the same parameters as /ws (last_seq, types, address, vault_id,
+// validator) and also honours the Last-Event-ID header browsers send when
+// an EventSource reconnects. SSE clients cannot send subscribe messages,
+// so a stream without types or a filter is refused.
+func (api *HTTPAPI) eventsStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !checkOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	identity := api.readIdentity(r)
+	p, err := parseStreamParams(r, identity)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if p.sub == nil {
+		http.Error(w, "types or a filter required", http.StatusBadRequest)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	c := api.Hub.addClient(nil, r.RemoteAddr, identity, p)
+	defer c.close()
+
+	writeWait := api.Hub.opts.WriteWait
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-c.done:
+			return
+		case out := <-c.send:
+			_ = rc.SetWriteDeadline(time.Now().Add(writeWait))
+			if out.seq > 0 {
+				fmt.Fprintf(w, "id: %d\n", out.seq)
+			}
+			if out.typ != "" {
+				fmt.Fprintf(w, "event: %s\n", out.typ)
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", out.data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			_ = rc.SetWriteDeadline(time.Now().Add(writeWait))
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// eventsListHandler returns journalled events after ?after= (default 0),
+// oldest first, for indexers that poll instead of streaming.
+func (api *HTTPAPI) eventsListHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	j := api.Hub.journal
+	if j == nil {
+		http.Error(w, "event journal disabled", http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	var after uint64
+	if v := q.Get("after"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid after", http.StatusBadRequest)
+			return
+		}
+		after = n
+	}
+	limit := 500
+	if v := q.Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 5000 {
+			limit = n
+		}
+	}
+	events, truncated, err := j.Since(r.Context(), after, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []Event{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"events":    events,
+		"truncated": truncated,
+		"last_seq":  j.LastSeq(),
+	})
+}
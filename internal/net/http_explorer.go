@@ -0,0 +1,128 @@
+package net
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"reservechain/internal/core"
+	"reservechain/internal/store"
+)
+
+// Kinds of SearchMatch.
+const (
+	SearchKindBlock     = "block"
+	SearchKindTx        = "tx"
+	SearchKindAddress   = "address"
+	SearchKindVault     = "vault"
+	SearchKindValidator = "validator"
+	SearchKindNode      = "node"
+)
+
+// normalizeHash lower-cases a block or tx hash and drops a 0x prefix.
+func normalizeHash(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return strings.TrimPrefix(s, "0x")
+}
+
+// isHash reports whether s (normalized) looks like a 32-byte hex hash.
+func isHash(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// txByHashHandler serves GET /api/v1/tx/{hash}: the transaction with its
+// typed body and balance effects. Each block carries one tx, so the tx
+// hash is the block hash.
+func (api *HTTPAPI) txByHashHandler(w http.ResponseWriter, r *http.Request) {
+	blk, ok := api.Chain.BlockByHash(normalizeHash(pathParam(r, "hash")))
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "tx_not_found", "no such transaction")
+		return
+	}
+	tx, err := core.DecodeBlockTx(blk)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "decode_failed", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(TxResponse{Tx: tx})
+}
+
+// searchHandler serves GET /api/v1/search?q=. It classifies q and returns
+// every match that exists: a height, a block/tx hash, an rc1 or 0x
+// address, a vault ID (bare or "vault:<id>"), a validator ID or a PoP
+// node ID. An unclassifiable or unknown query yields no matches.
+func (api *HTTPAPI) searchHandler(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		writeAPIError(w, http.StatusBadRequest, "missing_field", "q is required")
+		return
+	}
+	ctx := r.Context()
+	resp := SearchResponse{Query: q, Matches: []SearchMatch{}}
+	add := func(kind, id, path string) {
+		resp.Matches = append(resp.Matches, SearchMatch{Kind: kind, ID: id, Path: path})
+	}
+
+	if h, err := strconv.ParseUint(q, 10, 64); err == nil {
+		if _, ok := api.Chain.BlockByHeight(h); ok {
+			add(SearchKindBlock, q, v1Prefix+"/chain/block?height="+q)
+		}
+	}
+	if hash := normalizeHash(q); isHash(hash) {
+		if blk, ok := api.Chain.BlockByHash(hash); ok {
+			add(SearchKindBlock, hash, v1Prefix+"/chain/block?hash="+hash)
+			if blk.TxType != "" && blk.TxType != "EMPTY" {
+				add(SearchKindTx, hash, v1Prefix+"/tx/"+hash)
+			}
+		}
+	}
+	if wt, ok := addressWalletType(q); ok {
+		_, addr, _ := canonicalIdentity(wt, q)
+		add(SearchKindAddress, addr, v1Prefix+"/address/"+url.PathEscape(addr)+"/txs")
+	}
+
+	if api.DB != nil {
+		vaultID := strings.TrimPrefix(q, "vault:")
+		if found, err := api.DB.HasAddressTxs(ctx, "vault:"+vaultID); err == nil && found {
+			add(SearchKindVault, vaultID, v1Prefix+"/address/"+url.PathEscape("vault:"+vaultID)+"/txs")
+		}
+		if v, err := api.DB.GetValidator(ctx, q); err == nil {
+			add(SearchKindValidator, v.ValidatorID, "")
+		} else if !errors.Is(err, store.ErrNotFound) {
+			writeAPIError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		if n, err := api.DB.GetPoPNode(ctx, q); err == nil {
+			add(SearchKindNode, n.NodeID, "")
+		} else if !errors.Is(err, store.ErrNotFound) {
+			writeAPIError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// addressWalletType reports whether q is shaped like a wallet address
+// (rc1... or 0x followed by 40 hex digits) and which wallet type it is.
+func addressWalletType(q string) (string, bool) {
+	if strings.HasPrefix(q, "rc1") && len(q) > 3 {
+		return "rc", true
+	}
+	if h := strings.ToLower(q); strings.HasPrefix(h, "0x") && len(h) == 42 {
+		if _, err := hex.DecodeString(h[2:]); err == nil {
+			return "evm", true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,195 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"reservechain/internal/store"
+)
+
+// Liveness (/healthz) and readiness (/readyz) probes. A live node answers
+// and can reach its database; a ready one is also caught up with the peers
+// it syncs from and is not shutting down.
+
+const (
+	// readyMaxSyncLag is how many blocks behind a sync source the node may
+	// be and still report ready.
+	readyMaxSyncLag = 3
+	// syncReportTTL bounds the age of a sync report /readyz considers;
+	// sources that have not answered recently do not block readiness.
+	syncReportTTL = time.Minute
+	// healthDBTimeout bounds the database ping of a probe.
+	healthDBTimeout = 2 * time.Second
+)
+
+var draining atomic.Bool
+
+// SetDraining marks the node as shutting down: /readyz fails from then on
+// so load balancers stop routing new traffic to it.
+func SetDraining() {
+	draining.Store(true)
+}
+
+type syncReport struct {
+	lag float64
+	at  time.Time
+}
+
+var (
+	syncMu      sync.Mutex
+	syncReports = map[string]syncReport{}
+)
+
+// recordSyncLag records how many blocks the local tip was behind source's
+// head at a sync attempt, for /readyz and the sync lag metric. A source's
+// claimed head is only reported after it has served a batch of verified
+// blocks. A source that stops serving gets no new report and its last one
+// expires after syncReportTTL, so a peer that claims a height it cannot
+// back up cannot keep the node unready.
+func recordSyncLag(source string, lag float64) {
+	syncLag.Set(lag, source)
+	syncMu.Lock()
+	syncReports[source] = syncReport{lag: lag, at: time.Now()}
+	syncMu.Unlock()
+}
+
+// HealthCheck is the outcome of one probe check. Status is a short state
+// name ("ok", "disabled", "syncing", ...); Detail explains a failure.
+type HealthCheck struct {
+	OK     bool   `json:"ok"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// SyncSourceStatus is the last sync report from one upstream or peer.
+type SyncSourceStatus struct {
+	Source    string    `json:"source"`
+	LagBlocks float64   `json:"lag_blocks"`
+	LastSync  time.Time `json:"last_sync"`
+	Stale     bool      `json:"stale"`
+}
+
+// HealthResponse is the body of /healthz and /readyz. OK is the AND of
+// every check; the HTTP status is 200 when it holds and 503 otherwise.
+type HealthResponse struct {
+	OK     bool                   `json:"ok"`
+	Height uint64                 `json:"height"`
+	Checks map[string]HealthCheck `json:"checks"`
+	Sync   []SyncSourceStatus     `json:"sync,omitempty"`
+}
+
+// healthzHandler serves GET /healthz: the process is up and, when it has
+// one, its database answers.
+func (api *HTTPAPI) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	api.writeHealth(w, r, false)
+}
+
+// readyzHandler serves GET /readyz: healthy, caught up with its sync
+// sources and not draining.
+func (api *HTTPAPI) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	api.writeHealth(w, r, true)
+}
+
+func (api *HTTPAPI) writeHealth(w http.ResponseWriter, r *http.Request, ready bool) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	resp := HealthResponse{Checks: map[string]HealthCheck{
+		"db":    api.checkDB(r.Context()),
+		"miner": api.checkMiner(),
+	}}
+	if api.Chain != nil {
+		resp.Height = api.Chain.Height()
+	}
+	if ready {
+		var sc HealthCheck
+		sc, resp.Sync = checkSync(time.Now())
+		resp.Checks["sync"] = sc
+		resp.Checks["shutdown"] = HealthCheck{OK: true, Status: "running"}
+		if draining.Load() {
+			resp.Checks["shutdown"] = HealthCheck{Status: "draining"}
+		}
+	}
+	resp.OK = true
+	for _, c := range resp.Checks {
+		resp.OK = resp.OK && c.OK
+	}
+
+	status := http.StatusOK
+	if !resp.OK {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (api *HTTPAPI) checkDB(ctx context.Context) HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthDBTimeout)
+	defer cancel()
+	err := api.DB.Ping(ctx)
+	switch {
+	case errors.Is(err, store.ErrNoDB):
+		return HealthCheck{OK: true, Status: "disabled"}
+	case err != nil:
+		return HealthCheck{Status: "unreachable", Detail: err.Error()}
+	}
+	return HealthCheck{OK: true, Status: "ok"}
+}
+
+// checkMiner reports the miner state; a stopped miner is not a failure.
+func (api *HTTPAPI) checkMiner() HealthCheck {
+	switch {
+	case api.Miner == nil:
+		return HealthCheck{OK: true, Status: "disabled"}
+	case api.Miner.IsRunning():
+		return HealthCheck{OK: true, Status: "running"}
+	}
+	return HealthCheck{OK: true, Status: "stopped"}
+}
+
+// checkSync fails when a recently reported source is more than
+// readyMaxSyncLag blocks ahead of the local tip. With no recent report
+// (no sync sources, or none reachable) the status is "unknown" and the
+// node counts as ready.
+func checkSync(now time.Time) (HealthCheck, []SyncSourceStatus) {
+	syncMu.Lock()
+	out := make([]SyncSourceStatus, 0, len(syncReports))
+	for src, rep := range syncReports {
+		out = append(out, SyncSourceStatus{
+			Source:    src,
+			LagBlocks: rep.lag,
+			LastSync:  rep.at.UTC(),
+			Stale:     now.Sub(rep.at) > syncReportTTL,
+		})
+	}
+	syncMu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Source < out[j].Source })
+
+	recent, maxLag := 0, 0.0
+	for _, s := range out {
+		if s.Stale {
+			continue
+		}
+		recent++
+		if s.LagBlocks > maxLag {
+			maxLag = s.LagBlocks
+		}
+	}
+	switch {
+	case recent == 0:
+		return HealthCheck{OK: true, Status: "unknown"}, out
+	case maxLag > readyMaxSyncLag:
+		return HealthCheck{Status: "syncing", Detail: fmt.Sprintf("%.0f block(s) behind a sync source", maxLag)}, out
+	}
+	return HealthCheck{OK: true, Status: "ok"}, out
+}
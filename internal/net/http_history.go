@@ -0,0 +1,97 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"reservechain/internal/core"
+	"reservechain/internal/econ"
+	"reservechain/internal/store"
+)
+
+// statePoint is the point in chain history a query asked for with
+// at_height or at_epoch.
+type statePoint struct {
+	Height uint64
+	Epoch  *int64
+}
+
+// stateHistoryParams documents at_height / at_epoch in the v1 route table.
+var stateHistoryParams = []v1Param{
+	{Name: "at_height", Type: "integer", Description: "State after the block at this height."},
+	{Name: "at_epoch", Type: "integer", Description: "State at the end of this (finished) econ epoch."},
+}
+
+// historicalPoint parses at_height / at_epoch. It returns nil for a query
+// about the tip, and ok == false after writing an error response.
+func (api *HTTPAPI) historicalPoint(w http.ResponseWriter, r *http.Request) (p *statePoint, ok bool) {
+	q := r.URL.Query()
+	atHeight, atEpoch := q.Get("at_height"), q.Get("at_epoch")
+	if atHeight == "" && atEpoch == "" {
+		return nil, true
+	}
+	if atHeight != "" && atEpoch != "" {
+		writeAPIError(w, http.StatusBadRequest, "conflicting_params", "give at_height or at_epoch, not both")
+		return nil, false
+	}
+	if api.DB == nil {
+		writeAPIError(w, http.StatusServiceUnavailable, "unavailable", "historical state requires a database")
+		return nil, false
+	}
+	ctx := r.Context()
+	p = &statePoint{}
+	if atHeight != "" {
+		h, err := strconv.ParseUint(atHeight, 10, 64)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "invalid_height", "at_height must be an unsigned integer")
+			return nil, false
+		}
+		if h > api.Chain.Height() {
+			writeAPIError(w, http.StatusBadRequest, "height_in_future", "at_height is above the chain tip")
+			return nil, false
+		}
+		p.Height = h
+	} else {
+		e, err := strconv.ParseInt(atEpoch, 10, 64)
+		if err != nil || e < 0 {
+			writeAPIError(w, http.StatusBadRequest, "invalid_epoch", "at_epoch must be a non-negative integer")
+			return nil, false
+		}
+		if e >= econ.CurrentDevnetEpoch() {
+			writeAPIError(w, http.StatusBadRequest, "epoch_not_ended", "at_epoch has not ended yet")
+			return nil, false
+		}
+		h, err := api.DB.EpochEndHeight(ctx, e)
+		if errors.Is(err, store.ErrNotFound) {
+			writeAPIError(w, http.StatusNotFound, "history_unavailable", "no blocks recorded for that epoch")
+			return nil, false
+		} else if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return nil, false
+		}
+		p.Height, p.Epoch = h, &e
+	}
+
+	lo, _, recorded, err := api.DB.StateHistoryRange(ctx)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return nil, false
+	}
+	if !recorded || p.Height < lo {
+		writeAPIError(w, http.StatusNotFound, "history_unavailable", "no balance history at that height")
+		return nil, false
+	}
+	return p, true
+}
+
+// accountsAt returns the accounts (or the single account addr) as of
+// height, rebuilt from the balance history.
+func (api *HTTPAPI) accountsAt(ctx context.Context, addr string, height uint64) ([]*core.Account, error) {
+	rows, err := api.DB.BalancesAt(ctx, addr, height)
+	if err != nil {
+		return nil, err
+	}
+	return core.AccountsFromBalances(rows), nil
+}
@@ -0,0 +1,65 @@
+package net
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"reservechain/internal/logging"
+)
+
+// requestIDHeader carries the request ID. A well-formed incoming value is
+// kept so a caller can correlate its own logs with the node's; otherwise
+// one is generated. Either way it is echoed in the response.
+const requestIDHeader = "X-Request-ID"
+
+// requestLog tags each request's context with its request ID, so every
+// record logged while serving it (including chain and store writes) can
+// be correlated, and writes one access log record per request.
+func (api *HTTPAPI) requestLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = logging.NewRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		ctx := logging.WithRequestID(r.Context(), id)
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		level := slog.LevelInfo
+		switch {
+		case rec.status >= 500:
+			level = slog.LevelError
+		case r.URL.Path == "/healthz" || r.URL.Path == "/readyz" || r.URL.Path == "/metrics":
+			level = slog.LevelDebug
+		}
+		httpLog.LogAttrs(ctx, level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote", remoteHost(r)),
+		)
+	})
+}
+
+// validRequestID accepts 1-64 characters of [A-Za-z0-9._-], which keeps
+// caller-supplied IDs safe to log and echo.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9',
+			c == '.', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package net
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Browser requests carry the session cookie even when another site starts
+// them. Endpoints that act on the cookie session without a CSRF-safe
+// request shape (WebSocket upgrades, the SSE stream) check the Origin
+// header against the node's own origin and the configured ones.
+
+var (
+	allowedOriginsMu sync.RWMutex
+	allowedOrigins   map[string]bool
+)
+
+// SetAllowedOrigins sets the origins ("https://app.example") besides the
+// node's own that may open /rpc, /ws, /ws/terminal and the event stream.
+func SetAllowedOrigins(origins []string) {
+	m := make(map[string]bool, len(origins))
+	for _, o := range origins {
+		m[strings.ToLower(strings.TrimSuffix(o, "/"))] = true
+	}
+	allowedOriginsMu.Lock()
+	allowedOrigins = m
+	allowedOriginsMu.Unlock()
+}
+
+// checkOrigin accepts requests without an Origin header (non-browser
+// clients), same-origin requests and the configured origins. It is the
+// CheckOrigin of every WebSocket upgrader.
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	allowedOriginsMu.RLock()
+	ok := allowedOrigins[strings.ToLower(origin)]
+	allowedOriginsMu.RUnlock()
+	if !ok {
+		httpLog.WarnContext(r.Context(), "cross-origin request rejected", "origin", origin, "path", r.URL.Path)
+	}
+	return ok
+}
@@ -0,0 +1,74 @@
+package net
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"reservechain/internal/core"
+	"reservechain/internal/proof"
+)
+
+// proofAccountHandler returns a Merkle proof of an account against the state
+// root of the block at ?height= (default: chain tip). Clients check the
+// proof against a header they verified themselves, e.g. with lightclient.
+func (api *HTTPAPI) proofAccountHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	addr := q.Get("address")
+	if addr == "" {
+		http.Error(w, "missing address", http.StatusBadRequest)
+		return
+	}
+	height := api.Chain.Height()
+	if h := q.Get("height"); h != "" {
+		v, err := strconv.ParseUint(h, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid height", http.StatusBadRequest)
+			return
+		}
+		height = v
+	}
+
+	p, err := api.Chain.AccountProof(r.Context(), addr, height)
+	if err != nil {
+		switch {
+		case errors.Is(err, core.ErrAccountNotFound), errors.Is(err, core.ErrStateUnavailable):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// proofTxHandler returns the inclusion proof for the tx with ?hash=.
+func (api *HTTPAPI) proofTxHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	hash := r.URL.Query().Get("hash")
+	if hash == "" {
+		http.Error(w, "missing hash", http.StatusBadRequest)
+		return
+	}
+	blk, ok := api.Chain.BlockByHash(hash)
+	if !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	hdr, err := blk.Header()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(proof.TxProof{TxHash: blk.Hash, Block: hdr})
+}
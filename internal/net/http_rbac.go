@@ -0,0 +1,249 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"reservechain/internal/identity"
+	"reservechain/internal/logging"
+	"reservechain/internal/store"
+)
+
+// Roles a session can carry. Every wallet may act as a client; the other
+// roles must be granted (role_grants) or configured (auth.admins). Admin
+// satisfies every role requirement.
+const (
+	RoleClient   = "client"
+	RoleOperator = "operator"
+	RoleTreasury = "treasury"
+	RoleAdmin    = "admin"
+)
+
+func validRole(role string) bool {
+	switch role {
+	case RoleClient, RoleOperator, RoleTreasury, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// holdsRole reports whether roles satisfy a requirement for need.
+func holdsRole(roles []string, need string) bool {
+	for _, r := range roles {
+		if r == need || r == RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	bootstrapAdminsMu sync.RWMutex
+	bootstrapAdmins   map[string]bool
+)
+
+// SetBootstrapAdmins sets the identities ("rc:<addr>" or "evm:0x...") that
+// hold the admin role from config, so a fresh node has someone able to
+// grant roles. They are not stored in role_grants and cannot be revoked
+// through the API. Malformed entries are logged and skipped.
+func SetBootstrapAdmins(ids []string) {
+	m := make(map[string]bool, len(ids))
+	for _, raw := range ids {
+		id, ok := parseIdentity(raw)
+		if !ok {
+			authLog.Warn("ignoring malformed admin identity", "identity", raw)
+			continue
+		}
+		m[id] = true
+	}
+	bootstrapAdminsMu.Lock()
+	bootstrapAdmins = m
+	bootstrapAdminsMu.Unlock()
+}
+
+func isBootstrapAdmin(id string) bool {
+	bootstrapAdminsMu.RLock()
+	defer bootstrapAdminsMu.RUnlock()
+	return bootstrapAdmins[id]
+}
+
+// parseIdentity canonicalises "rc:<addr>" / "evm:0x..." identities.
+func parseIdentity(s string) (string, bool) {
+	wt, addr, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return "", false
+	}
+	return identity.CanonicalID(wt, addr)
+}
+
+// rolesFor returns every role identity currently holds, client included.
+func (api *HTTPAPI) rolesFor(ctx context.Context, id string) []string {
+	roles := []string{RoleClient}
+	if isBootstrapAdmin(id) {
+		roles = append(roles, RoleAdmin)
+	}
+	granted, err := api.DB.RolesFor(ctx, id)
+	if err != nil {
+		authLog.ErrorContext(ctx, "role lookup failed", "identity", id, logging.Err(err))
+	}
+	return append(roles, granted...)
+}
+
+// audit writes an entry to audit_events. Failures are logged, never
+// surfaced to the caller.
+func (api *HTTPAPI) audit(ctx context.Context, entityType, entityID, eventType, actor string, payload map[string]any) {
+	raw, _ := json.Marshal(payload)
+	err := api.DB.AppendAudit(ctx, store.AuditEvent{
+		EntityType: entityType,
+		EntityID:   entityID,
+		EventType:  eventType,
+		Payload:    raw,
+		Actor:      actor,
+	})
+	if err != nil {
+		authLog.ErrorContext(ctx, "audit write failed", "event", eventType, "entity", entityID, logging.Err(err))
+	}
+}
+
+// requireRole guards a privileged route. The caller's session must have
+// been opened with role (or admin), and the identity must still hold that
+// role, so revocations apply to live sessions. API keys reach operator
+// routes only, through the operator scope. Open devnet writes do not
+// bypass it. Every decision is written to audit_events.
+func (api *HTTPAPI) requireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		route := r.Method + " " + r.URL.Path
+		decision := map[string]any{
+			"required_role": role,
+			"remote_addr":   r.RemoteAddr,
+		}
+
+		s, ok := api.sessionFromRequest(r)
+		if !ok {
+			decision["reason"] = "unauthenticated"
+			api.audit(r.Context(), "route", route, "access_denied", "", decision)
+			writeAPIError(w, http.StatusUnauthorized, "unauthenticated", "a wallet session is required")
+			return
+		}
+		sessRole := s.Role
+		if s.APIKeyID != "" {
+			decision["api_key"] = s.APIKeyID
+			if role != RoleOperator || !s.allows(ScopeOperator) {
+				decision["reason"] = "scope"
+				api.audit(r.Context(), "route", route, "access_denied", s.Address, decision)
+				writeAPIError(w, http.StatusForbidden, "scope_required", "API keys need the operator scope and cannot reach "+role+" routes")
+				return
+			}
+			sessRole = RoleOperator
+		}
+		decision["session_role"] = sessRole
+		if !holdsRole([]string{sessRole}, role) {
+			decision["reason"] = "session_role"
+			api.audit(r.Context(), "route", route, "access_denied", s.Address, decision)
+			writeAPIError(w, http.StatusForbidden, "role_required", "this endpoint requires a "+role+" session")
+			return
+		}
+		if !holdsRole(api.rolesFor(r.Context(), s.Address), sessRole) {
+			decision["reason"] = "role_revoked"
+			api.audit(r.Context(), "route", route, "access_denied", s.Address, decision)
+			writeAPIError(w, http.StatusForbidden, "role_required", "role "+sessRole+" is not granted")
+			return
+		}
+		api.audit(r.Context(), "route", route, "access_allowed", s.Address, decision)
+		next(w, r.WithContext(context.WithValue(r.Context(), sessionCtxKey{}, s)))
+	}
+}
+
+// adminRolesHandler manages role grants (admin only):
+//
+//	GET    /api/admin/roles[?role=operator]
+//	POST   /api/admin/roles {"identity": "rc:...", "role": "operator"}
+//	DELETE /api/admin/roles {"identity": "rc:...", "role": "operator"}
+func (api *HTTPAPI) adminRolesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		role := r.URL.Query().Get("role")
+		grants, err := api.DB.ListRoleGrants(r.Context(), role)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		bootstrapAdminsMu.RLock()
+		admins := make([]string, 0, len(bootstrapAdmins))
+		for id := range bootstrapAdmins {
+			admins = append(admins, id)
+		}
+		bootstrapAdminsMu.RUnlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"grants":           grants,
+			"bootstrap_admins": admins,
+		})
+	case http.MethodPost, http.MethodDelete:
+		var req RoleGrantRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "bad_request", "invalid JSON body")
+			return
+		}
+		id, ok := parseIdentity(req.Identity)
+		if !ok {
+			writeAPIError(w, http.StatusBadRequest, "bad_identity", "identity must be rc:<addr> or evm:0x...")
+			return
+		}
+		if !validRole(req.Role) || req.Role == RoleClient {
+			writeAPIError(w, http.StatusBadRequest, "unknown_role", "role must be operator, treasury or admin")
+			return
+		}
+		actor, _ := sessionFromContext(r.Context())
+
+		if r.Method == http.MethodPost {
+			if err := api.DB.GrantRole(r.Context(), id, req.Role, actor.Address); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			api.audit(r.Context(), "role", id, "role_granted", actor.Address, map[string]any{"role": req.Role})
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "identity": id, "role": req.Role})
+			return
+		}
+
+		if id == actor.Address && req.Role == RoleAdmin {
+			writeAPIError(w, http.StatusBadRequest, "self_revoke", "admins cannot revoke their own admin role")
+			return
+		}
+		removed, err := api.DB.RevokeRole(r.Context(), id, req.Role)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if removed {
+			api.audit(r.Context(), "role", id, "role_revoked", actor.Address, map[string]any{"role": req.Role})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "identity": id, "role": req.Role, "removed": removed})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// adminAuditHandler lists recent audit events (admin only):
+// GET /api/admin/audit?entity_type=route&limit=100
+func (api *HTTPAPI) adminAuditHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	events, err := api.DB.ListAuditEvents(r.Context(), r.URL.Query().Get("entity_type"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(events)
+}
@@ -0,0 +1,40 @@
+package net
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// txSimulateHandler serves POST /api/v1/tx/simulate: the tx is run against
+// a copy-on-write fork of the current state and the balance deltas, NAV
+// and would-be block are returned. Nothing is committed or logged, so no
+// auth is required beyond the route's rate class.
+func (api *HTTPAPI) txSimulateHandler(w http.ResponseWriter, r *http.Request) {
+	var req TxSimulateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid_body", err.Error())
+		return
+	}
+	if req.Type == "" || len(req.Tx) == 0 {
+		writeAPIError(w, http.StatusBadRequest, "missing_field", "type and tx are required")
+		return
+	}
+	res, err := api.Chain.Simulate(r.Context(), normalizeTxType(req.Type), req.Tx)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid_tx", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// normalizeTxType accepts "transfer", "vault_withdraw" and friends for the
+// TX_* type names.
+func normalizeTxType(t string) string {
+	t = strings.ToUpper(strings.TrimSpace(t))
+	if !strings.HasPrefix(t, "TX_") {
+		t = "TX_" + t
+	}
+	return t
+}
@@ -74,8 +74,11 @@ func (api *HTTPAPI) stakingLockHandler(w http.ResponseWriter, r *http.Request) {
 		_ = json.NewEncoder(w).Encode(map[string]any{"error": "invalid JSON body"})
 		return
 	}
+	if !authorizeActor(w, r, tx.StakerWallet) {
+		return
+	}
 
-	blk, hash, err := api.Chain.ApplyStakeLock(tx)
+	blk, hash, err := api.Chain.ApplyStakeLock(r.Context(), tx)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
@@ -107,6 +110,9 @@ func (api *HTTPAPI) stakingUnlockHandler(w http.ResponseWriter, r *http.Request)
 		_ = json.NewEncoder(w).Encode(map[string]any{"error": "invalid JSON body"})
 		return
 	}
+	if !authorizeActor(w, r, tx.StakerWallet) {
+		return
+	}
 
 	// Enforce lock expiry at API layer (epoch state lives in econ).
 if api.DB != nil {
@@ -121,7 +127,7 @@ if api.DB != nil {
 	}
 }
 
-blk, hash, err := api.Chain.ApplyStakeUnlock(tx)
+blk, hash, err := api.Chain.ApplyStakeUnlock(r.Context(), tx)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		_ = json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
@@ -186,21 +192,47 @@ func (api *HTTPAPI) popRegisterNodeHandler(w http.ResponseWriter, r *http.Reques
     if v, ok := raw["role"].(string); ok {
         tx.Role = v
     }
+    if v, ok := raw["node_pubkey"].(string); ok {
+        tx.NodePubKey = v
+    }
+    if v, ok := raw["node_sig"].(string); ok {
+        tx.NodeSig = v
+    }
     // nonce optional: if omitted/0, server will use next nonce.
     if v, ok := raw["nonce"].(float64); ok {
         tx.Nonce = uint64(v)
     }
 
+    // Unsigned registrations are only accepted for this node itself, from a
+    // local caller (the operator's own tooling); the node key signs them here.
+    key := LocalNodeKey()
+    signLocally := tx.NodeSig == "" && key != nil && (tx.NodeID == "" || tx.NodeID == key.ID())
+    if signLocally {
+        if !isLoopbackRequest(r) {
+            w.WriteHeader(http.StatusForbidden)
+            _ = json.NewEncoder(w).Encode(map[string]any{"error": "node_sig required for remote registration"})
+            return
+        }
+        tx.NodeID = key.ID()
+        tx.NodePubKey = key.PublicKeyHex()
+    }
+
     if tx.OperatorWallet == "" || tx.NodeID == "" {
         w.WriteHeader(http.StatusBadRequest)
         _ = json.NewEncoder(w).Encode(map[string]any{"error": "operator_wallet and node_id required"})
         return
     }
+    if !authorizeActor(w, r, tx.OperatorWallet) {
+        return
+    }
     if tx.Nonce == 0 {
         tx.Nonce = api.Store.GetNonce(tx.OperatorWallet) + 1
     }
+    if signLocally {
+        tx.NodeSig = key.Sign(core.PoPRegisterNodeMessage(tx))
+    }
 
-    blk, txh, err := api.Chain.ApplyPoPRegisterNode(tx)
+    blk, txh, err := api.Chain.ApplyPoPRegisterNode(r.Context(), tx)
     if err != nil {
         w.WriteHeader(http.StatusBadRequest)
         _ = json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
@@ -261,6 +293,9 @@ func (api *HTTPAPI) popSubmitCapsHandler(w http.ResponseWriter, r *http.Request)
         _ = json.NewEncoder(w).Encode(map[string]any{"error": "operator_wallet and node_id required"})
         return
     }
+    if !authorizeActor(w, r, tx.OperatorWallet) {
+        return
+    }
     if tx.CPUScore == 0 { tx.CPUScore = 1 }
     if tx.RAMScore == 0 { tx.RAMScore = 1 }
     if tx.StorageScore == 0 { tx.StorageScore = 1 }
@@ -270,7 +305,7 @@ func (api *HTTPAPI) popSubmitCapsHandler(w http.ResponseWriter, r *http.Request)
         tx.Nonce = api.Store.GetNonce(tx.OperatorWallet) + 1
     }
 
-    blk, txh, err := api.Chain.ApplyPoPSetCaps(tx)
+    blk, txh, err := api.Chain.ApplyPoPSetCaps(r.Context(), tx)
     if err != nil {
         w.WriteHeader(http.StatusBadRequest)
         _ = json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
@@ -330,11 +365,14 @@ func (api *HTTPAPI) popClaimWorkHandler(w http.ResponseWriter, r *http.Request)
 		_ = json.NewEncoder(w).Encode(map[string]any{"error": "operator_wallet required"})
 		return
 	}
+	if !authorizeActor(w, r, body.OperatorWallet) {
+		return
+	}
 	if body.Epoch <= 0 {
 		body.Epoch = econ.CurrentDevnetEpoch()
 	}
 
-	blk, txh, err := api.Chain.ApplyPoPWorkClaim(core.PoPWorkClaimTx{
+	blk, txh, err := api.Chain.ApplyPoPWorkClaim(r.Context(), core.PoPWorkClaimTx{
 		OperatorWallet: body.OperatorWallet,
 		NodeID:         body.NodeID,
 		Epoch:          body.Epoch,
@@ -0,0 +1,453 @@
+package net
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"reservechain/internal/logging"
+)
+
+// JSON-RPC 2.0 error codes. -32000 to -32099 are the server-defined range.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+
+	rpcTxRejected   = -32000
+	rpcNotFound     = -32001
+	rpcUnauthorized = -32002
+	rpcWSOnly       = -32003
+	rpcRateLimited  = -32004
+)
+
+const (
+	maxRPCBody  = 1 << 20
+	maxRPCBatch = 100
+)
+
+// RPCRequest is a JSON-RPC 2.0 request. A request without an id is a
+// notification and gets no response.
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// RPCResponse carries either Result or Error. ID is null when the request
+// id could not be read.
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is a JSON-RPC error object.
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string { return e.Message }
+
+func rpcErrorf(code int, format string, args ...interface{}) *RPCError {
+	return &RPCError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// rpcSession is the caller of one request: its context, its session
+// (zero when anonymous) and, over WebSocket, the connection subscriptions
+// are attached to.
+type rpcSession struct {
+	ctx  context.Context
+	sess sessionEntry
+	host string // remote host, the rate limit key when anonymous
+	conn *rpcConn
+}
+
+type rpcMethod func(api *HTTPAPI, s *rpcSession, params json.RawMessage) (interface{}, error)
+
+// rpcUpgrader only accepts browser connections from the node's own origin
+// or a configured one (see checkOrigin).
+var rpcUpgrader = websocket.Upgrader{
+	CheckOrigin: checkOrigin,
+}
+
+// rpcHandler serves JSON-RPC 2.0 on /rpc: single and batch requests over
+// HTTP POST, and the same plus event subscriptions over WebSocket.
+func (api *HTTPAPI) rpcHandler(w http.ResponseWriter, r *http.Request) {
+	sess, _ := api.sessionFromRequest(r)
+	if websocket.IsWebSocketUpgrade(r) {
+		api.serveRPCWS(w, r, sess)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRPCBody))
+	if err != nil {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	out := api.rpcDispatch(&rpcSession{ctx: r.Context(), sess: sess, host: remoteHost(r)}, body)
+	if out == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(out)
+}
+
+// rpcDispatch handles one message body, single or batch, and returns the
+// encoded response, or nil when every request was a notification.
+//
+// Each call costs one RPC token. The HTTP request (charged by the rate
+// limit middleware) or WebSocket message (charged by readPump) pays for
+// the first; the rest of a batch is charged here, and calls that find the
+// bucket empty fail with rpcRateLimited.
+func (api *HTTPAPI) rpcDispatch(s *rpcSession, body []byte) []byte {
+	body = bytes.TrimSpace(body)
+	if !json.Valid(body) {
+		return mustMarshal(rpcFailure(nil, rpcErrorf(rpcParseError, "parse error")))
+	}
+	if len(body) == 0 || body[0] != '[' {
+		if resp := api.rpcCall(s, body); resp != nil {
+			return mustMarshal(resp)
+		}
+		return nil
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil || len(batch) == 0 {
+		return mustMarshal(rpcFailure(nil, rpcErrorf(rpcInvalidRequest, "invalid request")))
+	}
+	if len(batch) > maxRPCBatch {
+		return mustMarshal(rpcFailure(nil, rpcErrorf(rpcInvalidRequest, "batch exceeds %d requests", maxRPCBatch)))
+	}
+	out := make([]*RPCResponse, 0, len(batch))
+	for i, raw := range batch {
+		if i > 0 && !api.takeRPCToken(s) {
+			var req struct {
+				ID json.RawMessage `json:"id"`
+			}
+			if json.Unmarshal(raw, &req) != nil || len(req.ID) > 0 {
+				out = append(out, rpcFailure(req.ID, errRPCRateLimited()))
+			}
+			continue
+		}
+		if resp := api.rpcCall(s, raw); resp != nil {
+			out = append(out, resp)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return mustMarshal(out)
+}
+
+// rpcCall runs one request. It returns nil for notifications.
+func (api *HTTPAPI) rpcCall(s *rpcSession, raw json.RawMessage) *RPCResponse {
+	var req RPCRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return rpcFailure(nil, rpcErrorf(rpcInvalidRequest, "invalid request"))
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return rpcFailure(req.ID, rpcErrorf(rpcInvalidRequest, "invalid request"))
+	}
+	method, ok := rpcMethods[req.Method]
+	if !ok {
+		if len(req.ID) == 0 {
+			return nil
+		}
+		return rpcFailure(req.ID, rpcErrorf(rpcMethodNotFound, "method %q not found", req.Method))
+	}
+
+	result, err := method(api, s, req.Params)
+	if len(req.ID) == 0 {
+		return nil
+	}
+	if err != nil {
+		var rerr *RPCError
+		if !errors.As(err, &rerr) {
+			rerr = rpcErrorf(rpcInternalError, "%v", err)
+		}
+		return rpcFailure(req.ID, rerr)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return rpcFailure(req.ID, rpcErrorf(rpcInternalError, "encode result: %v", err))
+	}
+	return &RPCResponse{JSONRPC: "2.0", ID: req.ID, Result: data}
+}
+
+func errRPCRateLimited() *RPCError {
+	return rpcErrorf(rpcRateLimited, "too many rpc requests; retry later")
+}
+
+func rpcFailure(id json.RawMessage, err *RPCError) *RPCResponse {
+	return &RPCResponse{JSONRPC: "2.0", ID: id, Error: err}
+}
+
+func mustMarshal(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		rpcLog.Error("marshal response failed", logging.Err(err))
+		return []byte(`{"jsonrpc":"2.0","id":null,"error":{"code":-32603,"message":"internal error"}}`)
+	}
+	return data
+}
+
+// bindParams decodes positional ([a, b]) or named ({"a": .., "b": ..})
+// params into dst; names[i] is the name of the i-th positional param.
+// Absent params leave their destination untouched.
+func bindParams(raw json.RawMessage, names []string, dst ...interface{}) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil
+	}
+	switch raw[0] {
+	case '[':
+		var arr []json.RawMessage
+		if err := json.Unmarshal(raw, &arr); err != nil {
+			return rpcErrorf(rpcInvalidParams, "invalid params: %v", err)
+		}
+		if len(arr) > len(dst) {
+			return rpcErrorf(rpcInvalidParams, "expected at most %d params", len(dst))
+		}
+		for i, v := range arr {
+			if err := json.Unmarshal(v, dst[i]); err != nil {
+				return rpcErrorf(rpcInvalidParams, "invalid %s: %v", names[i], err)
+			}
+		}
+	case '{':
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return rpcErrorf(rpcInvalidParams, "invalid params: %v", err)
+		}
+		for i, n := range names {
+			if v, ok := obj[n]; ok {
+				if err := json.Unmarshal(v, dst[i]); err != nil {
+					return rpcErrorf(rpcInvalidParams, "invalid %s: %v", n, err)
+				}
+			}
+		}
+	default:
+		return rpcErrorf(rpcInvalidParams, "params must be an array or object")
+	}
+	return nil
+}
+
+// rpcConn is one JSON-RPC WebSocket. Event subscriptions ride on a hub
+// client whose subscription ids are the ones returned to the caller.
+type rpcConn struct {
+	api    *HTTPAPI
+	conn   *websocket.Conn
+	remote string
+	host   string
+	sess   sessionEntry
+	// ctx is the upgrade request's context without its cancellation,
+	// which comes when the handler returns.
+	ctx context.Context
+
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu     sync.Mutex
+	events *wsClient
+	nextID uint64
+}
+
+// rpcNotification is pushed for every event matching a subscription.
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  struct {
+		Subscription string          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	} `json:"params"`
+}
+
+func (api *HTTPAPI) serveRPCWS(w http.ResponseWriter, r *http.Request, sess sessionEntry) {
+	conn, err := rpcUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		rpcLog.WarnContext(r.Context(), "ws upgrade failed", logging.Err(err))
+		return
+	}
+	c := &rpcConn{
+		api:    api,
+		conn:   conn,
+		remote: conn.RemoteAddr().String(),
+		host:   remoteHost(r),
+		sess:   sess,
+		ctx:    context.WithoutCancel(r.Context()),
+		send:   make(chan []byte, api.Hub.opts.SendQueue),
+		done:   make(chan struct{}),
+	}
+	go c.writePump()
+	go c.readPump()
+}
+
+func (c *rpcConn) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+		c.mu.Lock()
+		if c.events != nil {
+			c.events.close()
+		}
+		c.mu.Unlock()
+	})
+}
+
+func (c *rpcConn) push(data []byte) bool {
+	select {
+	case c.send <- data:
+		return true
+	case <-c.done:
+		return false
+	}
+}
+
+func (c *rpcConn) writePump() {
+	opts := c.api.Hub.opts
+	ping := time.NewTicker(opts.PongWait * 9 / 10)
+	defer func() {
+		ping.Stop()
+		c.close()
+	}()
+	for {
+		select {
+		case <-c.done:
+			return
+		case data := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(opts.WriteWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ping.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(opts.WriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readPump handles requests in the order they arrive.
+func (c *rpcConn) readPump() {
+	defer c.close()
+	pongWait := c.api.Hub.opts.PongWait
+	c.conn.SetReadLimit(maxRPCBody)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	s := &rpcSession{ctx: c.ctx, sess: c.sess, host: c.host, conn: c}
+	for {
+		_, msg, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		if !c.api.takeRPCToken(s) {
+			if !c.push(mustMarshal(rpcFailure(nil, errRPCRateLimited()))) {
+				return
+			}
+			continue
+		}
+		if out := c.api.rpcDispatch(s, msg); out != nil && !c.push(out) {
+			return
+		}
+	}
+}
+
+// subscribe registers sub and returns its id. The first subscription
+// attaches the connection to the hub.
+func (c *rpcConn) subscribe(sub *wsSubscription) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.events == nil {
+		c.events = c.api.Hub.addClient(nil, c.remote, c.sess.readIdentity(), streamParams{})
+		go c.pumpEvents(c.events)
+	}
+	ev := c.events
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+	if len(ev.subs) >= maxWSSubscriptions {
+		return "", errWSTooManySubs
+	}
+	c.nextID++
+	id := "0x" + strconv.FormatUint(c.nextID, 16)
+	ev.subs[id] = sub
+	return id, nil
+}
+
+func (c *rpcConn) unsubscribe(id string) bool {
+	c.mu.Lock()
+	ev := c.events
+	c.mu.Unlock()
+	if ev == nil {
+		return false
+	}
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+	if _, ok := ev.subs[id]; !ok {
+		return false
+	}
+	delete(ev.subs, id)
+	return true
+}
+
+// pumpEvents turns hub deliveries into subscription notifications. If the
+// hub drops the client (slow consumer policy) the connection is closed.
+func (c *rpcConn) pumpEvents(ev *wsClient) {
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ev.done:
+			c.close()
+			return
+		case out := <-ev.send:
+			var scope *eventScope
+			scopeFn := func() eventScope {
+				if scope == nil {
+					sc := scopeOf(out.data)
+					scope = &sc
+				}
+				return *scope
+			}
+			ev.mu.Lock()
+			var ids []string
+			for id, sub := range ev.subs {
+				if sub.matches(EventType(out.typ), scopeFn()) {
+					ids = append(ids, id)
+				}
+			}
+			ev.mu.Unlock()
+			for _, id := range ids {
+				n := rpcNotification{JSONRPC: "2.0", Method: "events_subscription"}
+				n.Params.Subscription = id
+				n.Params.Result = out.data
+				if !c.push(mustMarshal(n)) {
+					return
+				}
+			}
+		}
+	}
+}
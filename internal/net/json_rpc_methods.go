@@ -0,0 +1,250 @@
+package net
+
+import (
+	"encoding/json"
+	"time"
+
+	"reservechain/internal/core"
+	"reservechain/internal/econ"
+)
+
+// rpcMethods mirrors the REST API. events_* need a WebSocket connection.
+var rpcMethods = map[string]rpcMethod{
+	"chain_getHead":      rpcChainGetHead,
+	"chain_getBlock":     rpcChainGetBlock,
+	"account_getBalance": rpcAccountGetBalance,
+	"account_getNonce":   rpcAccountGetNonce,
+	"tx_send":            rpcTxSend,
+	"tx_getReceipt":      rpcTxGetReceipt,
+	"econ_getCoverage":   rpcEconGetCoverage,
+	"events_subscribe":   rpcEventsSubscribe,
+	"events_unsubscribe": rpcEventsUnsubscribe,
+}
+
+// TxReceipt reports where a transaction was included. Every block carries
+// exactly one transaction, so the tx hash is the block hash.
+type TxReceipt struct {
+	TxHash      string      `json:"tx_hash"`
+	TxType      string      `json:"tx_type"`
+	Status      string      `json:"status"`
+	BlockHeight uint64      `json:"block_height"`
+	BlockHash   string      `json:"block_hash"`
+	Timestamp   time.Time   `json:"timestamp"`
+	Tx          interface{} `json:"tx"`
+}
+
+// authorize checks that the caller's session owns actor, the address a
+// write acts for, and that an API key carries scope, mirroring
+// requireSession and authorizeActor for the REST endpoints.
+func (s *rpcSession) authorize(actor, scope string) error {
+	if s.sess.Address == "" {
+		if devnetOpenWrites.Load() {
+			return nil
+		}
+		return rpcErrorf(rpcUnauthorized, "a wallet session is required")
+	}
+	if !s.sess.allows(scope) {
+		return rpcErrorf(rpcUnauthorized, "API key lacks the %s scope", scope)
+	}
+	if actor == "" || !sessionOwnsAddress(s.sess.Address, actor) {
+		return rpcErrorf(rpcUnauthorized, "session does not own %q", actor)
+	}
+	return nil
+}
+
+func rpcChainGetHead(api *HTTPAPI, _ *rpcSession, _ json.RawMessage) (interface{}, error) {
+	head := api.Chain.Head()
+	if head == nil {
+		return nil, rpcErrorf(rpcNotFound, "chain is empty")
+	}
+	return head, nil
+}
+
+// chain_getBlock: [height] or {"height": n} or {"hash": "..."}.
+func rpcChainGetBlock(api *HTTPAPI, _ *rpcSession, params json.RawMessage) (interface{}, error) {
+	var height *uint64
+	var hash string
+	if err := bindParams(params, []string{"height", "hash"}, &height, &hash); err != nil {
+		return nil, err
+	}
+	if height == nil && hash == "" {
+		return nil, rpcErrorf(rpcInvalidParams, "height or hash required")
+	}
+	var blk *core.Block
+	var ok bool
+	if height != nil {
+		blk, ok = api.Chain.BlockByHeight(*height)
+	} else {
+		blk, ok = api.Chain.BlockByHash(hash)
+	}
+	if !ok {
+		return nil, rpcErrorf(rpcNotFound, "block not found")
+	}
+	return blk, nil
+}
+
+// account_getBalance: [address, asset?]. Without an asset every balance
+// of the account is returned.
+func rpcAccountGetBalance(api *HTTPAPI, _ *rpcSession, params json.RawMessage) (interface{}, error) {
+	var addr, asset string
+	if err := bindParams(params, []string{"address", "asset"}, &addr, &asset); err != nil {
+		return nil, err
+	}
+	if addr == "" {
+		return nil, rpcErrorf(rpcInvalidParams, "address required")
+	}
+	acc := api.Store.Snapshot(addr)
+	if asset != "" {
+		return map[string]interface{}{
+			"address": addr,
+			"asset":   asset,
+			"balance": acc.Balances[asset],
+		}, nil
+	}
+	return map[string]interface{}{
+		"address":  addr,
+		"balances": acc.Balances,
+	}, nil
+}
+
+// account_getNonce: [address].
+func rpcAccountGetNonce(api *HTTPAPI, _ *rpcSession, params json.RawMessage) (interface{}, error) {
+	var addr string
+	if err := bindParams(params, []string{"address"}, &addr); err != nil {
+		return nil, err
+	}
+	if addr == "" {
+		return nil, rpcErrorf(rpcInvalidParams, "address required")
+	}
+	return map[string]interface{}{
+		"address": addr,
+		"nonce":   api.Store.GetNonce(addr),
+	}, nil
+}
+
+// tx_send: [type, tx] with the same type tags and bodies as the REST
+// endpoints (TX_TRANSFER, TX_VAULT_CREATE, TX_TIER_RENEW). The caller's
+// session must own the tx sender.
+func rpcTxSend(api *HTTPAPI, s *rpcSession, params json.RawMessage) (interface{}, error) {
+	var typ string
+	var raw json.RawMessage
+	if err := bindParams(params, []string{"type", "tx"}, &typ, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, rpcErrorf(rpcInvalidParams, "tx required")
+	}
+
+	var (
+		blk  *core.Block
+		hash string
+		err  error
+	)
+	switch typ {
+	case "TX_TRANSFER":
+		var tx core.TransferTx
+		if err := json.Unmarshal(raw, &tx); err != nil {
+			return nil, rpcErrorf(rpcInvalidParams, "invalid tx: %v", err)
+		}
+		if err := s.authorize(tx.From, ScopeTransfer); err != nil {
+			return nil, err
+		}
+		blk, hash, err = api.applyTransfer(s.ctx, tx)
+	case "TX_VAULT_CREATE":
+		var tx core.TxVaultCreate
+		if err := json.Unmarshal(raw, &tx); err != nil {
+			return nil, rpcErrorf(rpcInvalidParams, "invalid tx: %v", err)
+		}
+		if err := s.authorize(tx.Owner, ScopeTrade); err != nil {
+			return nil, err
+		}
+		blk, hash, err = api.applyVaultCreate(s.ctx, tx)
+	case "TX_TIER_RENEW":
+		var tx core.TxTierRenew
+		if err := json.Unmarshal(raw, &tx); err != nil {
+			return nil, rpcErrorf(rpcInvalidParams, "invalid tx: %v", err)
+		}
+		if err := s.authorize(tx.Sender, ScopeTrade); err != nil {
+			return nil, err
+		}
+		blk, hash, err = api.applyTierRenew(s.ctx, tx)
+	default:
+		return nil, rpcErrorf(rpcInvalidParams, "unsupported tx type %q", typ)
+	}
+	if err != nil {
+		return nil, rpcErrorf(rpcTxRejected, "%v", err)
+	}
+	out := map[string]interface{}{"tx_hash": hash}
+	if blk != nil {
+		out["height"] = blk.Height
+	}
+	return out, nil
+}
+
+// tx_getReceipt: [hash]. Unknown hashes return null.
+func rpcTxGetReceipt(api *HTTPAPI, _ *rpcSession, params json.RawMessage) (interface{}, error) {
+	var hash string
+	if err := bindParams(params, []string{"hash"}, &hash); err != nil {
+		return nil, err
+	}
+	if hash == "" {
+		return nil, rpcErrorf(rpcInvalidParams, "hash required")
+	}
+	blk, ok := api.Chain.BlockByHash(hash)
+	if !ok {
+		return nil, nil
+	}
+	return &TxReceipt{
+		TxHash:      blk.Hash,
+		TxType:      blk.TxType,
+		Status:      "confirmed",
+		BlockHeight: blk.Height,
+		BlockHash:   blk.Hash,
+		Timestamp:   blk.Timestamp,
+		Tx:          blk.Tx,
+	}, nil
+}
+
+func rpcEconGetCoverage(_ *HTTPAPI, _ *rpcSession, _ json.RawMessage) (interface{}, error) {
+	return econ.SnapshotCurrentCoverage(), nil
+}
+
+// events_subscribe: {"types": [...], "address": ..., "vault_id": ...,
+// "validator": ...}, same filters as /ws. Returns the subscription id
+// carried by each events_subscription notification.
+func rpcEventsSubscribe(_ *HTTPAPI, s *rpcSession, params json.RawMessage) (interface{}, error) {
+	if s.conn == nil {
+		return nil, rpcErrorf(rpcWSOnly, "subscriptions require a WebSocket connection")
+	}
+	var req WSRequest
+	if err := bindParams(params, []string{"types", "address", "vault_id", "validator"},
+		&req.Types, &req.Address, &req.VaultID, &req.Validator); err != nil {
+		return nil, err
+	}
+	req.ID = "rpc"
+	sub, err := newSubscription(req, s.sess.readIdentity())
+	switch err {
+	case nil:
+	case errWSAuthRequired, errWSAddressNotOwned, errWSAddressRequired:
+		return nil, rpcErrorf(rpcUnauthorized, "%v", err)
+	default:
+		return nil, rpcErrorf(rpcInvalidParams, "%v", err)
+	}
+	id, err := s.conn.subscribe(sub)
+	if err != nil {
+		return nil, rpcErrorf(rpcInvalidParams, "%v", err)
+	}
+	return id, nil
+}
+
+// events_unsubscribe: [id]. Returns whether the subscription existed.
+func rpcEventsUnsubscribe(_ *HTTPAPI, s *rpcSession, params json.RawMessage) (interface{}, error) {
+	if s.conn == nil {
+		return nil, rpcErrorf(rpcWSOnly, "subscriptions require a WebSocket connection")
+	}
+	var id string
+	if err := bindParams(params, []string{"id"}, &id); err != nil {
+		return nil, err
+	}
+	return s.conn.unsubscribe(id), nil
+}
@@ -1,17 +1,489 @@
 package net
 
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "sort"
+    "sync"
+    "time"
+
+    "reservechain/internal/identity"
+    "reservechain/internal/logging"
+    "reservechain/internal/store"
+)
+
+const (
+    // leaderSlotTicks is how many consecutive ticks one node leads before
+    // the schedule rotates to the next member.
+    leaderSlotTicks = 10
+    // leaderHeartbeatInterval is how often each node announces itself.
+    leaderHeartbeatInterval = 2 * time.Second
+    // leaderTimeout is how long a member may stay silent before the
+    // schedule fails over to the next live member.
+    leaderTimeout = 3 * leaderHeartbeatInterval
+    // maxTickAge bounds how far a received tick may lag behind local time.
+    maxTickAge = 10
+)
+
+var (
+    errUnknownLeader  = errors.New("unknown node")
+    errNotScheduled   = errors.New("not the scheduled leader")
+    errStaleTick      = errors.New("stale tick")
+    errPubKeyMismatch = errors.New("pubkey does not match handshake")
+)
+
+// leaderMember is a node that takes part in the valuation tick schedule.
+// Members come from peers that passed a signed handshake; lastSeen is
+// refreshed by their heartbeats and ticks.
+type leaderMember struct {
+    NodeID   string
+    Addr     string
+    PubKey   string
+    LastSeen time.Time
+}
+
+// LeaderSelector decides which node emits the valuation tick stream. The
+// schedule is the sorted set of this node plus every live, handshaken peer;
+// each member leads for leaderSlotTicks ticks in turn, and a member that has
+// not been heard from within leaderTimeout is skipped so the next live member
+// takes over. Ticks are keyed by unix second so all nodes agree on the slot.
 type LeaderSelector struct {
-    NodeIDs []string
+    mu       sync.RWMutex
+    selfID   string
+    members  map[string]*leaderMember
+    lastTick uint64
+
+    hub    *WSHub
+    db     *store.DB
+    client *http.Client
+}
+
+var (
+    leaderMu     sync.RWMutex
+    activeLeader *LeaderSelector
+)
+
+// NewLeaderSelector creates a selector for the local node. Verified ticks
+// received from other leaders are rebroadcast on hub.
+func NewLeaderSelector(selfID string, hub *WSHub) *LeaderSelector {
+    return &LeaderSelector{
+        selfID:  selfID,
+        members: make(map[string]*leaderMember),
+        hub:     hub,
+        client:  &http.Client{Timeout: 2 * time.Second},
+    }
+}
+
+// SetLeaderSelector makes ls serve the /api/econ/heartbeat, /api/econ/tick
+// and /api/econ/leader endpoints.
+func SetLeaderSelector(ls *LeaderSelector) {
+    leaderMu.Lock()
+    defer leaderMu.Unlock()
+    activeLeader = ls
+}
+
+func currentLeaderSelector() *LeaderSelector {
+    leaderMu.RLock()
+    defer leaderMu.RUnlock()
+    return activeLeader
+}
+
+// AttachDB wires the selector to the peer table it builds the schedule from.
+func (ls *LeaderSelector) AttachDB(db *store.DB) {
+    ls.mu.Lock()
+    defer ls.mu.Unlock()
+    ls.db = db
+}
+
+// refreshMembers reloads the member set from live peers with a known node
+// identity on this chain. Liveness timestamps of existing members are kept.
+func (ls *LeaderSelector) refreshMembers(ctx context.Context) {
+    ls.mu.RLock()
+    db := ls.db
+    ls.mu.RUnlock()
+    if db == nil {
+        return
+    }
+    recs, err := db.ListPeers(ctx, "", store.PeerStatusLive, 0)
+    if err != nil {
+        leaderLog.Error("list peers failed", logging.Err(err))
+        return
+    }
+    _, chainID := localInfo()
+
+    ls.mu.Lock()
+    defer ls.mu.Unlock()
+    next := make(map[string]*leaderMember, len(recs))
+    for _, rec := range recs {
+        if rec.NodeID == "" || rec.PubKey == "" || rec.NodeID == ls.selfID {
+            continue
+        }
+        if chainID != "" && rec.ChainID != chainID {
+            continue
+        }
+        m := &leaderMember{NodeID: rec.NodeID, Addr: rec.Addr, PubKey: rec.PubKey}
+        if old, ok := ls.members[rec.NodeID]; ok {
+            m.LastSeen = old.LastSeen
+        }
+        next[rec.NodeID] = m
+    }
+    ls.members = next
 }
 
-func NewLeaderSelector(ids []string) *LeaderSelector {
-    return &LeaderSelector{NodeIDs: ids}
+// Schedule returns the sorted node IDs taking part in the rotation.
+func (ls *LeaderSelector) Schedule() []string {
+    ls.mu.RLock()
+    defer ls.mu.RUnlock()
+    return ls.scheduleLocked()
 }
 
+func (ls *LeaderSelector) scheduleLocked() []string {
+    ids := make([]string, 0, len(ls.members)+1)
+    ids = append(ids, ls.selfID)
+    for id := range ls.members {
+        ids = append(ids, id)
+    }
+    sort.Strings(ids)
+    return ids
+}
+
+func (ls *LeaderSelector) aliveLocked(id string, now time.Time) bool {
+    if id == ls.selfID {
+        return true
+    }
+    m, ok := ls.members[id]
+    return ok && now.Sub(m.LastSeen) <= leaderTimeout
+}
+
+// LeaderForTick returns the node that should emit the given tick: the
+// scheduled member for the tick's slot, or the next live member after it.
 func (ls *LeaderSelector) LeaderForTick(tick uint64) string {
-    if len(ls.NodeIDs) == 0 {
-        return ""
+    ls.mu.RLock()
+    defer ls.mu.RUnlock()
+    return ls.leaderForTickLocked(tick, time.Now())
+}
+
+func (ls *LeaderSelector) leaderForTickLocked(tick uint64, now time.Time) string {
+    ids := ls.scheduleLocked()
+    n := uint64(len(ids))
+    start := (tick / leaderSlotTicks) % n
+    for i := uint64(0); i < n; i++ {
+        id := ids[(start+i)%n]
+        if ls.aliveLocked(id, now) {
+            return id
+        }
+    }
+    return ls.selfID
+}
+
+func (ls *LeaderSelector) markSeen(id string, at time.Time) {
+    ls.mu.Lock()
+    defer ls.mu.Unlock()
+    if m, ok := ls.members[id]; ok && at.After(m.LastSeen) {
+        m.LastSeen = at
+    }
+}
+
+// memberAddrs returns the base URLs of every member.
+func (ls *LeaderSelector) memberAddrs() []string {
+    ls.mu.RLock()
+    defer ls.mu.RUnlock()
+    out := make([]string, 0, len(ls.members))
+    for _, m := range ls.members {
+        out = append(out, m.Addr)
+    }
+    return out
+}
+
+// checkMember verifies that id is a member presenting its handshake key.
+func (ls *LeaderSelector) checkMember(id, pubKey string) error {
+    ls.mu.RLock()
+    defer ls.mu.RUnlock()
+    m, ok := ls.members[id]
+    if !ok {
+        return errUnknownLeader
+    }
+    if m.PubKey != pubKey {
+        return errPubKeyMismatch
+    }
+    return nil
+}
+
+// Run refreshes the member set and sends signed heartbeats to every member
+// until ctx is done.
+func (ls *LeaderSelector) Run(ctx context.Context) {
+    ticker := time.NewTicker(leaderHeartbeatInterval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+        ls.refreshMembers(ctx)
+        hb, err := newLeaderHeartbeat()
+        if err != nil {
+            continue
+        }
+        ls.fanOut("/api/econ/heartbeat", hb)
+    }
+}
+
+// fanOut POSTs body to path on every member without waiting for replies.
+func (ls *LeaderSelector) fanOut(path string, body interface{}) {
+    data, err := json.Marshal(body)
+    if err != nil {
+        return
+    }
+    for _, addr := range ls.memberAddrs() {
+        go func(addr string) {
+            resp, err := ls.client.Post(addr+path, "application/json", bytes.NewReader(data))
+            if err != nil {
+                return
+            }
+            resp.Body.Close()
+        }(addr)
+    }
+}
+
+// LeaderHeartbeat is the signed liveness message members exchange.
+type LeaderHeartbeat struct {
+    NodeID    string    `json:"node_id"`
+    PubKey    string    `json:"pubkey"`
+    ChainID   string    `json:"chain_id"`
+    Time      time.Time `json:"time"`
+    Signature string    `json:"signature"`
+}
+
+func (hb LeaderHeartbeat) signingBytes() []byte {
+    return []byte(fmt.Sprintf("reservechain-econ-heartbeat|%s|%s|%d", hb.NodeID, hb.ChainID, hb.Time.Unix()))
+}
+
+func newLeaderHeartbeat() (LeaderHeartbeat, error) {
+    key, chainID := localInfo()
+    if key == nil {
+        return LeaderHeartbeat{}, errors.New("node key not configured")
+    }
+    hb := LeaderHeartbeat{
+        NodeID:  key.ID(),
+        PubKey:  key.PublicKeyHex(),
+        ChainID: chainID,
+        Time:    time.Now().UTC(),
+    }
+    hb.Signature = key.Sign(hb.signingBytes())
+    return hb, nil
+}
+
+// AcceptHeartbeat verifies a heartbeat and marks its sender live.
+func (ls *LeaderSelector) AcceptHeartbeat(hb LeaderHeartbeat) error {
+    if err := identity.VerifyNodeSignature(hb.NodeID, hb.PubKey, hb.signingBytes(), hb.Signature); err != nil {
+        return err
+    }
+    if skew := time.Since(hb.Time); skew > maxHandshakeSkew || skew < -maxHandshakeSkew {
+        return errors.New("heartbeat timestamp outside allowed skew")
+    }
+    if _, chainID := localInfo(); chainID != "" && hb.ChainID != chainID {
+        return errChainIDMismatch
+    }
+    if err := ls.checkMember(hb.NodeID, hb.PubKey); err != nil {
+        return err
+    }
+    ls.markSeen(hb.NodeID, time.Now())
+    return nil
+}
+
+// SignedTick is a valuation tick event signed by the leader that produced
+// it. Followers verify it and rebroadcast the original event unchanged.
+type SignedTick struct {
+    TickID    uint64          `json:"tick_id"`
+    EventID   string          `json:"event_id"`
+    LeaderID  string          `json:"leader_id"`
+    PubKey    string          `json:"pubkey"`
+    ChainID   string          `json:"chain_id"`
+    Timestamp time.Time       `json:"timestamp"`
+    Payload   json.RawMessage `json:"payload"`
+    Signature string          `json:"signature"`
+}
+
+func (st SignedTick) signingBytes() []byte {
+    sum := sha256.Sum256(st.Payload)
+    return []byte(fmt.Sprintf("reservechain-econ-tick|%d|%s|%s|%s|%d|%s",
+        st.TickID, st.EventID, st.LeaderID, st.ChainID, st.Timestamp.UnixNano(), hex.EncodeToString(sum[:])))
+}
+
+func (st SignedTick) event() Event {
+    return Event{
+        ID:        st.EventID,
+        Type:      EventValuationTick,
+        Version:   "v1",
+        Payload:   st.Payload,
+        Timestamp: st.Timestamp,
+    }
+}
+
+// PublishTick signs a locally produced tick event, broadcasts it to local
+// WebSocket clients and pushes it to every member.
+func (ls *LeaderSelector) PublishTick(tickID uint64, ev Event) error {
+    key, chainID := localInfo()
+    if key == nil {
+        return errors.New("node key not configured")
+    }
+    payload, err := json.Marshal(ev.Payload)
+    if err != nil {
+        return err
+    }
+    st := SignedTick{
+        TickID:    tickID,
+        EventID:   ev.ID,
+        LeaderID:  key.ID(),
+        PubKey:    key.PublicKeyHex(),
+        ChainID:   chainID,
+        Timestamp: ev.Timestamp,
+        Payload:   payload,
+    }
+    st.Signature = key.Sign(st.signingBytes())
+
+    ls.mu.Lock()
+    if tickID > ls.lastTick {
+        ls.lastTick = tickID
+    }
+    ls.mu.Unlock()
+
+    ls.hub.Broadcast(st.event())
+    ls.fanOut("/api/econ/tick", st)
+    return nil
+}
+
+// AcceptTick verifies a tick from another node and rebroadcasts it locally.
+// The sender must be a member holding the key from its handshake and, by
+// this node's view of the schedule, the leader for that tick.
+func (ls *LeaderSelector) AcceptTick(st SignedTick) error {
+    if err := identity.VerifyNodeSignature(st.LeaderID, st.PubKey, st.signingBytes(), st.Signature); err != nil {
+        return err
+    }
+    if _, chainID := localInfo(); chainID != "" && st.ChainID != chainID {
+        return errChainIDMismatch
+    }
+    if err := ls.checkMember(st.LeaderID, st.PubKey); err != nil {
+        return err
+    }
+    now := time.Now()
+    if st.TickID+maxTickAge < uint64(now.Unix()) {
+        return errStaleTick
+    }
+    // A tick proves the sender is alive, which may itself settle a failover.
+    ls.markSeen(st.LeaderID, now)
+
+    ls.mu.Lock()
+    if st.TickID <= ls.lastTick {
+        ls.mu.Unlock()
+        return errStaleTick
+    }
+    if leader := ls.leaderForTickLocked(st.TickID, now); leader != st.LeaderID {
+        ls.mu.Unlock()
+        return errNotScheduled
+    }
+    ls.lastTick = st.TickID
+    ls.mu.Unlock()
+
+    ls.hub.Broadcast(st.event())
+    return nil
+}
+
+// LeaderStatus is the view served at /api/econ/leader.
+type LeaderStatus struct {
+    Self     string           `json:"self"`
+    Leader   string           `json:"leader"`
+    Tick     uint64           `json:"tick"`
+    Schedule []string         `json:"schedule"`
+    LastSeen map[string]int64 `json:"last_seen"` // unix seconds per member
+}
+
+// Status returns the current schedule and leader as seen by this node.
+func (ls *LeaderSelector) Status() LeaderStatus {
+    now := time.Now()
+    tick := uint64(now.Unix())
+    ls.mu.RLock()
+    defer ls.mu.RUnlock()
+    st := LeaderStatus{
+        Self:     ls.selfID,
+        Leader:   ls.leaderForTickLocked(tick, now),
+        Tick:     tick,
+        Schedule: ls.scheduleLocked(),
+        LastSeen: make(map[string]int64, len(ls.members)),
+    }
+    for id, m := range ls.members {
+        if !m.LastSeen.IsZero() {
+            st.LastSeen[id] = m.LastSeen.Unix()
+        }
+    }
+    return st
+}
+
+// econHeartbeatHandler accepts signed heartbeats from schedule members.
+func (api *HTTPAPI) econHeartbeatHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        w.WriteHeader(http.StatusMethodNotAllowed)
+        return
+    }
+    ls := currentLeaderSelector()
+    if ls == nil {
+        http.Error(w, "leader election disabled", http.StatusServiceUnavailable)
+        return
+    }
+    var hb LeaderHeartbeat
+    if err := json.NewDecoder(r.Body).Decode(&hb); err != nil {
+        http.Error(w, "invalid payload", http.StatusBadRequest)
+        return
+    }
+    if err := ls.AcceptHeartbeat(hb); err != nil {
+        http.Error(w, err.Error(), http.StatusForbidden)
+        return
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// econTickHandler accepts signed valuation ticks pushed by the leader.
+func (api *HTTPAPI) econTickHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        w.WriteHeader(http.StatusMethodNotAllowed)
+        return
+    }
+    ls := currentLeaderSelector()
+    if ls == nil {
+        http.Error(w, "leader election disabled", http.StatusServiceUnavailable)
+        return
+    }
+    var st SignedTick
+    if err := json.NewDecoder(r.Body).Decode(&st); err != nil {
+        http.Error(w, "invalid payload", http.StatusBadRequest)
+        return
+    }
+    if err := ls.AcceptTick(st); err != nil {
+        code := http.StatusForbidden
+        if errors.Is(err, errStaleTick) {
+            code = http.StatusConflict
+        }
+        http.Error(w, err.Error(), code)
+        return
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// econLeaderHandler reports the current leader schedule.
+func (api *HTTPAPI) econLeaderHandler(w http.ResponseWriter, r *http.Request) {
+    ls := currentLeaderSelector()
+    if ls == nil {
+        http.Error(w, "leader election disabled", http.StatusServiceUnavailable)
+        return
     }
-    idx := int(tick % uint64(len(ls.NodeIDs)))
-    return ls.NodeIDs[idx]
+    w.Header().Set("Content-Type", "application/json")
+    _ = json.NewEncoder(w).Encode(ls.Status())
 }
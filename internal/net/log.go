@@ -0,0 +1,18 @@
+package net
+
+import "reservechain/internal/logging"
+
+// Component loggers of the net package; see logging.For.
+var (
+	httpLog     = logging.For("http")
+	authLog     = logging.For("auth")
+	wsLog       = logging.For("ws")
+	terminalLog = logging.For("terminal")
+	rpcLog      = logging.For("rpc")
+	eventsLog   = logging.For("events")
+	p2pLog      = logging.For("p2p")
+	syncLog     = logging.For("sync")
+	snapshotLog = logging.For("snapshot")
+	leaderLog   = logging.For("leader")
+	limitLog    = logging.For("ratelimit")
+)
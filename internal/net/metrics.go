@@ -0,0 +1,142 @@
+package net
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	stdnet "net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"reservechain/internal/econ"
+	"reservechain/internal/metrics"
+)
+
+var (
+	httpDuration = metrics.NewHistogramVec("reservechain_http_request_duration_seconds",
+		"REST request latency by route template, method and status code.",
+		metrics.DefBuckets, "route", "method", "code")
+	syncLag = metrics.NewGaugeVec("reservechain_sync_lag_blocks",
+		"Blocks the local tip was behind a peer's head at the last sync attempt.", "peer")
+
+	// Refreshed on every scrape by metricsHandler.
+	mempoolSize = metrics.NewGaugeVec("reservechain_mempool_size",
+		"Transactions waiting in the mempool.")
+	wsClients = metrics.NewGaugeVec("reservechain_ws_clients",
+		"Connected event stream clients by transport.", "transport")
+	wsDropped = metrics.NewCounterVec("reservechain_ws_dropped_events_total",
+		"Event deliveries dropped because a queue was full.")
+	peerCount = metrics.NewGaugeVec("reservechain_peers",
+		"Persisted peers by liveness status.", "status")
+	seedPeers = metrics.NewGaugeVec("reservechain_seed_registry_peers",
+		"Peers currently registered with this node as a seed.")
+	rateLimited = metrics.NewCounterVec("reservechain_rate_limit_requests_total",
+		"Rate-limited route requests by class and outcome.", "class", "outcome")
+	econNAV = metrics.NewGaugeVec("reservechain_econ_nav",
+		"GRC NAV: the valuation feed's, and the one implied by on-chain treasury reserves.", "source")
+	econCoverage = metrics.NewGaugeVec("reservechain_econ_usdr_coverage",
+		"Effective reserves over USDR supply.")
+	econPendingRedemptions = metrics.NewGaugeVec("reservechain_econ_pending_redemptions",
+		"Queued USDR redemptions.")
+	econPendingRedemptionsUSDR = metrics.NewGaugeVec("reservechain_econ_pending_redemptions_usdr",
+		"USDR amount of the queued redemptions.")
+	econEpoch = metrics.NewGaugeVec("reservechain_econ_epoch",
+		"Current econ epoch.")
+	econSupply = metrics.NewGaugeVec("reservechain_econ_supply",
+		"Mainnet-model token supply by asset.", "asset")
+)
+
+// instrumentRoute records the latency of rt's requests under the route
+// template path. Stream routes are passed through.
+func instrumentRoute(rt v1Route, path string, h http.HandlerFunc) http.HandlerFunc {
+	if rt.Stream {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h(rec, r)
+		httpDuration.Observe(time.Since(start).Seconds(), path, r.Method, strconv.Itoa(rec.status))
+	}
+}
+
+// statusRecorder remembers the status code written through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if !s.wrote {
+		s.status, s.wrote = code, true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wrote = true
+	return s.ResponseWriter.Write(b)
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *statusRecorder) Hijack() (stdnet.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := s.ResponseWriter.(http.Hijacker); ok {
+		return hj.Hijack()
+	}
+	return nil, nil, errors.New("hijack not supported")
+}
+
+// metricsHandler serves GET /metrics in the Prometheus text format. Gauges
+// that mirror state owned elsewhere are refreshed first.
+func (api *HTTPAPI) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	api.refreshMetrics(r.Context())
+	metrics.Default.Handler().ServeHTTP(w, r)
+}
+
+func (api *HTTPAPI) refreshMetrics(ctx context.Context) {
+	if api.Chain != nil {
+		mempoolSize.Set(float64(len(api.Chain.PendingTxsSnapshot())))
+		_, _, nav := api.Chain.DevnetMonetarySnapshot()
+		econNAV.Set(nav, "chain")
+	}
+	if api.Hub != nil {
+		ws, sse := api.Hub.ClientCounts()
+		wsClients.Set(float64(ws), "ws")
+		wsClients.Set(float64(sse), "sse")
+		wsDropped.Set(float64(api.Hub.Dropped()))
+	}
+	if api.DB != nil {
+		if counts, err := api.DB.CountPeersByStatus(ctx); err == nil {
+			peerCount.Reset()
+			for status, n := range counts {
+				peerCount.Set(float64(n), status)
+			}
+		}
+	}
+	seedPeers.Set(float64(len(seedRegistry.List())))
+	for _, st := range RateLimitStats() {
+		rateLimited.Set(float64(st.Allowed), st.Class, "allowed")
+		rateLimited.Set(float64(st.Throttled), st.Class, "throttled")
+	}
+
+	econNAV.Set(econ.GetLastNAV().GRC, "feed")
+	econCoverage.Set(econ.SnapshotCurrentCoverage().Coverage)
+	red := econ.SnapshotDevnetRedemptions()
+	econPendingRedemptions.Set(float64(len(red.Pending)))
+	econPendingRedemptionsUSDR.Set(red.TotalPendingUSDR)
+	econEpoch.Set(float64(red.CurrentEpoch))
+	supply := econ.SnapshotMainnetState().Supply
+	econSupply.Set(supply.GRC, "GRC")
+	econSupply.Set(supply.USDR, "USDR")
+}
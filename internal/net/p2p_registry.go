@@ -0,0 +1,406 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	stdnet "net"
+	"net/http"
+	"sync"
+	"time"
+
+	"reservechain/internal/core"
+	"reservechain/internal/identity"
+	"reservechain/internal/logging"
+	"reservechain/internal/store"
+)
+
+// NodeVersion is the software version advertised in p2p handshakes.
+const NodeVersion = "2.0.0-devnet"
+
+// -----------------------------------------------------------------------------
+// DevNet Seed Registry (SQLite-backed, probed)
+// -----------------------------------------------------------------------------
+
+var (
+	seedMode     bool
+	seedRegistry = newSeedRegistry(5 * time.Minute)
+
+	localMu      sync.RWMutex
+	localKey     *identity.NodeKey
+	localChainID string
+)
+
+var (
+	errChainIDMismatch = errors.New("chain_id mismatch")
+	errTooManyAddrs    = errors.New("node has registered too many addresses")
+)
+
+// maxAddrsPerNode bounds how many addresses one node ID may register with
+// a seed, so a single key cannot fill p2p_peers or point the prober at an
+// unbounded list of targets.
+const maxAddrsPerNode = 4
+
+// maxHandshakeSkew bounds how old (or far in the future) a signed handshake
+// or registration may be, which limits replay of captured messages.
+const maxHandshakeSkew = 5 * time.Minute
+
+// EnableSeedMode toggles whether this node behaves as a seed for simple
+// HTTP-based peer discovery.
+func EnableSeedMode(on bool) {
+	seedMode = on
+}
+
+// SetLocalNodeInfo sets the key that signs this node's handshakes and
+// registrations, and the chain ID that remote peers must match before they
+// are accepted.
+func SetLocalNodeInfo(key *identity.NodeKey, chainID string) {
+	localMu.Lock()
+	defer localMu.Unlock()
+	localKey = key
+	localChainID = chainID
+}
+
+func localInfo() (*identity.NodeKey, string) {
+	localMu.RLock()
+	defer localMu.RUnlock()
+	return localKey, localChainID
+}
+
+// LocalNodeKey returns the key set via SetLocalNodeInfo, or nil.
+func LocalNodeKey() *identity.NodeKey {
+	key, _ := localInfo()
+	return key
+}
+
+// Handshake is the payload served at /api/p2p/handshake. It is signed by
+// the node key; peers are only advertised or synced from once they answer
+// with a valid signature and a matching chain ID.
+type Handshake struct {
+	NodeID    string    `json:"node_id"`
+	PubKey    string    `json:"pubkey"`
+	Version   string    `json:"version"`
+	ChainID   string    `json:"chain_id"`
+	Height    uint64    `json:"height"`
+	HeadHash  string    `json:"head_hash,omitempty"`
+	Time      time.Time `json:"time"`
+	Signature string    `json:"signature"`
+}
+
+func (h Handshake) signingBytes() []byte {
+	return []byte(fmt.Sprintf("reservechain-handshake|%s|%s|%s|%d|%s|%d",
+		h.NodeID, h.Version, h.ChainID, h.Height, h.HeadHash, h.Time.Unix()))
+}
+
+func localHandshake(chain *core.Chain) Handshake {
+	key, chainID := localInfo()
+	hs := Handshake{
+		Version: NodeVersion,
+		ChainID: chainID,
+		Time:    time.Now().UTC(),
+	}
+	if chain != nil {
+		if head := chain.Head(); head != nil {
+			hs.Height = head.Height
+			hs.HeadHash = head.Hash
+		}
+	}
+	if key != nil {
+		hs.NodeID = key.ID()
+		hs.PubKey = key.PublicKeyHex()
+		hs.Signature = key.Sign(hs.signingBytes())
+	}
+	return hs
+}
+
+// probeHandshake fetches a peer's handshake and checks its signature, its
+// freshness and that it belongs to the same chain as this node. When
+// expectNodeID is set the peer must also present that identity.
+func probeHandshake(ctx context.Context, client *http.Client, base, expectNodeID string) (Handshake, error) {
+	var hs Handshake
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/api/p2p/handshake", nil)
+	if err != nil {
+		return hs, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return hs, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return hs, fmt.Errorf("handshake status: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&hs); err != nil {
+		return hs, fmt.Errorf("handshake decode: %w", err)
+	}
+	if hs.NodeID == "" || hs.ChainID == "" {
+		return hs, errors.New("handshake missing node_id or chain_id")
+	}
+	if err := identity.VerifyNodeSignature(hs.NodeID, hs.PubKey, hs.signingBytes(), hs.Signature); err != nil {
+		return hs, fmt.Errorf("handshake: %w", err)
+	}
+	if skew := time.Since(hs.Time); skew > maxHandshakeSkew || skew < -maxHandshakeSkew {
+		return hs, errors.New("handshake timestamp outside allowed skew")
+	}
+	if expectNodeID != "" && hs.NodeID != expectNodeID {
+		return hs, fmt.Errorf("node_id changed: got %s, want %s", hs.NodeID, expectNodeID)
+	}
+	if _, chainID := localInfo(); chainID != "" && hs.ChainID != chainID {
+		return hs, fmt.Errorf("%w: got %q, want %q", errChainIDMismatch, hs.ChainID, chainID)
+	}
+	return hs, nil
+}
+
+// RegisterRequest is the signed body a peer POSTs to a seed's
+// /api/p2p/register. The seed verifies the signature, then probes addr and
+// requires the handshake there to present the same node ID.
+type RegisterRequest struct {
+	Addr      string    `json:"addr"`
+	NodeID    string    `json:"node_id"`
+	PubKey    string    `json:"pubkey"`
+	ChainID   string    `json:"chain_id"`
+	Time      time.Time `json:"time"`
+	Signature string    `json:"signature"`
+}
+
+func (rr RegisterRequest) signingBytes() []byte {
+	return []byte(fmt.Sprintf("reservechain-p2p-register|%s|%s|%s|%d",
+		rr.Addr, rr.NodeID, rr.ChainID, rr.Time.Unix()))
+}
+
+// newRegisterRequest builds a registration for addr signed by the local key.
+func newRegisterRequest(addr string) (RegisterRequest, error) {
+	key, chainID := localInfo()
+	if key == nil {
+		return RegisterRequest{}, errors.New("node key not configured")
+	}
+	rr := RegisterRequest{
+		Addr:    addr,
+		NodeID:  key.ID(),
+		PubKey:  key.PublicKeyHex(),
+		ChainID: chainID,
+		Time:    time.Now().UTC(),
+	}
+	rr.Signature = key.Sign(rr.signingBytes())
+	return rr, nil
+}
+
+// Verify checks the registration signature and timestamp.
+func (rr RegisterRequest) Verify() error {
+	if err := identity.VerifyNodeSignature(rr.NodeID, rr.PubKey, rr.signingBytes(), rr.Signature); err != nil {
+		return err
+	}
+	if skew := time.Since(rr.Time); skew > maxHandshakeSkew || skew < -maxHandshakeSkew {
+		return errors.New("registration timestamp outside allowed skew")
+	}
+	if _, chainID := localInfo(); chainID != "" && rr.ChainID != chainID {
+		return fmt.Errorf("%w: got %q, want %q", errChainIDMismatch, rr.ChainID, chainID)
+	}
+	return nil
+}
+
+// SeedRegistry tracks peers that registered with this seed. Peers are
+// persisted in p2p_peers when a DB is attached; the in-memory map only holds
+// addresses that passed a handshake within the TTL and are safe to advertise.
+type SeedRegistry struct {
+	mu     sync.Mutex
+	peers  map[string]time.Time
+	owners map[string]string // registered address -> node ID
+	ttl    time.Duration
+	db     *store.DB
+	client *http.Client
+}
+
+func newSeedRegistry(ttl time.Duration) *SeedRegistry {
+	return &SeedRegistry{
+		peers:  make(map[string]time.Time),
+		owners: make(map[string]string),
+		ttl:    ttl,
+		client: &http.Client{Timeout: 3 * time.Second},
+	}
+}
+
+// AttachDB wires the registry to SQLite and restores recently live peers.
+func (r *SeedRegistry) AttachDB(db *store.DB) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.db = db
+	if db == nil {
+		return
+	}
+	recs, err := db.ListPeers(context.Background(), store.PeerSourceRegistered, "", 1000)
+	if err != nil {
+		p2pLog.Error("load persisted peers failed", logging.Err(err))
+		return
+	}
+	for _, rec := range recs {
+		if rec.NodeID != "" {
+			r.owners[rec.Addr] = rec.NodeID
+		}
+		if rec.Status == store.PeerStatusLive && rec.LastSeen != nil {
+			r.peers[rec.Addr] = *rec.LastSeen
+		}
+	}
+}
+
+// Register probes the address of a verified registration and records it
+// only once the handshake there presents the registering node ID, so
+// addresses that never answered are neither persisted nor re-probed. Each
+// node ID may hold at most maxAddrsPerNode addresses.
+func (r *SeedRegistry) Register(ctx context.Context, rr RegisterRequest) (Handshake, error) {
+	r.mu.Lock()
+	owned := 0
+	for addr, id := range r.owners {
+		if id == rr.NodeID && addr != rr.Addr {
+			owned++
+		}
+	}
+	r.mu.Unlock()
+	if owned >= maxAddrsPerNode {
+		return Handshake{}, errTooManyAddrs
+	}
+
+	hs, err := probeHandshake(ctx, r.client, rr.Addr, rr.NodeID)
+	if err != nil {
+		return hs, err
+	}
+	if err := r.db.UpsertPeer(ctx, rr.Addr, store.PeerSourceRegistered); err != nil {
+		p2pLog.ErrorContext(ctx, "persist peer failed", "peer", rr.Addr, logging.Err(err))
+	}
+	r.markLive(ctx, rr.Addr, hs, time.Now().UTC())
+	return hs, nil
+}
+
+func (r *SeedRegistry) probe(ctx context.Context, addr, expectNodeID string) (Handshake, error) {
+	now := time.Now().UTC()
+	hs, err := probeHandshake(ctx, r.client, addr, expectNodeID)
+	if err != nil {
+		r.mu.Lock()
+		delete(r.peers, addr)
+		r.mu.Unlock()
+		if dbErr := r.db.MarkPeerFailed(ctx, addr, err.Error(), now); dbErr != nil {
+			p2pLog.ErrorContext(ctx, "mark peer failed", "peer", addr, logging.Err(dbErr))
+		}
+		return hs, err
+	}
+	r.markLive(ctx, addr, hs, now)
+	return hs, nil
+}
+
+func (r *SeedRegistry) markLive(ctx context.Context, addr string, hs Handshake, now time.Time) {
+	r.mu.Lock()
+	r.peers[addr] = now
+	r.owners[addr] = hs.NodeID
+	r.mu.Unlock()
+	if dbErr := r.db.MarkPeerLive(ctx, addr, hs.NodeID, hs.PubKey, hs.Version, hs.ChainID, now); dbErr != nil {
+		p2pLog.ErrorContext(ctx, "mark peer live failed", "peer", addr, logging.Err(dbErr))
+	}
+}
+
+// List returns peers that passed a handshake within the TTL.
+func (r *SeedRegistry) List() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	for addr, ts := range r.peers {
+		if now.Sub(ts) > r.ttl {
+			delete(r.peers, addr)
+		}
+	}
+
+	out := make([]string, 0, len(r.peers))
+	for addr := range r.peers {
+		out = append(out, addr)
+	}
+	return out
+}
+
+// RunProber periodically re-probes every registered peer and prunes entries
+// that have been unreachable for a day, until ctx is done.
+func (r *SeedRegistry) RunProber(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for addr, nodeID := range r.knownPeers(ctx) {
+			if _, err := r.probe(ctx, addr, nodeID); err != nil {
+				p2pLog.DebugContext(ctx, "probe failed", "peer", addr, logging.Err(err))
+			}
+		}
+		if n, err := r.db.PrunePeers(ctx, time.Now().Add(-24*time.Hour), 5); err != nil {
+			p2pLog.ErrorContext(ctx, "prune peers failed", logging.Err(err))
+		} else if n > 0 {
+			p2pLog.InfoContext(ctx, "pruned stale peers", "count", n)
+			r.forgetPruned(ctx)
+		}
+	}
+}
+
+// knownPeers maps every registered address to the node ID it is pinned to
+// (empty when the identity is not known yet).
+func (r *SeedRegistry) knownPeers(ctx context.Context) map[string]string {
+	r.mu.Lock()
+	db := r.db
+	out := make(map[string]string, len(r.peers))
+	for addr := range r.peers {
+		out[addr] = ""
+	}
+	r.mu.Unlock()
+
+	if db == nil {
+		return out
+	}
+	recs, err := db.ListPeers(ctx, store.PeerSourceRegistered, "", 1000)
+	if err != nil {
+		p2pLog.ErrorContext(ctx, "list peers failed", logging.Err(err))
+		return out
+	}
+	for _, rec := range recs {
+		out[rec.Addr] = rec.NodeID
+	}
+	return out
+}
+
+// forgetPruned drops registrations that are no longer in p2p_peers so
+// they stop counting towards their node's address limit.
+func (r *SeedRegistry) forgetPruned(ctx context.Context) {
+	recs, err := r.db.ListPeers(ctx, store.PeerSourceRegistered, "", 1000)
+	if err != nil {
+		p2pLog.ErrorContext(ctx, "list peers failed", logging.Err(err))
+		return
+	}
+	kept := make(map[string]bool, len(recs))
+	for _, rec := range recs {
+		kept[rec.Addr] = true
+	}
+	r.mu.Lock()
+	for addr := range r.owners {
+		if !kept[addr] {
+			delete(r.owners, addr)
+		}
+	}
+	r.mu.Unlock()
+}
+
+// RunSeedProber runs the liveness prober for this node's seed registry
+// until ctx is done.
+func RunSeedProber(ctx context.Context, interval time.Duration) {
+	seedRegistry.RunProber(ctx, interval)
+}
+
+// isLoopbackRequest reports whether r arrived directly from this host.
+func isLoopbackRequest(r *http.Request) bool {
+	host, _, err := stdnet.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := stdnet.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
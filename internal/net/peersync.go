@@ -4,12 +4,12 @@ package net
 import (
     "context"
     "encoding/json"
-    "log"
     "net/http"
     "strconv"
     "time"
 
     "reservechain/internal/core"
+    "reservechain/internal/logging"
     "reservechain/internal/store"
 )
 
@@ -35,23 +35,48 @@ func NewPeerSync(chain *core.Chain, db *store.DB, peers []string, poll time.Dura
     }
 }
 
-func (p *PeerSync) Run() {
+// Run syncs from every peer each PollInterval until ctx is done.
+func (p *PeerSync) Run(ctx context.Context) {
     ticker := time.NewTicker(p.PollInterval)
     defer ticker.Stop()
 
-    for range ticker.C {
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
         for _, base := range p.Peers {
-            if base == "" {
+            if base == "" || ctx.Err() != nil {
                 continue
             }
-            if err := p.syncPeer(context.Background(), base); err != nil {
-                log.Printf("[peersync] sync from %s error: %v", base, err)
+            if err := p.syncPeer(ctx, base); err != nil {
+                syncLog.WarnContext(ctx, "peer sync failed", "peer", base, logging.Err(err))
             }
         }
     }
 }
 
 func (p *PeerSync) syncPeer(ctx context.Context, baseURL string) error {
+    // Handshake first so liveness, version and chain ID are tracked per peer
+    // and peers on a different chain are never synced from. Once a peer's
+    // node ID is known it is pinned; a different key at the same URL fails.
+    now := time.Now().UTC()
+    var pinned string
+    if rec, err := p.DB.GetPeer(ctx, baseURL); err == nil {
+        pinned = rec.NodeID
+    }
+    hs, err := probeHandshake(ctx, p.Client, baseURL, pinned)
+    if err != nil {
+        if dbErr := p.DB.MarkPeerFailed(ctx, baseURL, err.Error(), now); dbErr != nil {
+            syncLog.ErrorContext(ctx, "mark peer failed", "peer", baseURL, logging.Err(dbErr))
+        }
+        return err
+    }
+    if err := p.DB.MarkPeerLive(ctx, baseURL, hs.NodeID, hs.PubKey, hs.Version, hs.ChainID, now); err != nil {
+        syncLog.ErrorContext(ctx, "mark peer live failed", "peer", baseURL, logging.Err(err))
+    }
+
     // Determine local height.
     head := p.Chain.Head()
     var localHeight uint64
@@ -78,6 +103,7 @@ func (p *PeerSync) syncPeer(ctx context.Context, baseURL string) error {
         return nil
     }
     if headPayload.Head.Height <= localHeight {
+        recordSyncLag(baseURL, 0)
         return nil
     }
 
@@ -108,10 +134,14 @@ func (p *PeerSync) syncPeer(ctx context.Context, baseURL string) error {
         }
 
         for _, blk := range blkPayload.Blocks {
+            if err := verifySyncedBlock(p.Chain.Head(), blk); err != nil {
+                return err
+            }
+
             // Persist to local DB if available.
             if p.DB != nil {
-                if err := p.DB.InsertBlockAndTx(ctx, blk.Hash, blk.PrevHash, blk.Height, blk.TxType, blk.Nonce, blk.Difficulty, blk.Tx); err != nil {
-                    log.Printf("[peersync] insert block %d failed: %v", blk.Height, err)
+                if err := p.DB.InsertBlockAndTxAt(ctx, blk.Timestamp, blk.Hash, blk.PrevHash, blk.Height, blk.TxType, blk.Nonce, blk.Difficulty, blk.StateRoot, blk.Tx); err != nil {
+                    syncLog.ErrorContext(ctx, "insert block failed", "peer", baseURL, "height", blk.Height, logging.Err(err))
                 }
             }
 
@@ -128,20 +158,20 @@ func (p *PeerSync) syncPeer(ctx context.Context, baseURL string) error {
                         Timestamp: blk.Timestamp,
                     }
                     if err := p.Chain.ReplayFromTxRows([]store.ChainTxRow{row}); err != nil {
-                        log.Printf("[peersync] replay block %d failed: %v", blk.Height, err)
+                        syncLog.ErrorContext(ctx, "replay block failed", "peer", baseURL, "height", blk.Height, logging.Err(err))
                     }
                 }
             }
 
-            p.Chain.AppendRemoteBlock(blk)
+            p.Chain.AppendRemoteBlock(ctx, blk)
             next = blk.Height + 1
         }
+        recordSyncLag(baseURL, lagBehind(headPayload.Head.Height, p.Chain.Head()))
 
         if blkPayload.NextFromHeight <= next {
             break
         }
         next = blkPayload.NextFromHeight
     }
-
     return nil
 }
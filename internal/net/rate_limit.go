@@ -0,0 +1,348 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"reservechain/internal/logging"
+)
+
+// Route classes for rate limiting. Each class has its own token bucket
+// per caller.
+const (
+	RateClassAuth  = "auth"  // login challenges, wallet login, refresh
+	RateClassWrite = "write" // any non-GET API call
+	RateClassRead  = "read"  // GET API calls and stream connects
+	RateClassSim   = "sim"   // /api/sim, a full multi-epoch simulation per call
+	RateClassRPC   = "rpc"   // JSON-RPC calls, WebSocket messages and connects
+	RateClassPeer  = "peer"  // node-to-node sync, heartbeats and ticks
+)
+
+// RateLimitClass is a token bucket: Rate tokens per second, up to Burst.
+type RateLimitClass struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitOptions configures SetRateLimits. Classes missing from Classes
+// keep their defaults. TierMultipliers scale both rate and burst for
+// callers whose wallet has an active tier in user_tiers.
+type RateLimitOptions struct {
+	Classes         map[string]RateLimitClass
+	TierMultipliers map[string]float64
+}
+
+var defaultRateLimitClasses = map[string]RateLimitClass{
+	RateClassAuth:  {Rate: 0.2, Burst: 5},
+	RateClassWrite: {Rate: 2, Burst: 10},
+	RateClassRead:  {Rate: 20, Burst: 50},
+	RateClassSim:   {Rate: 0.05, Burst: 2},
+	RateClassRPC:   {Rate: 10, Burst: 40},
+	RateClassPeer:  {Rate: 50, Burst: 200},
+}
+
+var defaultTierMultipliers = map[string]float64{
+	"core":      1,
+	"elite":     2,
+	"executive": 4,
+	"express":   8,
+}
+
+const (
+	// tierCacheTTL bounds how long a looked-up tier is reused.
+	tierCacheTTL = time.Minute
+	// bucketIdleTTL drops buckets that have been full and unused this long.
+	bucketIdleTTL = 10 * time.Minute
+)
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type tierCacheEntry struct {
+	tier    string
+	fetched time.Time
+}
+
+// rateLimiter holds token buckets keyed by class and caller. Callers are
+// identified by API key, else by session identity, else by IP; only the
+// first two are scaled by tier.
+type rateLimiter struct {
+	classes map[string]RateLimitClass
+	tiers   map[string]float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	tierMu  sync.Mutex
+	tierOf  map[string]tierCacheEntry
+
+	allowed   map[string]*atomic.Uint64
+	throttled map[string]*atomic.Uint64
+}
+
+var currentRateLimiter atomic.Pointer[rateLimiter]
+
+// SetRateLimits enables rate limiting with opts merged over the defaults.
+// Pass nil to disable it.
+func SetRateLimits(opts *RateLimitOptions) {
+	if opts == nil {
+		currentRateLimiter.Store(nil)
+		return
+	}
+	rl := &rateLimiter{
+		classes:   make(map[string]RateLimitClass),
+		tiers:     make(map[string]float64),
+		buckets:   make(map[string]*tokenBucket),
+		tierOf:    make(map[string]tierCacheEntry),
+		allowed:   make(map[string]*atomic.Uint64),
+		throttled: make(map[string]*atomic.Uint64),
+	}
+	for name, c := range defaultRateLimitClasses {
+		if o, ok := opts.Classes[name]; ok && o.Rate > 0 && o.Burst > 0 {
+			c = o
+		}
+		rl.classes[name] = c
+		rl.allowed[name] = new(atomic.Uint64)
+		rl.throttled[name] = new(atomic.Uint64)
+	}
+	for name := range opts.Classes {
+		if _, ok := defaultRateLimitClasses[name]; !ok {
+			limitLog.Warn("unknown route class ignored", "class", name)
+		}
+	}
+	for tier, m := range defaultTierMultipliers {
+		rl.tiers[tier] = m
+	}
+	for tier, m := range opts.TierMultipliers {
+		if m > 0 {
+			rl.tiers[tier] = m
+		}
+	}
+	currentRateLimiter.Store(rl)
+}
+
+// rateClassFor maps a request to its route class, or "" for routes that
+// are not limited (static assets, pages).
+func rateClassFor(r *http.Request) string {
+	p := r.URL.Path
+	if rest, ok := strings.CutPrefix(p, v1Prefix+"/"); ok {
+		p = "/api/" + rest
+	}
+	switch {
+	case p == "/api/auth/nonce", p == "/api/auth/wallet-login", p == "/api/auth/refresh":
+		return RateClassAuth
+	case p == "/api/sim", p == "/api/tx/simulate":
+		return RateClassSim
+	case p == "/rpc":
+		return RateClassRPC
+	case strings.HasPrefix(p, "/api/p2p/"), strings.HasPrefix(p, "/api/snapshot/"),
+		p == "/api/econ/heartbeat", p == "/api/econ/tick":
+		return RateClassPeer
+	case strings.HasPrefix(p, "/api/"), strings.HasPrefix(p, "/econ/"), strings.HasPrefix(p, "/ws"):
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			return RateClassRead
+		}
+		return RateClassWrite
+	}
+	return ""
+}
+
+// take spends one token from key's bucket in class. When it is empty it
+// returns false and how long until a token is available.
+func (rl *rateLimiter) take(class, key string, mult float64, now time.Time) (bool, time.Duration) {
+	c := rl.classes[class]
+	rate := c.Rate * mult
+	burst := float64(c.Burst) * mult
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	b, ok := rl.buckets[class+"|"+key]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		rl.buckets[class+"|"+key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// sweep drops buckets idle long enough to have refilled.
+func (rl *rateLimiter) sweep(now time.Time) {
+	rl.mu.Lock()
+	for k, b := range rl.buckets {
+		if now.Sub(b.last) > bucketIdleTTL {
+			delete(rl.buckets, k)
+		}
+	}
+	rl.mu.Unlock()
+
+	rl.tierMu.Lock()
+	for id, e := range rl.tierOf {
+		if now.Sub(e.fetched) > tierCacheTTL {
+			delete(rl.tierOf, id)
+		}
+	}
+	rl.tierMu.Unlock()
+}
+
+// tierMultiplier returns the limit multiplier for a session identity.
+func (api *HTTPAPI) tierMultiplier(ctx context.Context, rl *rateLimiter, identity string) float64 {
+	now := time.Now()
+	rl.tierMu.Lock()
+	e, ok := rl.tierOf[identity]
+	rl.tierMu.Unlock()
+	if !ok || now.Sub(e.fetched) > tierCacheTTL {
+		addr := identity
+		if i := strings.IndexByte(addr, ':'); i >= 0 {
+			addr = addr[i+1:]
+		}
+		tier, err := api.DB.ActiveTierForWallet(ctx, addr)
+		if err != nil {
+			limitLog.ErrorContext(ctx, "tier lookup failed", "identity", identity, logging.Err(err))
+		}
+		e = tierCacheEntry{tier: tier, fetched: now}
+		rl.tierMu.Lock()
+		rl.tierOf[identity] = e
+		rl.tierMu.Unlock()
+	}
+	if m, ok := rl.tiers[e.tier]; ok {
+		return m
+	}
+	return 1
+}
+
+// rateLimit wraps the node's mux with the configured limiter. Throttled
+// requests get 429 with Retry-After and are counted per class.
+func (api *HTTPAPI) rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rl := currentRateLimiter.Load()
+		class := ""
+		if rl != nil {
+			class = rateClassFor(r)
+		}
+		if class == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		s, _ := api.sessionFromRequest(r)
+		ok, wait := api.takeToken(r.Context(), rl, class, s, remoteHost(r))
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeAPIError(w, http.StatusTooManyRequests, "rate_limited", "too many "+class+" requests; retry later")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// takeToken spends one token in class for the caller of sess (zero when
+// anonymous, which falls back to host) and counts the outcome.
+func (api *HTTPAPI) takeToken(ctx context.Context, rl *rateLimiter, class string, sess sessionEntry, host string) (bool, time.Duration) {
+	key, mult := "ip:"+host, 1.0
+	if sess.Address != "" {
+		if sess.APIKeyID != "" {
+			key = "key:" + sess.APIKeyID
+		} else {
+			key = "id:" + sess.Address
+		}
+		mult = api.tierMultiplier(ctx, rl, sess.Address)
+	}
+	ok, wait := rl.take(class, key, mult, time.Now())
+	if ok {
+		rl.allowed[class].Add(1)
+	} else {
+		rl.throttled[class].Add(1)
+	}
+	return ok, wait
+}
+
+// takeRPCToken charges one RPC token for a JSON-RPC call made outside the
+// HTTP middleware: further calls of a batch and WebSocket messages.
+func (api *HTTPAPI) takeRPCToken(s *rpcSession) bool {
+	rl := currentRateLimiter.Load()
+	if rl == nil {
+		return true
+	}
+	ok, _ := api.takeToken(s.ctx, rl, RateClassRPC, s.sess, s.host)
+	return ok
+}
+
+// runRateLimitSweeper periodically drops idle buckets and stale tiers
+// until ctx is done.
+func runRateLimitSweeper(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		var now time.Time
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-t.C:
+		}
+		if rl := currentRateLimiter.Load(); rl != nil {
+			rl.sweep(now)
+		}
+	}
+}
+
+// RateLimitStat is one route class's limits and request counts.
+type RateLimitStat struct {
+	Class     string  `json:"class"`
+	Rate      float64 `json:"rate"`
+	Burst     int     `json:"burst"`
+	Allowed   uint64  `json:"allowed"`
+	Throttled uint64  `json:"throttled"`
+}
+
+// RateLimitStats reports per-class counters, or nil when limiting is off.
+// They are also served to admins on /api/admin/rate-limits.
+func RateLimitStats() []RateLimitStat {
+	rl := currentRateLimiter.Load()
+	if rl == nil {
+		return nil
+	}
+	out := make([]RateLimitStat, 0, len(rl.classes))
+	for name, c := range rl.classes {
+		out = append(out, RateLimitStat{
+			Class:     name,
+			Rate:      c.Rate,
+			Burst:     c.Burst,
+			Allowed:   rl.allowed[name].Load(),
+			Throttled: rl.throttled[name].Load(),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Class < out[j].Class })
+	return out
+}
+
+// adminRateLimitsHandler serves GET /api/admin/rate-limits (admin only).
+func (api *HTTPAPI) adminRateLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	out := map[string]any{"enabled": false}
+	if rl := currentRateLimiter.Load(); rl != nil {
+		out = map[string]any{
+			"enabled":          true,
+			"classes":          RateLimitStats(),
+			"tier_multipliers": rl.tiers,
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
@@ -0,0 +1,147 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(classes map[string]RateLimitClass) *rateLimiter {
+	SetRateLimits(&RateLimitOptions{Classes: classes})
+	rl := currentRateLimiter.Load()
+	SetRateLimits(nil)
+	return rl
+}
+
+func TestTokenBucketRefill(t *testing.T) {
+	rl := newTestLimiter(map[string]RateLimitClass{RateClassWrite: {Rate: 2, Burst: 3}})
+	t0 := time.Unix(1_700_000_000, 0)
+	steps := []struct {
+		at       time.Duration
+		ok       bool
+		wantWait time.Duration
+	}{
+		{0, true, 0},
+		{0, true, 0},
+		{0, true, 0},
+		{0, false, 500 * time.Millisecond},
+		{250 * time.Millisecond, false, 250 * time.Millisecond},
+		{500 * time.Millisecond, true, 0},
+		{500 * time.Millisecond, false, 500 * time.Millisecond},
+		// A long pause refills to the burst, never beyond it.
+		{time.Hour, true, 0},
+		{time.Hour, true, 0},
+		{time.Hour, true, 0},
+		{time.Hour, false, 500 * time.Millisecond},
+	}
+	for i, s := range steps {
+		ok, wait := rl.take(RateClassWrite, "ip:1.2.3.4", 1, t0.Add(s.at))
+		if ok != s.ok {
+			t.Fatalf("step %d at +%s: ok = %v, want %v", i, s.at, ok, s.ok)
+		}
+		if d := wait - s.wantWait; d < -time.Millisecond || d > time.Millisecond {
+			t.Errorf("step %d at +%s: wait %s, want %s", i, s.at, wait, s.wantWait)
+		}
+	}
+
+	// Buckets are per caller and per class.
+	if ok, _ := rl.take(RateClassWrite, "ip:5.6.7.8", 1, t0); !ok {
+		t.Error("another caller shares the drained bucket")
+	}
+	if ok, _ := rl.take(RateClassRead, "ip:1.2.3.4", 1, t0); !ok {
+		t.Error("another class shares the drained bucket")
+	}
+}
+
+func TestTierMultiplierScalesBucket(t *testing.T) {
+	rl := newTestLimiter(map[string]RateLimitClass{RateClassWrite: {Rate: 1, Burst: 2}})
+	t0 := time.Unix(1_700_000_000, 0)
+	tests := []struct {
+		mult      float64
+		allowed   int
+		refillFor time.Duration // time to earn the next token once drained
+	}{
+		{1, 2, time.Second},
+		{2, 4, 500 * time.Millisecond},
+		{8, 16, 125 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		key := "id:rc:tier"
+		rl.buckets = make(map[string]*tokenBucket)
+		n := 0
+		var wait time.Duration
+		for {
+			ok, w := rl.take(RateClassWrite, key, tt.mult, t0)
+			if !ok {
+				wait = w
+				break
+			}
+			n++
+		}
+		if n != tt.allowed {
+			t.Errorf("mult %v: %d requests allowed, want %d", tt.mult, n, tt.allowed)
+		}
+		if d := wait - tt.refillFor; d < -time.Millisecond || d > time.Millisecond {
+			t.Errorf("mult %v: wait %s, want %s", tt.mult, wait, tt.refillFor)
+		}
+	}
+}
+
+func TestTierMultiplierLookup(t *testing.T) {
+	SetRateLimits(&RateLimitOptions{TierMultipliers: map[string]float64{"elite": 3, "express": 0}})
+	rl := currentRateLimiter.Load()
+	SetRateLimits(nil)
+	api := &HTTPAPI{}
+	now := time.Now()
+	tests := []struct {
+		tier string
+		want float64
+	}{
+		{"", 1},
+		{"core", 1},
+		{"elite", 3},     // overridden
+		{"executive", 4}, // default
+		{"express", 8},   // a non-positive override keeps the default
+		{"unknown", 1},
+	}
+	for _, tt := range tests {
+		id := "wallet:rc:" + tt.tier
+		rl.tierOf[id] = tierCacheEntry{tier: tt.tier, fetched: now}
+		if got := api.tierMultiplier(context.Background(), rl, id); got != tt.want {
+			t.Errorf("tier %q: multiplier %v, want %v", tt.tier, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitRetryAfter(t *testing.T) {
+	SetRateLimits(&RateLimitOptions{Classes: map[string]RateLimitClass{RateClassRead: {Rate: 0.25, Burst: 1}}})
+	t.Cleanup(func() { SetRateLimits(nil) })
+	api := NewHTTPAPI(NewWSHub(), nil, nil, nil, nil, nil)
+	h := api.rateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		path, remote string
+		wantCode     int
+		retryAfter   string
+	}{
+		{"/api/econ/state", "10.0.0.1:1000", http.StatusOK, ""},
+		{"/api/econ/state", "10.0.0.1:1001", http.StatusTooManyRequests, "4"},
+		{"/api/v1/econ/state", "10.0.0.1:1002", http.StatusTooManyRequests, "4"},
+		{"/api/econ/state", "10.0.0.2:1000", http.StatusOK, ""},
+		{"/index.html", "10.0.0.1:1003", http.StatusOK, ""}, // not a limited route
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.RemoteAddr = tt.remote
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.wantCode {
+			t.Errorf("GET %s from %s: status %d, want %d", tt.path, tt.remote, rec.Code, tt.wantCode)
+		}
+		if got := rec.Header().Get("Retry-After"); got != tt.retryAfter {
+			t.Errorf("GET %s from %s: Retry-After %q, want %q", tt.path, tt.remote, got, tt.retryAfter)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package net
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"reservechain/internal/core"
+	"reservechain/internal/logging"
+	"reservechain/internal/store"
+)
+
+// snapshotChunkSize is the size of each snapshot chunk served to peers.
+const snapshotChunkSize = 256 << 10
+
+// SnapshotManifest describes a stored state snapshot and the sha256 of
+// each chunk so a downloader can verify chunks as they arrive.
+type SnapshotManifest struct {
+	Height    uint64   `json:"height"`
+	BlockHash string   `json:"block_hash"`
+	StateRoot string   `json:"state_root"`
+	Size      int      `json:"size"`
+	ChunkSize int      `json:"chunk_size"`
+	Chunks    []string `json:"chunks"`
+}
+
+func manifestFor(row store.StateSnapshotRow) SnapshotManifest {
+	m := SnapshotManifest{
+		Height:    row.Height,
+		BlockHash: row.BlockHash,
+		StateRoot: row.StateRoot,
+		Size:      len(row.Data),
+		ChunkSize: snapshotChunkSize,
+	}
+	for off := 0; off < len(row.Data); off += snapshotChunkSize {
+		end := off + snapshotChunkSize
+		if end > len(row.Data) {
+			end = len(row.Data)
+		}
+		sum := sha256.Sum256(row.Data[off:end])
+		m.Chunks = append(m.Chunks, hex.EncodeToString(sum[:]))
+	}
+	return m
+}
+
+// loadSnapshotRow returns the snapshot named by ?height=, or the latest.
+func (api *HTTPAPI) loadSnapshotRow(r *http.Request) (store.StateSnapshotRow, error) {
+	if h := r.URL.Query().Get("height"); h != "" {
+		height, err := strconv.ParseUint(h, 10, 64)
+		if err != nil {
+			return store.StateSnapshotRow{}, err
+		}
+		return api.DB.GetStateSnapshot(r.Context(), height)
+	}
+	return api.DB.LatestStateSnapshot(r.Context())
+}
+
+// snapshotManifestHandler serves the manifest of the latest snapshot, or
+// of the one at ?height=.
+func (api *HTTPAPI) snapshotManifestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	row, err := api.loadSnapshotRow(r)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "no snapshot", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "invalid snapshot request", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(manifestFor(row))
+}
+
+// snapshotChunkHandler serves chunk ?index= of the snapshot at ?height=.
+func (api *HTTPAPI) snapshotChunkHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if r.URL.Query().Get("height") == "" {
+		http.Error(w, "missing height", http.StatusBadRequest)
+		return
+	}
+	idx, err := strconv.Atoi(r.URL.Query().Get("index"))
+	if err != nil || idx < 0 {
+		http.Error(w, "invalid index", http.StatusBadRequest)
+		return
+	}
+	row, err := api.loadSnapshotRow(r)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "no snapshot", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "invalid height", http.StatusBadRequest)
+		return
+	}
+	off := idx * snapshotChunkSize
+	if off >= len(row.Data) {
+		http.Error(w, "chunk out of range", http.StatusNotFound)
+		return
+	}
+	end := off + snapshotChunkSize
+	if end > len(row.Data) {
+		end = len(row.Data)
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(row.Data[off:end])
+}
+
+// SnapshotSyncOptions decides which peer snapshot SyncFromSnapshot may
+// restore. With a checkpoint set only the snapshot at that block is
+// accepted; otherwise its anchor block needs Quorum agreeing peers.
+type SnapshotSyncOptions struct {
+	Quorum           int // peers that must report the anchor block (default 2)
+	MaxSize          int // largest snapshot accepted in bytes (default 256 MiB)
+	CheckpointHeight uint64
+	CheckpointHash   string
+}
+
+func (o SnapshotSyncOptions) withDefaults() SnapshotSyncOptions {
+	if o.Quorum <= 0 {
+		o.Quorum = 2
+	}
+	if o.MaxSize <= 0 {
+		o.MaxSize = 256 << 20
+	}
+	return o
+}
+
+// SyncFromSnapshot bootstraps chain from the newest confirmed snapshot
+// offered by peers. The snapshot's anchor block must carry valid PoW,
+// commit to the snapshot's state root, and be either the configured
+// checkpoint or reported by a quorum and a majority of the peers that
+// answered; peers without a block at that height count against it. Each
+// chunk is checked against the manifest before the state is restored.
+// Afterwards normal block sync continues from the height after the
+// snapshot.
+func SyncFromSnapshot(chain *core.Chain, peers []string, opts SnapshotSyncOptions) error {
+	opts = opts.withDefaults()
+	client := &http.Client{Timeout: 15 * time.Second}
+
+	manifestURL := "/api/snapshot/manifest"
+	if opts.CheckpointHeight > 0 {
+		manifestURL += "?height=" + strconv.FormatUint(opts.CheckpointHeight, 10)
+	}
+	type offer struct {
+		m       SnapshotManifest
+		sources []string
+	}
+	offers := map[string]*offer{}
+	var reachable []string
+	for _, base := range peers {
+		var m SnapshotManifest
+		if err := getJSON(client, base+manifestURL, &m); err != nil {
+			snapshotLog.Warn("manifest fetch failed", "peer", base, logging.Err(err))
+			continue
+		}
+		reachable = append(reachable, base)
+		if m.Height == 0 {
+			continue
+		}
+		if err := checkManifest(m, opts.MaxSize); err != nil {
+			snapshotLog.Warn("manifest rejected", "peer", base, logging.Err(err))
+			continue
+		}
+		key := fmt.Sprintf("%d/%s/%s", m.Height, m.BlockHash, m.StateRoot)
+		if o, ok := offers[key]; ok {
+			o.sources = append(o.sources, base)
+		} else {
+			offers[key] = &offer{m: m, sources: []string{base}}
+		}
+	}
+	candidates := make([]*offer, 0, len(offers))
+	for _, o := range offers {
+		if opts.CheckpointHeight > 0 && (o.m.Height != opts.CheckpointHeight || o.m.BlockHash != opts.CheckpointHash) {
+			continue
+		}
+		candidates = append(candidates, o)
+	}
+	if len(candidates) == 0 {
+		return errors.New("no peer offers an acceptable snapshot")
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].m.Height != candidates[j].m.Height {
+			return candidates[i].m.Height > candidates[j].m.Height
+		}
+		return len(candidates[i].sources) > len(candidates[j].sources)
+	})
+
+	// Try the newest snapshot first; an unconfirmed one falls back to an
+	// older offer rather than failing the bootstrap.
+	var best SnapshotManifest
+	var sources []string
+	var anchor *core.Block
+	for _, c := range candidates {
+		blk, err := fetchAnchorBlock(client, reachable, c.m, opts)
+		if err != nil {
+			snapshotLog.Warn("snapshot not confirmed", "height", c.m.Height, logging.Err(err))
+			continue
+		}
+		best, sources, anchor = c.m, c.sources, blk
+		break
+	}
+	if anchor == nil {
+		return errors.New("no snapshot offered by peers could be confirmed")
+	}
+
+	data := make([]byte, 0, best.Size)
+	for i, want := range best.Chunks {
+		chunk, err := fetchChunk(client, sources, best.Height, i, want)
+		if err != nil {
+			return err
+		}
+		if len(data)+len(chunk) > best.Size {
+			return fmt.Errorf("snapshot exceeds manifest size %d", best.Size)
+		}
+		data = append(data, chunk...)
+	}
+	if len(data) != best.Size {
+		return fmt.Errorf("snapshot size %d, manifest %d", len(data), best.Size)
+	}
+
+	var snap core.StateSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+	if err := chain.RestoreSnapshot(&snap, anchor, data); err != nil {
+		return err
+	}
+	snapshotLog.Info("restored state", "height", snap.Height, "peers", len(sources))
+	return nil
+}
+
+// checkManifest rejects a peer manifest whose size is out of bounds or
+// inconsistent with its chunk list, before anything is allocated for it.
+func checkManifest(m SnapshotManifest, maxSize int) error {
+	switch {
+	case m.ChunkSize != snapshotChunkSize:
+		return fmt.Errorf("chunk size %d, want %d", m.ChunkSize, snapshotChunkSize)
+	case len(m.Chunks) == 0 || len(m.Chunks) > maxSize/snapshotChunkSize+1:
+		return fmt.Errorf("%d chunks", len(m.Chunks))
+	case m.Size <= (len(m.Chunks)-1)*snapshotChunkSize || m.Size > len(m.Chunks)*snapshotChunkSize:
+		return fmt.Errorf("size %d does not fit %d chunks", m.Size, len(m.Chunks))
+	case m.Size > maxSize:
+		return fmt.Errorf("size %d exceeds the %d byte limit", m.Size, maxSize)
+	}
+	return nil
+}
+
+// fetchAnchorBlock asks every peer for the block at the snapshot height.
+// With a checkpoint the block must be the pinned one; otherwise at least
+// opts.Quorum peers, and more than half of those asked, must return a
+// PoW-valid block matching the manifest. Peers that disagree, lack the
+// block or fail to answer all count against it.
+func fetchAnchorBlock(client *http.Client, peers []string, m SnapshotManifest, opts SnapshotSyncOptions) (*core.Block, error) {
+	var anchor *core.Block
+	agree := 0
+	url := "/api/chain/block?height=" + strconv.FormatUint(m.Height, 10)
+	for _, base := range peers {
+		var payload struct {
+			Block *core.Block `json:"block"`
+		}
+		if err := getJSON(client, base+url, &payload); err != nil || payload.Block == nil {
+			continue
+		}
+		if err := core.VerifyBlockPoW(payload.Block); err != nil {
+			snapshotLog.Warn("anchor block rejected", "peer", base, "height", m.Height, logging.Err(err))
+			continue
+		}
+		if payload.Block.Height != m.Height || payload.Block.Hash != m.BlockHash || payload.Block.StateRoot != m.StateRoot {
+			snapshotLog.Warn("peer disagrees on anchor block", "peer", base, "height", m.Height)
+			continue
+		}
+		agree++
+		if anchor == nil {
+			anchor = payload.Block
+		}
+	}
+	if anchor == nil {
+		return nil, fmt.Errorf("no verifiable block at snapshot height %d", m.Height)
+	}
+	if opts.CheckpointHeight > 0 {
+		if anchor.Height != opts.CheckpointHeight || anchor.Hash != opts.CheckpointHash {
+			return nil, fmt.Errorf("block %d is not the trusted checkpoint", m.Height)
+		}
+		return anchor, nil
+	}
+	if agree < opts.Quorum || agree*2 <= len(peers) {
+		return nil, fmt.Errorf("%d of %d peer(s) confirm block %d; need %d and a majority", agree, len(peers), m.Height, opts.Quorum)
+	}
+	return anchor, nil
+}
+
+func fetchChunk(client *http.Client, sources []string, height uint64, index int, wantHash string) ([]byte, error) {
+	for _, base := range sources {
+		url := fmt.Sprintf("%s/api/snapshot/chunk?height=%d&index=%d", base, height, index)
+		resp, err := client.Get(url)
+		if err != nil {
+			continue
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, snapshotChunkSize+1))
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			continue
+		}
+		sum := sha256.Sum256(body)
+		if hex.EncodeToString(sum[:]) != wantHash {
+			snapshotLog.Warn("chunk hash mismatch", "peer", base, "chunk", index)
+			continue
+		}
+		return bytes.Clone(body), nil
+	}
+	return nil, fmt.Errorf("chunk %d unavailable from %d peer(s)", index, len(sources))
+}
+
+func getJSON(client *http.Client, url string, out interface{}) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,118 @@
+package net
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"reservechain/internal/core"
+	"reservechain/internal/proof"
+)
+
+func TestCheckManifest(t *testing.T) {
+	chunks := func(n int) []string { return make([]string, n) }
+	const limit = 4 * snapshotChunkSize
+	tests := []struct {
+		name string
+		m    SnapshotManifest
+		ok   bool
+	}{
+		{"one partial chunk", SnapshotManifest{Size: 10, ChunkSize: snapshotChunkSize, Chunks: chunks(1)}, true},
+		{"full chunks", SnapshotManifest{Size: 2 * snapshotChunkSize, ChunkSize: snapshotChunkSize, Chunks: chunks(2)}, true},
+		{"negative size", SnapshotManifest{Size: -1, ChunkSize: snapshotChunkSize, Chunks: chunks(1)}, false},
+		{"zero size", SnapshotManifest{Size: 0, ChunkSize: snapshotChunkSize, Chunks: chunks(1)}, false},
+		{"size beyond chunks", SnapshotManifest{Size: snapshotChunkSize + 1, ChunkSize: snapshotChunkSize, Chunks: chunks(1)}, false},
+		{"spare chunk", SnapshotManifest{Size: 10, ChunkSize: snapshotChunkSize, Chunks: chunks(2)}, false},
+		{"no chunks", SnapshotManifest{Size: 10, ChunkSize: snapshotChunkSize}, false},
+		{"over limit", SnapshotManifest{Size: limit + 1, ChunkSize: snapshotChunkSize, Chunks: chunks(5)}, false},
+		{"huge size", SnapshotManifest{Size: 1 << 62, ChunkSize: snapshotChunkSize, Chunks: chunks(1)}, false},
+		{"other chunk size", SnapshotManifest{Size: 10, ChunkSize: 1024, Chunks: chunks(1)}, false},
+	}
+	for _, tt := range tests {
+		err := checkManifest(tt.m, limit)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestSnapshotSyncOptionsDefaults(t *testing.T) {
+	o := SnapshotSyncOptions{}.withDefaults()
+	if o.Quorum < 2 {
+		t.Errorf("default quorum %d lets a single peer decide the snapshot", o.Quorum)
+	}
+	if o.MaxSize <= 0 {
+		t.Errorf("default max size %d", o.MaxSize)
+	}
+}
+
+// mineTestBlock seals a block at the lowest valid difficulty.
+func mineTestBlock(height uint64, stateRoot string) *core.Block {
+	payload := json.RawMessage(`{"note":"anchor"}`)
+	blk := &core.Block{Height: height, PrevHash: "prev", TxType: "TX_TEST", Tx: payload,
+		Difficulty: proof.MinDifficulty, StateRoot: stateRoot}
+	for ; ; blk.Nonce++ {
+		blk.Hash = proof.HeaderHash(blk.Height, blk.PrevHash, blk.TxType, payload, blk.StateRoot, blk.Nonce)
+		if proof.MeetsDifficulty(blk.Hash, blk.Difficulty) {
+			return blk
+		}
+	}
+}
+
+func TestFetchAnchorBlock(t *testing.T) {
+	good := mineTestBlock(100, "root-a")
+	other := mineTestBlock(100, "root-b")
+	forged := *good
+	forged.StateRoot = "root-b" // claims good's hash without the work
+	m := SnapshotManifest{Height: 100, BlockHash: good.Hash, StateRoot: good.StateRoot}
+
+	serve := func(blk *core.Block) string {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if blk == nil {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"block": blk})
+		}))
+		t.Cleanup(srv.Close)
+		return srv.URL
+	}
+	peers := map[string]string{
+		"good":    serve(good),
+		"other":   serve(other),
+		"forged":  serve(&forged),
+		"missing": serve(nil),
+	}
+
+	tests := []struct {
+		name  string
+		peers []string
+		opts  SnapshotSyncOptions
+		ok    bool
+	}{
+		{"quorum of two", []string{"good", "good"}, SnapshotSyncOptions{Quorum: 2}, true},
+		{"single peer", []string{"good"}, SnapshotSyncOptions{Quorum: 2}, false},
+		{"majority of three", []string{"good", "good", "other"}, SnapshotSyncOptions{Quorum: 2}, true},
+		{"split vote", []string{"good", "good", "other", "other"}, SnapshotSyncOptions{Quorum: 2}, false},
+		{"missing counts against", []string{"good", "good", "missing", "missing"}, SnapshotSyncOptions{Quorum: 2}, false},
+		{"forged PoW ignored", []string{"good", "forged", "forged"}, SnapshotSyncOptions{Quorum: 2}, false},
+		{"trusted checkpoint", []string{"good"}, SnapshotSyncOptions{Quorum: 2, CheckpointHeight: 100, CheckpointHash: good.Hash}, true},
+		{"checkpoint mismatch", []string{"good", "good"}, SnapshotSyncOptions{Quorum: 2, CheckpointHeight: 100, CheckpointHash: other.Hash}, false},
+		{"no peers agree", []string{"other", "missing"}, SnapshotSyncOptions{Quorum: 1}, false},
+	}
+	for _, tt := range tests {
+		urls := make([]string, len(tt.peers))
+		for i, p := range tt.peers {
+			urls[i] = peers[p]
+		}
+		blk, err := fetchAnchorBlock(http.DefaultClient, urls, m, tt.opts)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok=%v", tt.name, err, tt.ok)
+			continue
+		}
+		if err == nil && blk.Hash != good.Hash {
+			t.Errorf("%s: anchor %s, want %s", tt.name, blk.Hash, good.Hash)
+		}
+	}
+}
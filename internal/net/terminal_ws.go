@@ -0,0 +1,448 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"reservechain/internal/core"
+	"reservechain/internal/econ"
+	"reservechain/internal/logging"
+)
+
+const (
+	// terminalFrameInterval is how often metrics, chain and margin frames
+	// are pushed to every terminal client.
+	terminalFrameInterval = time.Second
+	// terminalSendQueue bounds the frames buffered per terminal client.
+	// Frames are snapshots, so a full queue drops rather than blocks.
+	terminalSendQueue = 64
+	// terminalPing is how often clients are pinged to measure RTT.
+	terminalPing = 5 * time.Second
+)
+
+// terminalUpgrader checks the Origin like /rpc: the feed streams the
+// cookie session's fills and margin, which another site must not read.
+var terminalUpgrader = websocket.Upgrader{
+	CheckOrigin: checkOrigin,
+}
+
+// TerminalFeed drives the trading terminal channels on /ws/terminal from
+// engine state: chain height, valuation ticks, treasury reserve and the
+// balances of the session's own account. Public channels (metrics, price,
+// chain, reserve) go to every client; execution and margin frames only
+// to clients whose session owns the account they describe.
+//
+// With demo set the feed streams the synthetic DevNet generator instead.
+type TerminalFeed struct {
+	hub   *WSHub
+	chain *core.Chain
+	demo  bool
+
+	mu      sync.RWMutex
+	clients map[*terminalClient]struct{}
+	closed  bool // set once Run has returned
+	tick    *econ.ValuationTick
+	feedLag time.Duration // age of the last valuation tick when it arrived
+	reserve float64       // reserve in GRC at the last reserve event
+}
+
+type terminalClient struct {
+	conn      *websocket.Conn
+	identity  string
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+	rttMs     int64 // last ping/pong round trip, atomic
+}
+
+var (
+	terminalMu sync.RWMutex
+	activeFeed *TerminalFeed
+)
+
+// NewTerminalFeed creates a feed reading events from hub and state from
+// chain. demo selects the synthetic generator.
+func NewTerminalFeed(hub *WSHub, chain *core.Chain, demo bool) *TerminalFeed {
+	return &TerminalFeed{
+		hub:     hub,
+		chain:   chain,
+		demo:    demo,
+		clients: make(map[*terminalClient]struct{}),
+	}
+}
+
+// SetTerminalFeed makes f serve /ws/terminal.
+func SetTerminalFeed(f *TerminalFeed) {
+	terminalMu.Lock()
+	defer terminalMu.Unlock()
+	activeFeed = f
+}
+
+func currentTerminalFeed() *TerminalFeed {
+	terminalMu.RLock()
+	defer terminalMu.RUnlock()
+	return activeFeed
+}
+
+// Run produces terminal frames until ctx is done, then disconnects every
+// terminal client.
+func (f *TerminalFeed) Run(ctx context.Context) {
+	defer f.closeClients()
+	if f.demo {
+		terminalLog.Info("streaming synthetic demo data")
+		f.runDemo(ctx)
+		return
+	}
+	frames := time.NewTicker(terminalFrameInterval)
+	defer frames.Stop()
+	events, cancel := f.hub.subscribeInternal(EventValuationTick, EventNewBlock, EventMint, EventRedeem)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case data := <-events:
+			f.handleEvent(data)
+		case <-frames.C:
+			f.publishFrames()
+		}
+	}
+}
+
+// closeClients disconnects every terminal client and refuses new ones.
+func (f *TerminalFeed) closeClients() {
+	f.mu.Lock()
+	f.closed = true
+	clients := make([]*terminalClient, 0, len(f.clients))
+	for c := range f.clients {
+		clients = append(clients, c)
+	}
+	f.mu.Unlock()
+
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "node shutting down")
+	for _, c := range clients {
+		_ = c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		c.close()
+	}
+}
+
+func (f *TerminalFeed) handleEvent(data []byte) {
+	var env struct {
+		ID        string          `json:"id"`
+		Type      EventType       `json:"type"`
+		Version   string          `json:"version"`
+		Payload   json.RawMessage `json:"payload"`
+		Timestamp time.Time       `json:"timestamp"`
+	}
+	if err := json.Unmarshal(data, &env); err != nil {
+		return
+	}
+	p, err := DecodeEventPayload(Event{ID: env.ID, Type: env.Type, Version: env.Version, Payload: env.Payload})
+	if err != nil {
+		terminalLog.Warn("decode event failed", "type", env.Type, "version", env.Version, logging.Err(err))
+		return
+	}
+	switch p := p.(type) {
+	case ValuationTickPayload:
+		tick := econ.ValuationTick(p)
+		f.mu.Lock()
+		f.tick = &tick
+		f.feedLag = time.Since(env.Timestamp)
+		f.mu.Unlock()
+		f.broadcast(TerminalMessage{Channel: "price", Tick: &TerminalTick{Price: tick.NAV.GRC}})
+	case NewBlockPayload:
+		f.broadcast(TerminalMessage{Channel: "chain", Chain: f.chainSnapshot()})
+	case MintPayload:
+		f.reserveEvent("issuance", p.Address, p.Asset, p.Deposit, p.Minted, env.ID, env.Timestamp)
+	case RedeemPayload:
+		f.reserveEvent("redemption", p.Address, p.Asset, -p.Payout, -p.Burned, env.ID, env.Timestamp)
+	}
+}
+
+// reserveEvent publishes a reserve overlay marker for a mint or redemption
+// and the matching fill to the account owner. assetDelta is the backing
+// asset moved and grcDelta the GRC minted or burned; both are signed,
+// positive for a mint.
+func (f *TerminalFeed) reserveEvent(kind, addr, asset string, assetDelta, grcDelta float64, id string, ts time.Time) {
+	nav := f.nav()
+	reserveUSD, _, _ := f.chain.DevnetMonetarySnapshot()
+	after := reserveUSD / nav
+	// The reserve is quoted in GRC at NAV; DevNet backing assets are USD-like.
+	delta := assetDelta / nav
+
+	f.mu.Lock()
+	before := f.reserve
+	if before == 0 {
+		before = after - delta
+	}
+	f.reserve = after
+	f.mu.Unlock()
+
+	amount, qty := assetDelta, grcDelta
+	side := "buy"
+	if grcDelta < 0 {
+		amount, qty, side = -assetDelta, -grcDelta, "sell"
+	}
+	ev := &ReserveEventMsg{
+		Type:          kind,
+		Amount:        amount,
+		NAV:           nav,
+		ReserveBefore: before,
+		ReserveAfter:  after,
+	}
+	if after > 0 {
+		ev.CompositionDelta = map[string]float64{asset: delta / after}
+	}
+	f.broadcast(TerminalMessage{Channel: "reserve", Event: ev})
+
+	// Minting buys GRC with the deposited asset; redeeming sells it.
+	fill := TerminalMessage{Channel: "execution", Fill: &ExecutionFill{
+		OrderID: id,
+		Symbol:  "GRC-USD",
+		Side:    side,
+		Qty:     qty,
+		Price:   nav,
+		TS:      ts.Unix(),
+	}}
+	f.sendWhere(fill, func(c *terminalClient) bool { return sessionOwnsAddress(c.identity, addr) })
+}
+
+// publishFrames sends the periodic metrics, chain and margin frames.
+func (f *TerminalFeed) publishFrames() {
+	f.mu.RLock()
+	var slot uint64
+	if f.tick != nil {
+		slot = f.tick.TickID
+	}
+	feedMs := f.feedLag.Milliseconds()
+	clients := make([]*terminalClient, 0, len(f.clients))
+	for c := range f.clients {
+		clients = append(clients, c)
+	}
+	f.mu.RUnlock()
+	if len(clients) == 0 {
+		return
+	}
+
+	nav := f.nav()
+	reserveUSD, _, _ := f.chain.DevnetMonetarySnapshot()
+	chain := TerminalMessage{Channel: "chain", Chain: f.chainSnapshot()}
+	chainData, err := json.Marshal(chain)
+	if err != nil {
+		return
+	}
+	margins := map[string][]byte{}
+	for _, c := range clients {
+		f.sendTo(c, TerminalMessage{Channel: "metrics", Metrics: &TerminalMetrics{
+			RTTMs:      atomic.LoadInt64(&c.rttMs),
+			FeedMs:     feedMs,
+			Slot:       slot,
+			ReserveStr: formatReserveString(reserveUSD / nav),
+		}})
+		c.enqueue(chainData)
+		if c.identity == "" {
+			continue
+		}
+		data, ok := margins[c.identity]
+		if !ok {
+			data, _ = json.Marshal(TerminalMessage{Channel: "margin", Risk: f.marginFor(c.identity, nav)})
+			margins[c.identity] = data
+		}
+		c.enqueue(data)
+	}
+}
+
+func (f *TerminalFeed) nav() float64 {
+	f.mu.RLock()
+	tick := f.tick
+	f.mu.RUnlock()
+	if tick != nil && tick.NAV.GRC > 0 {
+		return tick.NAV.GRC
+	}
+	if nav := econ.GetLastNAV().GRC; nav > 0 {
+		return nav
+	}
+	return 1.0
+}
+
+func (f *TerminalFeed) chainSnapshot() *ChainSnapshot {
+	f.mu.RLock()
+	var slot uint64
+	if f.tick != nil {
+		slot = f.tick.TickID
+	}
+	f.mu.RUnlock()
+	return &ChainSnapshot{
+		Slot:   slot,
+		Height: f.chain.Height(),
+		Epoch:  uint64(econ.CurrentDevnetEpoch()),
+		NAV:    f.nav(),
+	}
+}
+
+// marginFor values the session's account in GRC. DevNet accounts are
+// spot only: nothing is borrowed, so all equity is free margin and the
+// exposure is the GRC position itself.
+func (f *TerminalFeed) marginFor(ident string, nav float64) *MarginSnapshot {
+	acc := f.chain.Store().Snapshot(ident)
+	if len(acc.Balances) == 0 {
+		if i := strings.IndexByte(ident, ':'); i >= 0 {
+			acc = f.chain.Store().Snapshot(ident[i+1:])
+		}
+	}
+	var equity float64
+	for asset, bal := range acc.Balances {
+		if asset == "GRC" {
+			equity += bal
+			continue
+		}
+		if price, ok := econ.DevnetPriceUSD(asset); ok {
+			equity += bal * price / nav
+		}
+	}
+	m := &MarginSnapshot{
+		Equity:           equity,
+		MarginFree:       equity,
+		ExposureNotional: acc.Balances["GRC"],
+	}
+	if equity > 0 {
+		m.Leverage = m.ExposureNotional / equity
+	}
+	return m
+}
+
+func (f *TerminalFeed) broadcast(msg TerminalMessage) {
+	f.sendWhere(msg, func(*terminalClient) bool { return true })
+}
+
+func (f *TerminalFeed) sendWhere(msg TerminalMessage, want func(*terminalClient) bool) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		terminalLog.Error("marshal frame failed", logging.Err(err))
+		return
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for c := range f.clients {
+		if want(c) {
+			c.enqueue(data)
+		}
+	}
+}
+
+func (f *TerminalFeed) sendTo(c *terminalClient, msg TerminalMessage) {
+	if data, err := json.Marshal(msg); err == nil {
+		c.enqueue(data)
+	}
+}
+
+func (f *TerminalFeed) remove(c *terminalClient) {
+	f.mu.Lock()
+	delete(f.clients, c)
+	f.mu.Unlock()
+}
+
+func (c *terminalClient) enqueue(data []byte) {
+	select {
+	case c.send <- data:
+	default:
+	}
+}
+
+func (c *terminalClient) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// writePump is the only writer on conn. Pings carry their send time so the
+// pong handler can measure the round trip shown in the metrics HUD.
+func (c *terminalClient) writePump(writeWait time.Duration) {
+	ping := time.NewTicker(terminalPing)
+	defer func() {
+		ping.Stop()
+		c.close()
+	}()
+	for {
+		select {
+		case <-c.done:
+			return
+		case data := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case now := <-ping.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			stamp := strconv.FormatInt(now.UnixNano(), 10)
+			if err := c.conn.WriteMessage(websocket.PingMessage, []byte(stamp)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readPump drains the socket (the protocol is one-way) and records RTTs.
+func (c *terminalClient) readPump(pongWait time.Duration) {
+	defer c.close()
+	c.conn.SetReadLimit(maxWSMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(data string) error {
+		if sent, err := strconv.ParseInt(data, 10, 64); err == nil {
+			atomic.StoreInt64(&c.rttMs, time.Since(time.Unix(0, sent)).Milliseconds())
+		}
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// terminalWSHandler serves /ws/terminal. The session cookie, if any,
+// scopes the execution and margin channels to the session's account.
+func (api *HTTPAPI) terminalWSHandler(w http.ResponseWriter, r *http.Request) {
+	f := currentTerminalFeed()
+	if f == nil {
+		http.Error(w, "terminal feed not running", http.StatusServiceUnavailable)
+		return
+	}
+	identity := api.readIdentity(r)
+	conn, err := terminalUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		terminalLog.WarnContext(r.Context(), "upgrade failed", logging.Err(err))
+		return
+	}
+	c := &terminalClient{
+		conn:     conn,
+		identity: identity,
+		send:     make(chan []byte, terminalSendQueue),
+		done:     make(chan struct{}),
+	}
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		c.close()
+		return
+	}
+	f.clients[c] = struct{}{}
+	f.mu.Unlock()
+	go func() {
+		<-c.done
+		f.remove(c)
+	}()
+
+	opts := api.Hub.opts
+	go c.writePump(opts.WriteWait)
+	go c.readPump(opts.PongWait)
+}
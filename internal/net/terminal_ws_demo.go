@@ -2,15 +2,10 @@
 package net
 
 import (
-    "encoding/json"
-    "log"
+    "context"
     "math"
     "math/rand"
-    "net/http"
-    "sync"
     "time"
-
-    "github.com/gorilla/websocket"
 )
 
 // TerminalMessage is the envelope sent to the trading terminal WS client.
@@ -50,7 +45,7 @@ type ReserveEventMsg struct {
     CompositionDelta map[string]float64 `json:"composition_delta,omitempty"`
 }
 
-// ExecutionFill is a synthetic fill event for DevNet.
+// ExecutionFill is a fill on the session's account.
 type ExecutionFill struct {
     OrderID string  `json:"order_id"`
     Symbol  string  `json:"symbol"`
@@ -61,7 +56,7 @@ type ExecutionFill struct {
     TS      int64   `json:"ts"`
 }
 
-// MarginSnapshot is a simple risk summary for future use.
+// MarginSnapshot is a risk summary of the session's account.
 type MarginSnapshot struct {
     Equity          float64 `json:"equity"`
     MarginUsed      float64 `json:"margin_used"`
@@ -78,61 +73,12 @@ type ChainSnapshot struct {
     NAV    float64 `json:"nav"`
 }
 
-var (
-    terminalUpgrader = websocket.Upgrader{
-        CheckOrigin: func(r *http.Request) bool { return true },
-    }
-
-    terminalConnsMu sync.Mutex
-    terminalConns   = make(map[*websocket.Conn]struct{})
-)
-
-func init() {
-    // Register the terminal WS handler on the default mux.
-    http.HandleFunc("/ws/terminal", HandleTerminalWS)
-    // Seed RNG for demo streams.
+// runDemo emits synthetic metrics, price ticks, reserve events, execution
+// fills, margin snapshots, and chain snapshots to every connected terminal
+// client. It is DevNet-only and enabled by rpc.terminal_demo.
+func (f *TerminalFeed) runDemo(ctx context.Context) {
     rand.Seed(time.Now().UnixNano())
-    // Start a background demo loop so the terminal feels alive even before
-    // the real engine wiring is finished.
-    go startTerminalDemoLoop()
-}
-
-// HandleTerminalWS upgrades the HTTP connection to a WebSocket and
-// tracks the terminal client. For now this is a pure broadcast-only
-// channel; we ignore incoming messages.
-func HandleTerminalWS(w http.ResponseWriter, r *http.Request) {
-    conn, err := terminalUpgrader.Upgrade(w, r, nil)
-    if err != nil {
-        log.Println("terminal ws upgrade:", err)
-        return
-    }
 
-    terminalConnsMu.Lock()
-    terminalConns[conn] = struct{}{}
-    terminalConnsMu.Unlock()
-
-    go func(c *websocket.Conn) {
-        defer func() {
-            terminalConnsMu.Lock()
-            delete(terminalConns, c)
-            terminalConnsMu.Unlock()
-            _ = c.Close()
-        }()
-
-        for {
-            // Drain any incoming messages; protocol is one-way for now.
-            if _, _, err := c.ReadMessage(); err != nil {
-                return
-            }
-        }
-    }(conn)
-}
-
-// startTerminalDemoLoop emits synthetic metrics, price ticks, reserve events,
-// execution fills, margin snapshots, and chain snapshots to any connected
-// terminal clients. This is purely for DevNet and can later be replaced
-// by real engine-driven streams.
-func startTerminalDemoLoop() {
     basePrice := 1.0000
     reserve := 34_500_000.0
     slot := uint64(41)
@@ -157,6 +103,8 @@ func startTerminalDemoLoop() {
 
     for {
         select {
+        case <-ctx.Done():
+            return
         case <-metricsTicker.C:
             slot++
             if slot%50 == 0 {
@@ -178,7 +126,7 @@ func startTerminalDemoLoop() {
                     ReserveStr: formatReserveString(reserve),
                 },
             }
-            broadcastTerminalMessage(m)
+            f.broadcast(m)
 
             cmsg := TerminalMessage{
                 Channel: "chain",
@@ -189,7 +137,7 @@ func startTerminalDemoLoop() {
                     NAV:    nav,
                 },
             }
-            broadcastTerminalMessage(cmsg)
+            f.broadcast(cmsg)
 
             // Also emit a margin snapshot derived from our internal position.
             equity, marginUsed, marginFree, exposureNotional, lev := computeMarginSnapshot(baseEquity, basePrice, posSide, posQty, totalFees)
@@ -204,7 +152,7 @@ func startTerminalDemoLoop() {
                 Channel: "margin",
                 Risk:    risk,
             }
-            broadcastTerminalMessage(rmsg)
+            f.broadcast(rmsg)
 
         case <-priceTicker.C:
             delta := (rand.Float64() - 0.5) * 0.0008
@@ -218,7 +166,7 @@ func startTerminalDemoLoop() {
                     Price: basePrice,
                 },
             }
-            broadcastTerminalMessage(pmsg)
+            f.broadcast(pmsg)
 
         case <-reserveTicker.C:
             eventType := []string{"issuance", "redemption", "rebalance"}[rand.Intn(3)]
@@ -264,7 +212,7 @@ func startTerminalDemoLoop() {
                 Channel: "reserve",
                 Event:   ev,
             }
-            broadcastTerminalMessage(rmsg2)
+            f.broadcast(rmsg2)
 
         case <-execTicker.C:
             // Generate a synthetic fill consistent with current price.
@@ -312,26 +260,7 @@ func startTerminalDemoLoop() {
                 Channel: "execution",
                 Fill:    fill,
             }
-            broadcastTerminalMessage(emsg)
-        }
-    }
-}
-
-func broadcastTerminalMessage(msg TerminalMessage) {
-    payload, err := json.Marshal(msg)
-    if err != nil {
-        log.Println("terminal ws marshal:", err)
-        return
-    }
-
-    terminalConnsMu.Lock()
-    defer terminalConnsMu.Unlock()
-
-    for c := range terminalConns {
-        if err := c.WriteMessage(websocket.TextMessage, payload); err != nil {
-            log.Println("terminal ws write:", err)
-            _ = c.Close()
-            delete(terminalConns, c)
+            f.broadcast(emsg)
         }
     }
 }
@@ -1,6 +1,7 @@
 package net
 
 import (
+    "context"
     "encoding/json"
     "net/http"
     "time"
@@ -18,10 +19,7 @@ func (api *HTTPAPI) tierRenewHandler(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    var body struct {
-        Type string        `json:"type"`
-        Tx   core.TxTierRenew `json:"tx"`
-    }
+    var body TierRenewRequest
     if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
         http.Error(w, err.Error(), http.StatusBadRequest)
         return
@@ -30,42 +28,40 @@ func (api *HTTPAPI) tierRenewHandler(w http.ResponseWriter, r *http.Request) {
         http.Error(w, "invalid type", http.StatusBadRequest)
         return
     }
+    if !authorizeActor(w, r, body.Tx.Sender) {
+        return
+    }
 
-    blk, txHash, err := api.Chain.ApplyTierRenew(body.Tx)
+    _, txHash, err := api.applyTierRenew(r.Context(), body.Tx)
     if err != nil {
         http.Error(w, err.Error(), http.StatusBadRequest)
         return
     }
 
-    // Broadcast a synthetic event so UIs can react to the tier renewal if needed.
-    ev := Event{
-        ID:      "tier-renew-" + time.Now().Format(time.RFC3339Nano),
-        Type:    EventType("TierRenew"),
-        Version: "v1",
-        Payload: map[string]interface{}{
-            "sender": body.Tx.Sender,
-            "tier":   body.Tx.Tier,
-            "amount": body.Tx.Payment.AmountGRC,
-        },
-        Timestamp: time.Now().UTC(),
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(TxSubmitResponse{
+        Success: true,
+        TxHash:  txHash,
+    })
+}
+
+// applyTierRenew applies a TX_TIER_RENEW and announces it to event clients.
+func (api *HTTPAPI) applyTierRenew(ctx context.Context, tx core.TxTierRenew) (*core.Block, string, error) {
+    blk, txHash, err := api.Chain.ApplyTierRenew(ctx, tx)
+    if err != nil {
+        return nil, "", err
     }
-    api.Hub.Broadcast(ev)
+
+    // Broadcast a synthetic event so UIs can react to the tier renewal if needed.
+    api.Hub.Broadcast(NewEvent("tier-renew-"+time.Now().Format(time.RFC3339Nano), TierRenewPayload{
+        Sender: tx.Sender,
+        Tier:   tx.Tier,
+        Amount: tx.Payment.AmountGRC,
+    }, time.Now().UTC()))
 
     // Also broadcast a NewBlock event for explorers / dashboards.
     if blk != nil {
-        bev := Event{
-            ID:        "block-" + time.Now().Format(time.RFC3339Nano),
-            Type:      EventNewBlock,
-            Version:   "v1",
-            Payload:   blk,
-            Timestamp: blk.Timestamp,
-        }
-        api.Hub.Broadcast(bev)
+        api.Hub.Broadcast(NewEvent("block-"+time.Now().Format(time.RFC3339Nano), NewBlockPayload(*blk), blk.Timestamp))
     }
-
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(map[string]interface{}{
-        "success": true,
-        "tx_hash": txHash,
-    })
+    return blk, txHash, nil
 }
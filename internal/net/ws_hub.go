@@ -1,68 +1,596 @@
 package net
 
 import (
+    "context"
     "encoding/json"
-    "log"
     "net/http"
+    "strconv"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "time"
 
     "github.com/gorilla/websocket"
+
+    "reservechain/internal/logging"
 )
 
+// SlowConsumerPolicy decides what happens when a client's send queue is full.
+type SlowConsumerPolicy string
+
+const (
+    // SlowConsumerDrop drops the event for that client only.
+    SlowConsumerDrop SlowConsumerPolicy = "drop"
+    // SlowConsumerDisconnect closes the client's connection.
+    SlowConsumerDisconnect SlowConsumerPolicy = "disconnect"
+)
+
+// WSHubOptions tunes WebSocket delivery. Zero values pick the defaults.
+type WSHubOptions struct {
+    SendQueue      int                // per-client queued messages (default 256)
+    BroadcastQueue int                // events waiting for fan-out (default 1024)
+    SlowConsumer   SlowConsumerPolicy // default SlowConsumerDrop
+    WriteWait      time.Duration      // per-write deadline (default 10s)
+    PongWait       time.Duration      // max silence before a client is dropped (default 60s)
+}
+
+func (o WSHubOptions) withDefaults() WSHubOptions {
+    if o.SendQueue <= 0 {
+        o.SendQueue = 256
+    }
+    if o.BroadcastQueue <= 0 {
+        o.BroadcastQueue = 1024
+    }
+    if o.SlowConsumer != SlowConsumerDisconnect {
+        o.SlowConsumer = SlowConsumerDrop
+    }
+    if o.WriteWait <= 0 {
+        o.WriteWait = 10 * time.Second
+    }
+    if o.PongWait <= 0 {
+        o.PongWait = 60 * time.Second
+    }
+    return o
+}
+
+// maxWSMessageSize bounds control messages read from clients.
+const maxWSMessageSize = 4096
+
+// wsOutbound is one queued message: a journalled event (seq > 0) or a
+// control response.
+type wsOutbound struct {
+    seq  uint64
+    typ  string
+    data []byte
+}
+
+// wsClient is one /ws connection. It only receives events matching one of
+// its subscriptions, so a client that has not subscribed receives nothing.
+//
+// All writes go through send and are performed by the client's writer
+// goroutine, so neither the hub nor HTTP handlers ever wait on a socket.
+// The same client type backs SSE streams, where conn is nil.
+type wsClient struct {
+    hub      *WSHub
+    conn     *websocket.Conn
+    remote   string
+    identity string // session identity, empty when anonymous
+
+    send      chan wsOutbound
+    done      chan struct{}
+    closeOnce sync.Once
+    dropped   uint64
+
+    mu         sync.Mutex
+    subs       map[string]*wsSubscription
+    replaying  bool         // resume in progress; live events wait in pending
+    pending    []wsOutbound // live events that arrived during a resume
+}
+
+// deliver queues a live event, holding it back while a resume replays
+// older events so the client sees them in sequence order.
+func (c *wsClient) deliver(out wsOutbound) {
+    c.mu.Lock()
+    if c.replaying {
+        if len(c.pending) < cap(c.send) {
+            c.pending = append(c.pending, out)
+        } else {
+            atomic.AddUint64(&c.dropped, 1)
+            atomic.AddUint64(&c.hub.dropped, 1)
+        }
+        c.mu.Unlock()
+        return
+    }
+    c.mu.Unlock()
+    c.enqueue(out)
+}
+
+// push queues out, waiting for room; used only from the client's own
+// goroutines (never from the hub).
+func (c *wsClient) push(out wsOutbound) bool {
+    select {
+    case c.send <- out:
+        return true
+    case <-c.done:
+        return false
+    }
+}
+
+// resume replays journalled events after seq `after` that match the
+// client's subscriptions, then releases the live events held back
+// meanwhile. A "truncated" notice is sent first if part of the gap has
+// already been pruned.
+func (c *wsClient) resume(after uint64) {
+    defer func() {
+        c.mu.Lock()
+        pending := c.pending
+        c.pending = nil
+        c.replaying = false
+        c.mu.Unlock()
+        for _, out := range pending {
+            if out.seq > after {
+                c.enqueue(out)
+            }
+        }
+    }()
+
+    j := c.hub.journal
+    if j == nil {
+        return
+    }
+    events, truncated, err := j.Since(context.Background(), after, 0)
+    if err != nil {
+        wsLog.Error("resume failed", "after", after, logging.Err(err))
+        truncated = true
+    }
+    // A replay capped at maxReplayEvents cannot close the gap either.
+    if truncated || len(events) >= maxReplayEvents {
+        data, _ := json.Marshal(WSResponse{Op: "truncated", LastSeq: after})
+        if !c.push(wsOutbound{typ: "truncated", data: data}) {
+            return
+        }
+    }
+    for _, ev := range events {
+        after = ev.Seq // pending events up to here were replayed (or filtered)
+        data, err := json.Marshal(ev)
+        if err != nil {
+            continue
+        }
+        if !c.wants(ev.Type, func() eventScope { return scopeOf(data) }) {
+            continue
+        }
+        if !c.push(wsOutbound{seq: ev.Seq, typ: string(ev.Type), data: data}) {
+            return
+        }
+    }
+}
+
+// enqueue queues out without blocking and applies the slow consumer
+// policy when the queue is full.
+func (c *wsClient) enqueue(out wsOutbound) {
+    select {
+    case <-c.done:
+        return
+    default:
+    }
+    select {
+    case c.send <- out:
+    default:
+        atomic.AddUint64(&c.hub.dropped, 1)
+        if c.hub.opts.SlowConsumer == SlowConsumerDisconnect {
+            wsLog.Warn("disconnecting slow consumer", "remote", c.remote)
+            // The hub may hold its client lock here; close asynchronously.
+            go c.close()
+            return
+        }
+        if n := atomic.AddUint64(&c.dropped, 1); n == 1 || n%1000 == 0 {
+            wsLog.Warn("slow consumer dropped events", "remote", c.remote, "dropped", n)
+        }
+    }
+}
+
+func (c *wsClient) enqueueJSON(v interface{}) {
+    data, err := json.Marshal(v)
+    if err != nil {
+        return
+    }
+    c.enqueue(wsOutbound{data: data})
+}
+
+func (c *wsClient) close() {
+    c.closeOnce.Do(func() {
+        close(c.done)
+        c.hub.remove(c)
+        if c.conn != nil {
+            c.conn.Close()
+        }
+    })
+}
+
+// writePump delivers queued messages and keeps the connection alive with
+// pings. It is the only goroutine that writes to conn.
+func (c *wsClient) writePump() {
+    opts := c.hub.opts
+    ping := time.NewTicker(opts.PongWait * 9 / 10)
+    defer func() {
+        ping.Stop()
+        c.close()
+    }()
+    for {
+        select {
+        case <-c.done:
+            return
+        case out := <-c.send:
+            _ = c.conn.SetWriteDeadline(time.Now().Add(opts.WriteWait))
+            if err := c.conn.WriteMessage(websocket.TextMessage, out.data); err != nil {
+                return
+            }
+        case <-ping.C:
+            _ = c.conn.SetWriteDeadline(time.Now().Add(opts.WriteWait))
+            if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+                return
+            }
+        }
+    }
+}
+
+// readPump handles subscribe/unsubscribe messages and pongs until the
+// client goes away.
+func (c *wsClient) readPump() {
+    defer c.close()
+    pongWait := c.hub.opts.PongWait
+    c.conn.SetReadLimit(maxWSMessageSize)
+    _ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+    c.conn.SetPongHandler(func(string) error {
+        return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+    })
+    for {
+        var req WSRequest
+        if err := c.conn.ReadJSON(&req); err != nil {
+            if _, ok := err.(*json.SyntaxError); ok {
+                c.enqueueJSON(WSResponse{Op: "error", Error: "invalid_json"})
+                continue
+            }
+            return
+        }
+        _ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+        c.enqueueJSON(c.handle(req))
+    }
+}
+
+// wants reports whether one of the client's subscriptions matches. A
+// client that has not subscribed receives nothing.
+func (c *wsClient) wants(t EventType, sc func() eventScope) bool {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    for _, s := range c.subs {
+        if s.matches(t, sc()) {
+            return true
+        }
+    }
+    return false
+}
+
+func (c *wsClient) handle(req WSRequest) WSResponse {
+    switch req.Op {
+    case "subscribe":
+        sub, err := newSubscription(req, c.identity)
+        if err != nil {
+            return WSResponse{Op: "error", ID: req.ID, Error: err.Error()}
+        }
+        c.mu.Lock()
+        defer c.mu.Unlock()
+        if _, exists := c.subs[req.ID]; !exists && len(c.subs) >= maxWSSubscriptions {
+            return WSResponse{Op: "error", ID: req.ID, Error: errWSTooManySubs.Error()}
+        }
+        c.subs[req.ID] = sub
+        return WSResponse{Op: "subscribed", ID: req.ID}
+    case "unsubscribe":
+        c.mu.Lock()
+        defer c.mu.Unlock()
+        if _, ok := c.subs[req.ID]; !ok {
+            return WSResponse{Op: "error", ID: req.ID, Error: errWSUnknownSub.Error()}
+        }
+        delete(c.subs, req.ID)
+        return WSResponse{Op: "unsubscribed", ID: req.ID}
+    default:
+        return WSResponse{Op: "error", ID: req.ID, Error: errWSUnknownOp.Error()}
+    }
+}
+
 type WSHub struct {
-    clients    map[*websocket.Conn]bool
-    broadcast  chan Event
-    register   chan *websocket.Conn
-    unregister chan *websocket.Conn
-    upgrader   websocket.Upgrader
+    opts      WSHubOptions
+    journal   *EventJournal
+    pubMu     sync.Mutex // keeps the queue in sequence order
+    mu        sync.RWMutex
+    clients   map[*wsClient]struct{}
+    internal  map[*internalSub]struct{}
+    closed    bool // set once Run has returned
+    broadcast chan Event
+    dropped   uint64 // events dropped across all clients and the hub queue
+    upgrader  websocket.Upgrader
 }
 
 func NewWSHub() *WSHub {
+    return NewWSHubWithOptions(WSHubOptions{})
+}
+
+// NewWSHubWithOptions creates a hub with the given delivery settings.
+func NewWSHubWithOptions(opts WSHubOptions) *WSHub {
+    opts = opts.withDefaults()
     return &WSHub{
-        clients:    make(map[*websocket.Conn]bool),
-        broadcast:  make(chan Event, 128),
-        register:   make(chan *websocket.Conn),
-        unregister: make(chan *websocket.Conn),
+        opts:      opts,
+        clients:   make(map[*wsClient]struct{}),
+        internal:  make(map[*internalSub]struct{}),
+        broadcast: make(chan Event, opts.BroadcastQueue),
         upgrader: websocket.Upgrader{
-            CheckOrigin: func(r *http.Request) bool { return true },
+            CheckOrigin: checkOrigin,
         },
     }
 }
 
-func (h *WSHub) Run() {
+// AttachJournal makes the hub sequence and record every event so clients
+// can resume. It must be called before Run.
+func (h *WSHub) AttachJournal(j *EventJournal) {
+    h.journal = j
+}
+
+// Run fans queued events out to client send queues until ctx is done, then
+// disconnects every client. It never blocks on a connection; slow clients
+// are handled by their own queue policy.
+func (h *WSHub) Run(ctx context.Context) {
+    defer h.closeClients()
     for {
         select {
-        case conn := <-h.register:
-            h.clients[conn] = true
-        case conn := <-h.unregister:
-            if _, ok := h.clients[conn]; ok {
-                delete(h.clients, conn)
-                conn.Close()
-            }
+        case <-ctx.Done():
+            return
         case ev := <-h.broadcast:
-            data, err := json.Marshal(ev)
-            if err != nil {
-                log.Println("marshal event:", err)
-                continue
-            }
-            for conn := range h.clients {
-                if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
-                    log.Println("write ws:", err)
-                    h.unregister <- conn
-                }
-            }
+            h.dispatch(ev)
+        }
+    }
+}
+
+// closeClients disconnects every client, telling WebSocket clients the
+// node is going away, and refuses clients that connect afterwards.
+func (h *WSHub) closeClients() {
+    h.mu.Lock()
+    h.closed = true
+    clients := make([]*wsClient, 0, len(h.clients))
+    for c := range h.clients {
+        clients = append(clients, c)
+    }
+    h.mu.Unlock()
+
+    msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "node shutting down")
+    for _, c := range clients {
+        if c.conn != nil {
+            _ = c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
         }
+        c.close()
+    }
+    if len(clients) > 0 {
+        wsLog.Info("closed clients on shutdown", "count", len(clients))
+    }
+}
+
+// dispatch queues ev, already journalled by Broadcast, for every client
+// that wants it.
+func (h *WSHub) dispatch(ev Event) {
+    data, err := json.Marshal(ev)
+    if err != nil {
+        wsLog.Error("marshal event failed", logging.Err(err))
+        return
+    }
+    // The payload is only decoded for routing if some client filters.
+    var scope *eventScope
+    scopeFn := func() eventScope {
+        if scope == nil {
+            sc := scopeOf(data)
+            scope = &sc
+        }
+        return *scope
+    }
+    out := wsOutbound{seq: ev.Seq, typ: string(ev.Type), data: data}
+    h.mu.RLock()
+    for c := range h.clients {
+        if c.wants(ev.Type, scopeFn) {
+            c.deliver(out)
+        }
+    }
+    for s := range h.internal {
+        if !s.types[ev.Type] {
+            continue
+        }
+        select {
+        case s.ch <- data:
+        default:
+            atomic.AddUint64(&h.dropped, 1)
+        }
+    }
+    h.mu.RUnlock()
+}
+
+// internalSub is an in-process consumer of hub events. It is not a client:
+// it is not counted in ClientCounts, skips the journal replay and the
+// subscription checks, and is never disconnected; events that find its
+// buffer full are dropped and counted.
+type internalSub struct {
+    types map[EventType]bool
+    ch    chan []byte
+}
+
+// subscribeInternal registers an in-process consumer of the listed event
+// types. It receives each event JSON-encoded, as clients do; cancel
+// unregisters it.
+func (h *WSHub) subscribeInternal(types ...EventType) (events <-chan []byte, cancel func()) {
+    s := &internalSub{
+        types: make(map[EventType]bool, len(types)),
+        ch:    make(chan []byte, h.opts.SendQueue),
+    }
+    for _, t := range types {
+        s.types[t] = true
+    }
+    h.mu.Lock()
+    h.internal[s] = struct{}{}
+    h.mu.Unlock()
+    return s.ch, func() {
+        h.mu.Lock()
+        delete(h.internal, s)
+        h.mu.Unlock()
     }
 }
 
+// Broadcast journals ev, which assigns its sequence number, then queues it
+// for delivery and returns without waiting on clients. If the hub queue is
+// full the live delivery is dropped rather than blocking the caller; the
+// event is already journalled, so clients see a gap in seq and can
+// recover it by resuming from last_seq.
 func (h *WSHub) Broadcast(ev Event) {
-    h.broadcast <- ev
+    checkEventRegistered(ev)
+    h.pubMu.Lock()
+    defer h.pubMu.Unlock()
+    if h.journal != nil {
+        ev = h.journal.Append(ev)
+    }
+    select {
+    case h.broadcast <- ev:
+    default:
+        if n := atomic.AddUint64(&h.dropped, 1); n == 1 || n%1000 == 0 {
+            wsLog.Warn("broadcast queue full", "dropped", n, "seq", ev.Seq)
+        }
+    }
+}
+
+// Dropped returns how many event deliveries have been dropped so far.
+func (h *WSHub) Dropped() uint64 {
+    return atomic.LoadUint64(&h.dropped)
+}
+
+// ClientCounts returns the connected WebSocket and SSE clients.
+func (h *WSHub) ClientCounts() (ws, sse int) {
+    h.mu.RLock()
+    defer h.mu.RUnlock()
+    for c := range h.clients {
+        if c.conn == nil {
+            sse++
+        } else {
+            ws++
+        }
+    }
+    return ws, sse
+}
+
+func (h *WSHub) remove(c *wsClient) {
+    h.mu.Lock()
+    delete(h.clients, c)
+    h.mu.Unlock()
 }
 
+// HandleWS serves an anonymous /ws connection.
 func (h *WSHub) HandleWS(w http.ResponseWriter, r *http.Request) {
+    h.serveWS(w, r, "")
+}
+
+// streamParams are the query parameters shared by /ws and the SSE stream:
+// last_seq resumes after that event, and types/address/vault_id/validator
+// set an initial subscription that also filters the replay.
+type streamParams struct {
+    resume  bool
+    lastSeq uint64
+    sub     *wsSubscription
+}
+
+func parseStreamParams(r *http.Request, identity string) (streamParams, error) {
+    var p streamParams
+    q := r.URL.Query()
+    last := q.Get("last_seq")
+    if last == "" {
+        last = r.Header.Get("Last-Event-ID")
+    }
+    if last != "" {
+        v, err := strconv.ParseUint(last, 10, 64)
+        if err != nil {
+            return p, err
+        }
+        p.resume, p.lastSeq = true, v
+    }
+    req := WSRequest{
+        Op:        "subscribe",
+        ID:        "initial",
+        Address:   q.Get("address"),
+        VaultID:   q.Get("vault_id"),
+        Validator: q.Get("validator"),
+    }
+    if t := q.Get("types"); t != "" {
+        for _, name := range strings.Split(t, ",") {
+            if name = strings.TrimSpace(name); name != "" {
+                req.Types = append(req.Types, EventType(name))
+            }
+        }
+    }
+    if len(req.Types) > 0 || req.Address != "" || req.VaultID != "" || req.Validator != "" {
+        sub, err := newSubscription(req, identity)
+        if err != nil {
+            return p, err
+        }
+        p.sub = sub
+    }
+    return p, nil
+}
+
+// addClient registers a client for conn (nil for SSE) and, when the
+// parameters ask for it, starts replaying missed events.
+func (h *WSHub) addClient(conn *websocket.Conn, remote, identity string, p streamParams) *wsClient {
+    c := &wsClient{
+        hub:       h,
+        conn:      conn,
+        remote:    remote,
+        identity:  identity,
+        send:      make(chan wsOutbound, h.opts.SendQueue),
+        done:      make(chan struct{}),
+        subs:      make(map[string]*wsSubscription),
+        replaying: p.resume,
+    }
+    if p.sub != nil {
+        c.subs["initial"] = p.sub
+    }
+    h.mu.Lock()
+    if h.closed {
+        h.mu.Unlock()
+        c.close()
+        return c
+    }
+    h.clients[c] = struct{}{}
+    h.mu.Unlock()
+
+    if p.resume {
+        go c.resume(p.lastSeq)
+    }
+    return c
+}
+
+// serveWS upgrades the request and starts the client's reader and writer.
+// identity is the caller's session identity and gates address-scoped
+// subscriptions.
+func (h *WSHub) serveWS(w http.ResponseWriter, r *http.Request, identity string) {
+    p, err := parseStreamParams(r, identity)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
     conn, err := h.upgrader.Upgrade(w, r, nil)
     if err != nil {
-        log.Println("upgrade:", err)
+        wsLog.WarnContext(r.Context(), "upgrade failed", logging.Err(err))
         return
     }
-    h.register <- conn
+    c := h.addClient(conn, conn.RemoteAddr().String(), identity, p)
+
+    go c.writePump()
+    go c.readPump()
+}
+
+// wsHandler serves /ws, attaching the caller's session (if any) so clients
+// can subscribe to events for the addresses they own.
+func (api *HTTPAPI) wsHandler(w http.ResponseWriter, r *http.Request) {
+    api.Hub.serveWS(w, r, api.readIdentity(r))
 }
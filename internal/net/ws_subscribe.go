@@ -0,0 +1,196 @@
+package net
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// WSRequest is a control message a client sends on /ws.
+//
+//	{"op":"subscribe","id":"s1","types":["Transfer","NewBlock"],"address":"rc:abc"}
+//	{"op":"unsubscribe","id":"s1"}
+//
+// A subscription matches an event when its type is listed (or types is
+// empty) and every filter that is set matches the event. A connection
+// receives nothing until it subscribes. Address filters require an
+// authenticated session that owns the address, and events that name
+// accounts (see addressedEvents) are only delivered through such a filter.
+type WSRequest struct {
+	Op        string      `json:"op"`
+	ID        string      `json:"id"`
+	Types     []EventType `json:"types,omitempty"`
+	Address   string      `json:"address,omitempty"`
+	VaultID   string      `json:"vault_id,omitempty"`
+	Validator string      `json:"validator,omitempty"`
+}
+
+// WSResponse acknowledges a WSRequest.
+type WSResponse struct {
+	Op      string `json:"op"` // "subscribed", "unsubscribed", "truncated" or "error"
+	ID      string `json:"id,omitempty"`
+	Error   string `json:"error,omitempty"`
+	LastSeq uint64 `json:"last_seq,omitempty"` // "truncated": the seq the resume started from
+}
+
+// maxWSSubscriptions bounds how many subscriptions one connection may hold.
+const maxWSSubscriptions = 32
+
+var (
+	errWSSubscriptionID  = errors.New("subscription id required")
+	errWSTooManySubs     = errors.New("too many subscriptions")
+	errWSAuthRequired    = errors.New("address filter requires an authenticated session")
+	errWSAddressNotOwned = errors.New("session does not own address")
+	errWSAddressRequired = errors.New("event type names accounts; subscribe with an owned address filter")
+	errWSUnknownOp       = errors.New("unknown op")
+	errWSUnknownSub      = errors.New("unknown subscription id")
+)
+
+type wsSubscription struct {
+	types     map[EventType]bool
+	address   string
+	vaultID   string
+	validator string
+}
+
+// addressedEvents are the event types that report account activity. They
+// are only delivered to subscriptions filtered on an address the session
+// owns, and only when the event names that address. NewBlock stays a
+// public topic so explorers can follow the chain; its block is the same
+// one served by the public block API.
+var addressedEvents = map[EventType]bool{
+	EventTransfer:    true,
+	EventMint:        true,
+	EventRedeem:      true,
+	EventTierRenew:   true,
+	EventVaultCreate: true,
+}
+
+// eventScope holds the routing keys extracted from an event payload.
+type eventScope struct {
+	addresses  map[string]bool
+	vaultIDs   map[string]bool
+	validators map[string]bool
+}
+
+// Payload keys that identify the addresses, vaults and validators an event
+// concerns. NewBlock payloads are also searched inside their "tx" object.
+var (
+	addressKeys   = []string{"address", "from", "to", "owner", "sender", "staker_wallet", "operator_wallet"}
+	vaultKeys     = []string{"vault_id"}
+	validatorKeys = []string{"validator_id", "validator"}
+)
+
+// scopeOf decodes the event payload once and collects its routing keys.
+func scopeOf(data []byte) eventScope {
+	sc := eventScope{
+		addresses:  map[string]bool{},
+		vaultIDs:   map[string]bool{},
+		validators: map[string]bool{},
+	}
+	var env struct {
+		Payload map[string]interface{} `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &env); err != nil || env.Payload == nil {
+		return sc
+	}
+	objs := []map[string]interface{}{env.Payload}
+	if tx, ok := env.Payload["tx"].(map[string]interface{}); ok {
+		objs = append(objs, tx)
+	}
+	for _, obj := range objs {
+		collectKeys(obj, addressKeys, sc.addresses, true)
+		collectKeys(obj, vaultKeys, sc.vaultIDs, false)
+		collectKeys(obj, validatorKeys, sc.validators, false)
+	}
+	return sc
+}
+
+func collectKeys(obj map[string]interface{}, keys []string, into map[string]bool, fold bool) {
+	for _, k := range keys {
+		if v, ok := obj[k].(string); ok && v != "" {
+			if fold {
+				// Addresses are recorded both as given and without a
+				// "rc:"/"evm:" prefix.
+				v = strings.ToLower(v)
+				if i := strings.IndexByte(v, ':'); i >= 0 {
+					into[v[i+1:]] = true
+				}
+			}
+			into[v] = true
+		}
+	}
+}
+
+func (s *wsSubscription) matches(t EventType, sc eventScope) bool {
+	if len(s.types) > 0 && !s.types[t] {
+		return false
+	}
+	if addressedEvents[t] && s.address == "" {
+		return false
+	}
+	if s.address != "" && !sc.addresses[s.address] {
+		return false
+	}
+	if s.vaultID != "" && !sc.vaultIDs[s.vaultID] {
+		return false
+	}
+	if s.validator != "" && !sc.validators[s.validator] {
+		return false
+	}
+	return true
+}
+
+// sessionOwnsAddress reports whether a session identity ("rc:abc",
+// "evm:0x…") owns addr, given either in canonical or bare form.
+func sessionOwnsAddress(ident, addr string) bool {
+	if ident == "" || addr == "" {
+		return false
+	}
+	if strings.EqualFold(ident, addr) {
+		return true
+	}
+	if i := strings.IndexByte(ident, ':'); i >= 0 {
+		return strings.EqualFold(ident[i+1:], addr)
+	}
+	return false
+}
+
+// newSubscription validates a subscribe request for a connection whose
+// session identity is ident (empty when anonymous).
+func newSubscription(req WSRequest, ident string) (*wsSubscription, error) {
+	if req.ID == "" {
+		return nil, errWSSubscriptionID
+	}
+	if req.Address != "" {
+		if ident == "" {
+			return nil, errWSAuthRequired
+		}
+		if !sessionOwnsAddress(ident, req.Address) {
+			return nil, errWSAddressNotOwned
+		}
+	}
+	if req.Address == "" {
+		for _, t := range req.Types {
+			if addressedEvents[t] {
+				return nil, errWSAddressRequired
+			}
+		}
+	}
+	sub := &wsSubscription{
+		types:     make(map[EventType]bool, len(req.Types)),
+		vaultID:   req.VaultID,
+		validator: req.Validator,
+	}
+	if req.Address != "" {
+		// Match events that name either form of the address.
+		sub.address = strings.ToLower(req.Address)
+		if i := strings.IndexByte(sub.address, ':'); i >= 0 {
+			sub.address = sub.address[i+1:]
+		}
+	}
+	for _, t := range req.Types {
+		sub.types[t] = true
+	}
+	return sub, nil
+}
@@ -0,0 +1,132 @@
+package proof
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MinDifficulty is the lowest PoW difficulty a valid block may claim.
+const MinDifficulty = 2
+
+// AccountsSection is the state section whose hash is the Merkle root over
+// all accounts, which is what account proofs are checked against.
+const AccountsSection = "accounts"
+
+// HeaderHash is the PoW hash over a block header. Blocks mined before state
+// roots existed have no root in their header.
+func HeaderHash(height uint64, prevHash, txType string, payload []byte, stateRoot string, nonce uint64) string {
+	header := fmt.Sprintf("%d:%s:%s:%s:%d", height, prevHash, txType, string(payload), nonce)
+	if stateRoot != "" {
+		header = fmt.Sprintf("%d:%s:%s:%s:%s:%d", height, prevHash, txType, string(payload), stateRoot, nonce)
+	}
+	sum := sha256.Sum256([]byte(header))
+	return hex.EncodeToString(sum[:])
+}
+
+// MeetsDifficulty reports whether hash has at least difficulty leading
+// zero hex digits.
+func MeetsDifficulty(hash string, difficulty uint32) bool {
+	if int(difficulty) > len(hash) {
+		return false
+	}
+	for i := 0; i < int(difficulty); i++ {
+		if hash[i] != '0' {
+			return false
+		}
+	}
+	return true
+}
+
+// StateRoot folds consensus section hashes (by name) into a single root.
+func StateRoot(sections map[string]string) string {
+	lines := make([]string, 0, len(sections))
+	for name, hash := range sections {
+		lines = append(lines, name+":"+hash+"\n")
+	}
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+// BlockHeader is a block as served by /api/chain/block(s). Tx is kept raw
+// because the PoW hash covers its exact bytes.
+type BlockHeader struct {
+	Height     uint64          `json:"height"`
+	PrevHash   string          `json:"prev_hash"`
+	Hash       string          `json:"hash"`
+	Timestamp  time.Time       `json:"timestamp"`
+	TxType     string          `json:"tx_type"`
+	Tx         json.RawMessage `json:"tx"`
+	Nonce      uint64          `json:"nonce"`
+	Difficulty uint32          `json:"difficulty"`
+	StateRoot  string          `json:"state_root,omitempty"`
+}
+
+// VerifyPoW recomputes the header hash and checks it against the claimed
+// hash and difficulty.
+func (h BlockHeader) VerifyPoW() error {
+	payload := []byte(h.Tx)
+	if len(payload) == 0 {
+		payload = []byte("null")
+	}
+	if HeaderHash(h.Height, h.PrevHash, h.TxType, payload, h.StateRoot, h.Nonce) != h.Hash {
+		return fmt.Errorf("block %d: header hash mismatch", h.Height)
+	}
+	if h.Difficulty < MinDifficulty || !MeetsDifficulty(h.Hash, h.Difficulty) {
+		return fmt.Errorf("block %d: insufficient proof of work", h.Height)
+	}
+	return nil
+}
+
+// AccountProof proves one account's balances and nonce against the state
+// root of the block at Height. Account is the exact leaf that was hashed.
+type AccountProof struct {
+	Height    uint64            `json:"height"`
+	BlockHash string            `json:"block_hash"`
+	StateRoot string            `json:"state_root"`
+	Account   json.RawMessage   `json:"account"`
+	Steps     []Step            `json:"steps"`
+	Sections  map[string]string `json:"sections"` // consensus section name -> hash
+}
+
+// Verify checks the Merkle path up to the accounts section hash and the
+// section hashes up to the state root. The caller still has to check that
+// StateRoot belongs to a header it trusts.
+func (p AccountProof) Verify() error {
+	accountsRoot, ok := p.Sections[AccountsSection]
+	if !ok {
+		return errors.New("proof missing accounts section")
+	}
+	if !VerifyMerkleProof(p.Account, p.Steps, accountsRoot) {
+		return errors.New("account not in accounts root")
+	}
+	if StateRoot(p.Sections) != p.StateRoot {
+		return errors.New("sections do not match state root")
+	}
+	return nil
+}
+
+// TxProof proves a transaction's inclusion in a block. Blocks carry a single
+// transaction whose hash is the block hash, so the header itself is the
+// proof: its PoW hash covers the exact tx payload.
+type TxProof struct {
+	TxHash string      `json:"tx_hash"`
+	Block  BlockHeader `json:"block"`
+}
+
+// Verify checks the block's PoW and that it is the block for TxHash.
+func (p TxProof) Verify() error {
+	if err := p.Block.VerifyPoW(); err != nil {
+		return err
+	}
+	if p.Block.Hash != p.TxHash {
+		return errors.New("tx hash does not match block")
+	}
+	return nil
+}
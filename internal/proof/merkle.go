@@ -0,0 +1,107 @@
+// Package proof holds the hashing rules shared by full nodes and light
+// clients: block header PoW hashes, state roots and Merkle proofs. It has no
+// dependencies on the rest of the node so thin clients can import it alone.
+package proof
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// Leaves and inner nodes are domain-separated so a leaf can never be passed
+// off as an inner node (and vice versa).
+const (
+	leafPrefix  = 0x00
+	innerPrefix = 0x01
+)
+
+// Step is one level of a Merkle path: the sibling hash and which side of
+// the running hash it sits on.
+type Step struct {
+	Hash string `json:"hash"`
+	Left bool   `json:"left"` // sibling is the left child
+}
+
+func leafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func innerHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{innerPrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// levels builds every tree level bottom-up. An odd node at the end of a
+// level is carried up unchanged rather than paired with itself.
+func levels(leaves [][]byte) [][][]byte {
+	level := make([][]byte, len(leaves))
+	for i, l := range leaves {
+		level[i] = leafHash(l)
+	}
+	out := [][][]byte{level}
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, innerHash(level[i], level[i+1]))
+		}
+		out = append(out, next)
+		level = next
+	}
+	return out
+}
+
+// MerkleRoot returns the hex root over the given leaf data. The root of an
+// empty tree is sha256 of nothing.
+func MerkleRoot(leaves [][]byte) string {
+	if len(leaves) == 0 {
+		sum := sha256.Sum256(nil)
+		return hex.EncodeToString(sum[:])
+	}
+	lv := levels(leaves)
+	return hex.EncodeToString(lv[len(lv)-1][0])
+}
+
+// MerkleProof returns the path from leaf index to the root.
+func MerkleProof(leaves [][]byte, index int) ([]Step, error) {
+	if index < 0 || index >= len(leaves) {
+		return nil, errors.New("leaf index out of range")
+	}
+	lv := levels(leaves)
+	steps := []Step{}
+	for _, level := range lv[:len(lv)-1] {
+		sib := index ^ 1
+		if sib < len(level) {
+			steps = append(steps, Step{Hash: hex.EncodeToString(level[sib]), Left: sib < index})
+		}
+		index /= 2
+	}
+	return steps, nil
+}
+
+// VerifyMerkleProof reports whether leaf is committed to by root via steps.
+func VerifyMerkleProof(leaf []byte, steps []Step, root string) bool {
+	cur := leafHash(leaf)
+	for _, s := range steps {
+		sib, err := hex.DecodeString(s.Hash)
+		if err != nil || len(sib) != sha256.Size {
+			return false
+		}
+		if s.Left {
+			cur = innerHash(sib, cur)
+		} else {
+			cur = innerHash(cur, sib)
+		}
+	}
+	return hex.EncodeToString(cur) == root
+}
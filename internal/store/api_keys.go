@@ -0,0 +1,138 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// APIKey mirrors a row in the api_keys table.
+type APIKey struct {
+	ID         string     `json:"id"`
+	KeyHash    string     `json:"-"`
+	Identity   string     `json:"identity"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func nullAuthTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return formatAuthTime(*t)
+}
+
+func parseNullAuthTime(s sql.NullString) *time.Time {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	t := parseAuthTime(s.String)
+	return &t
+}
+
+// InsertAPIKey stores a new API key.
+func (db *DB) InsertAPIKey(ctx context.Context, k APIKey) error {
+	if db == nil || db.sql == nil {
+		return errors.New("API keys require a database")
+	}
+	_, err := db.sql.ExecContext(ctx, `
+        INSERT INTO api_keys (id, key_hash, identity, name, scopes, allowed_ips, created_at, expires_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+    `, k.ID, k.KeyHash, k.Identity, k.Name, strings.Join(k.Scopes, ","), strings.Join(k.AllowedIPs, ","),
+		formatAuthTime(k.CreatedAt), nullAuthTime(k.ExpiresAt))
+	return err
+}
+
+const apiKeyColumns = `id, key_hash, identity, name, scopes, allowed_ips, created_at, expires_at, last_used_at, revoked_at`
+
+func scanAPIKey(row interface{ Scan(...any) error }) (APIKey, error) {
+	var k APIKey
+	var scopes, ips, created string
+	var exp, used, revoked sql.NullString
+	if err := row.Scan(&k.ID, &k.KeyHash, &k.Identity, &k.Name, &scopes, &ips, &created, &exp, &used, &revoked); err != nil {
+		return APIKey{}, err
+	}
+	k.Scopes = splitList(scopes)
+	k.AllowedIPs = splitList(ips)
+	k.CreatedAt = parseAuthTime(created)
+	k.ExpiresAt = parseNullAuthTime(exp)
+	k.LastUsedAt = parseNullAuthTime(used)
+	k.RevokedAt = parseNullAuthTime(revoked)
+	return k, nil
+}
+
+// GetAPIKey returns a key by its public ID, revoked or not.
+func (db *DB) GetAPIKey(ctx context.Context, id string) (APIKey, error) {
+	if db == nil || db.sql == nil {
+		return APIKey{}, ErrNotFound
+	}
+	k, err := scanAPIKey(db.sql.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id=?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, ErrNotFound
+	}
+	return k, err
+}
+
+// ListAPIKeys returns identity's keys, newest first.
+func (db *DB) ListAPIKeys(ctx context.Context, identity string) ([]APIKey, error) {
+	if db == nil || db.sql == nil {
+		return nil, nil
+	}
+	rows, err := db.sql.QueryContext(ctx, `
+        SELECT `+apiKeyColumns+`
+        FROM api_keys WHERE identity=? ORDER BY created_at DESC
+    `, identity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
+
+// TouchAPIKey records use of a key.
+func (db *DB) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	if db == nil || db.sql == nil {
+		return nil
+	}
+	_, err := db.sql.ExecContext(ctx, `UPDATE api_keys SET last_used_at=? WHERE id=?`, formatAuthTime(at), id)
+	return err
+}
+
+// RevokeAPIKey revokes one of identity's keys and reports whether an
+// active key was revoked.
+func (db *DB) RevokeAPIKey(ctx context.Context, identity, id string, at time.Time) (bool, error) {
+	if db == nil || db.sql == nil {
+		return false, nil
+	}
+	res, err := db.sql.ExecContext(ctx, `
+        UPDATE api_keys SET revoked_at=? WHERE id=? AND identity=? AND revoked_at IS NULL
+    `, formatAuthTime(at), id, identity)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
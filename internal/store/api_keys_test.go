@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyRoundTrip(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	created := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	expires := created.Add(30 * 24 * time.Hour)
+	keys := []APIKey{
+		{ID: "a1", KeyHash: "h1", Identity: "rc:owner", Name: "bot", Scopes: []string{"read", "transfer"},
+			AllowedIPs: []string{"10.0.0.0/8", "192.0.2.1"}, CreatedAt: created, ExpiresAt: &expires},
+		{ID: "a2", KeyHash: "h2", Identity: "rc:owner", Name: "reader", Scopes: []string{"read"},
+			CreatedAt: created.Add(time.Minute)},
+	}
+	for _, k := range keys {
+		if err := db.InsertAPIKey(ctx, k); err != nil {
+			t.Fatalf("insert %s: %v", k.ID, err)
+		}
+	}
+	for _, want := range keys {
+		got, err := db.GetAPIKey(ctx, want.ID)
+		if err != nil {
+			t.Fatalf("get %s: %v", want.ID, err)
+		}
+		if !reflect.DeepEqual(got.Scopes, want.Scopes) || !reflect.DeepEqual(got.AllowedIPs, want.AllowedIPs) {
+			t.Errorf("%s: scopes %v ips %v, want %v %v", want.ID, got.Scopes, got.AllowedIPs, want.Scopes, want.AllowedIPs)
+		}
+		if (got.ExpiresAt == nil) != (want.ExpiresAt == nil) || (got.ExpiresAt != nil && !got.ExpiresAt.Equal(*want.ExpiresAt)) {
+			t.Errorf("%s: expires %v, want %v", want.ID, got.ExpiresAt, want.ExpiresAt)
+		}
+	}
+	if _, err := db.GetAPIKey(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing key: err = %v, want ErrNotFound", err)
+	}
+
+	list, err := db.ListAPIKeys(ctx, "rc:owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].ID != "a2" {
+		t.Errorf("list = %+v, want a2 then a1", list)
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	if err := db.InsertAPIKey(ctx, APIKey{ID: "k", KeyHash: "h", Identity: "rc:owner", Name: "k", Scopes: []string{"read"}, CreatedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		identity, id string
+		want         bool
+	}{
+		{"rc:other", "k", false}, // not the owner
+		{"rc:owner", "nope", false},
+		{"rc:owner", "k", true},
+		{"rc:owner", "k", false}, // already revoked
+	}
+	for i, s := range steps {
+		got, err := db.RevokeAPIKey(ctx, s.identity, s.id, now)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if got != s.want {
+			t.Errorf("step %d: revoke(%s, %s) = %v, want %v", i, s.identity, s.id, got, s.want)
+		}
+	}
+	k, err := db.GetAPIKey(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.RevokedAt == nil || !k.RevokedAt.Equal(now) {
+		t.Errorf("revoked_at = %v, want %v", k.RevokedAt, now)
+	}
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// AuditEvent mirrors a row in the audit_events table.
+type AuditEvent struct {
+	ID         int64           `json:"id"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	EventType  string          `json:"event_type"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	Actor      string          `json:"actor"`
+	CreatedAt  string          `json:"created_at"`
+}
+
+// AppendAudit records an audit event. created_at is set by the database.
+func (db *DB) AppendAudit(ctx context.Context, e AuditEvent) error {
+	if db == nil || db.sql == nil {
+		return nil
+	}
+	var payload interface{}
+	if len(e.Payload) > 0 {
+		payload = string(e.Payload)
+	}
+	_, err := db.sql.ExecContext(ctx, `
+        INSERT INTO audit_events (entity_type, entity_id, event_type, payload, actor)
+        VALUES (?, ?, ?, ?, ?)
+    `, e.EntityType, e.EntityID, e.EventType, payload, e.Actor)
+	return err
+}
+
+// ListAuditEvents returns the newest audit events first, optionally only
+// those of entityType.
+func (db *DB) ListAuditEvents(ctx context.Context, entityType string, limit int) ([]AuditEvent, error) {
+	if db == nil || db.sql == nil {
+		return nil, nil
+	}
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	rows, err := db.sql.QueryContext(ctx, `
+        SELECT id, entity_type, COALESCE(entity_id, ''), event_type, COALESCE(payload, ''),
+               COALESCE(actor, ''), COALESCE(created_at, '')
+        FROM audit_events WHERE ? = '' OR entity_type = ?
+        ORDER BY id DESC LIMIT ?
+    `, entityType, entityType, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []AuditEvent{}
+	for rows.Next() {
+		var e AuditEvent
+		var payload string
+		if err := rows.Scan(&e.ID, &e.EntityType, &e.EntityID, &e.EventType, &payload, &e.Actor, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if payload != "" {
+			e.Payload = json.RawMessage(payload)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
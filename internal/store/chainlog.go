@@ -4,7 +4,7 @@ import (
     "context"
     "database/sql"
     "encoding/json"
-    "log"
+    "fmt"
     "time"
 )
 
@@ -17,6 +17,7 @@ type ChainBlockRow struct {
     TxType     string
     Nonce      uint64
     Difficulty uint32
+    StateRoot  string
 }
 
 // ChainTxRow mirrors the chain_tx table.
@@ -28,9 +29,11 @@ type ChainTxRow struct {
     BodyJSON    string
 }
 
-// InsertBlockAndTx persists a single block + tx into the chain log tables.
+// InsertBlockAndTxAt persists a single block + tx into the chain log
+// tables. ts is the block's own timestamp, stored as given so blocks
+// received from peers or imported from an export keep theirs.
 // txBody is the Go struct which will be JSON-encoded into body_json.
-func (db *DB) InsertBlockAndTx(ctx context.Context, blkHash string, prevHash string, height uint64, txType string, nonce uint64, difficulty uint32, txBody interface{}) error {
+func (db *DB) InsertBlockAndTxAt(ctx context.Context, ts time.Time, blkHash string, prevHash string, height uint64, txType string, nonce uint64, difficulty uint32, stateRoot string, txBody interface{}) error {
     if db == nil || db.sql == nil {
         return nil
     }
@@ -47,13 +50,7 @@ func (db *DB) InsertBlockAndTx(ctx context.Context, blkHash string, prevHash str
     if err != nil {
         return err
     }
-    defer func() {
-        if err != nil {
-            _ = sqlTx.Rollback()
-        } else {
-            _ = sqlTx.Commit()
-        }
-    }()
+    defer sqlTx.Rollback()
 
     var prevHashPtr *string
     if prevHash != "" {
@@ -61,19 +58,19 @@ func (db *DB) InsertBlockAndTx(ctx context.Context, blkHash string, prevHash str
     }
 
     _, err = sqlTx.ExecContext(ctx,
-        `INSERT OR REPLACE INTO chain_blocks (height, hash, prev_hash, timestamp, tx_type, nonce, difficulty)
-         VALUES (?, ?, ?, ?, ?, ?, ?)`,
+        `INSERT OR REPLACE INTO chain_blocks (height, hash, prev_hash, timestamp, tx_type, nonce, difficulty, state_root)
+         VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
         height,
         blkHash,
         prevHashPtr,
-        time.Now().UTC().Format(time.RFC3339),
+        ts.UTC().Format(time.RFC3339Nano),
         txType,
         nonce,
         difficulty,
+        stateRoot,
     )
     if err != nil {
-        log.Printf("[store] insert chain_blocks failed: %v", err)
-        return err
+        return fmt.Errorf("insert chain_blocks: %w", err)
     }
 
     res, err := sqlTx.ExecContext(ctx,
@@ -85,15 +82,16 @@ func (db *DB) InsertBlockAndTx(ctx context.Context, blkHash string, prevHash str
         string(payload),
     )
     if err != nil {
-        log.Printf("[store] insert chain_tx failed: %v", err)
-        return err
+        return fmt.Errorf("insert chain_tx: %w", err)
     }
     txID, _ := res.LastInsertId()
 
-    // Optionally route into typed tables based on txType later.
-    _ = txID
+    // Typed projections + address index, in the same transaction.
+    if err = indexTx(ctx, sqlTx, txID, height, txType, payload); err != nil {
+        return fmt.Errorf("index chain_tx %d: %w", txID, err)
+    }
 
-    return nil
+    return sqlTx.Commit()
 }
 
 // LoadAllBlocks loads all chain_blocks + chain_tx rows ordered by height and
@@ -105,7 +103,7 @@ func (db *DB) LoadAllBlocks(ctx context.Context) ([]ChainBlockRow, []ChainTxRow,
     }
 
     rows, err := db.sql.QueryContext(ctx,
-        `SELECT height, hash, prev_hash, timestamp, tx_type, nonce, difficulty
+        `SELECT height, hash, prev_hash, timestamp, tx_type, nonce, difficulty, COALESCE(state_root, '')
          FROM chain_blocks
          ORDER BY height ASC`)
     if err != nil {
@@ -117,7 +115,7 @@ func (db *DB) LoadAllBlocks(ctx context.Context) ([]ChainBlockRow, []ChainTxRow,
     for rows.Next() {
         var r ChainBlockRow
         var ts string
-        if err := rows.Scan(&r.Height, &r.Hash, &r.PrevHash, &ts, &r.TxType, &r.Nonce, &r.Difficulty); err != nil {
+        if err := rows.Scan(&r.Height, &r.Hash, &r.PrevHash, &ts, &r.TxType, &r.Nonce, &r.Difficulty, &r.StateRoot); err != nil {
             return nil, nil, err
         }
         // Parse timestamp but don't fail hard if it is malformed.
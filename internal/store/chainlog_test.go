@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "chain.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := EnsureSchemaFromFile(db, "../../database/schema.sql"); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestInsertBlockKeepsTimestamp(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	stamps := []time.Time{
+		time.Date(2024, 3, 1, 12, 0, 0, 123456789, time.UTC),
+		time.Date(2024, 3, 1, 12, 0, 7, 0, time.UTC),
+	}
+	prev := ""
+	for i, ts := range stamps {
+		hash := "h" + string(rune('0'+i))
+		body := json.RawMessage(`{"note":"test"}`)
+		if err := db.InsertBlockAndTxAt(ctx, ts, hash, prev, uint64(i), "genesis", 0, 0, "", body); err != nil {
+			t.Fatalf("insert block %d: %v", i, err)
+		}
+		prev = hash
+	}
+
+	rows, _, err := db.LoadAllBlocks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(stamps) {
+		t.Fatalf("loaded %d blocks, want %d", len(rows), len(stamps))
+	}
+	for i, r := range rows {
+		if !r.Timestamp.Equal(stamps[i]) {
+			t.Errorf("block %d: timestamp %s, want %s", r.Height, r.Timestamp.Format(time.RFC3339Nano), stamps[i].Format(time.RFC3339Nano))
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// eventTimeLayout is fixed-width so created_at sorts lexicographically.
+const eventTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// EventRow mirrors a row in the event_journal table.
+type EventRow struct {
+	Seq       uint64    `json:"seq"`
+	EventID   string    `json:"event_id"`
+	EventType string    `json:"event_type"`
+	Version   string    `json:"version"`
+	Payload   []byte    `json:"payload"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AppendEvent stores one journal entry under its sequence number.
+func (db *DB) AppendEvent(ctx context.Context, e EventRow) error {
+	if db == nil || db.sql == nil {
+		return nil
+	}
+	_, err := db.sql.ExecContext(ctx, `
+        INSERT OR REPLACE INTO event_journal (seq, event_id, event_type, version, payload, created_at)
+        VALUES (?, ?, ?, ?, ?, ?)
+    `, e.Seq, e.EventID, e.EventType, e.Version, string(e.Payload), e.CreatedAt.UTC().Format(eventTimeLayout))
+	return err
+}
+
+// ListEventsSince returns up to limit events with seq > after, oldest first.
+func (db *DB) ListEventsSince(ctx context.Context, after uint64, limit int) ([]EventRow, error) {
+	if db == nil || db.sql == nil {
+		return nil, nil
+	}
+	if limit <= 0 || limit > 10000 {
+		limit = 1000
+	}
+	rows, err := db.sql.QueryContext(ctx, `
+        SELECT seq, event_id, event_type, version, payload, created_at
+        FROM event_journal WHERE seq > ? ORDER BY seq ASC LIMIT ?
+    `, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []EventRow{}
+	for rows.Next() {
+		var e EventRow
+		var payload, created string
+		if err := rows.Scan(&e.Seq, &e.EventID, &e.EventType, &e.Version, &payload, &created); err != nil {
+			continue
+		}
+		e.Payload = []byte(payload)
+		if t, err := time.Parse(eventTimeLayout, created); err == nil {
+			e.CreatedAt = t
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// EventSeqRange returns the lowest and highest journalled sequence numbers
+// (0, 0 when the journal is empty).
+func (db *DB) EventSeqRange(ctx context.Context) (uint64, uint64, error) {
+	if db == nil || db.sql == nil {
+		return 0, 0, nil
+	}
+	var lo, hi sql.NullInt64
+	err := db.sql.QueryRowContext(ctx, `SELECT MIN(seq), MAX(seq) FROM event_journal`).Scan(&lo, &hi)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint64(lo.Int64), uint64(hi.Int64), nil
+}
+
+// PruneEvents deletes journal entries created before cutoff.
+func (db *DB) PruneEvents(ctx context.Context, cutoff time.Time) (int64, error) {
+	if db == nil || db.sql == nil {
+		return 0, nil
+	}
+	res, err := db.sql.ExecContext(ctx, `DELETE FROM event_journal WHERE created_at < ?`,
+		cutoff.UTC().Format(eventTimeLayout))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
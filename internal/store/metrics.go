@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+
+	"reservechain/internal/logging"
+	"reservechain/internal/metrics"
+)
+
+var storeLog = logging.For("store")
+
+var sqliteWriteErrors = metrics.NewCounterVec("reservechain_sqlite_write_errors_total",
+	"SQLite writes that failed without failing the caller, by operation.", "op")
+
+// LogWriteError records a failed write whose caller carries on regardless
+// (derived tables, audit rows): it is logged with ctx's request ID and tx
+// hash and counted under op rather than discarded. A nil err is a no-op.
+func LogWriteError(ctx context.Context, op string, err error) {
+	if err == nil {
+		return
+	}
+	sqliteWriteErrors.Inc(op)
+	storeLog.ErrorContext(ctx, "write failed", "op", op, logging.Err(err))
+}
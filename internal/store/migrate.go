@@ -7,12 +7,16 @@ import (
 	"io"
 	"os"
 	"strings"
+
+	"reservechain/internal/logging"
 )
 
 // EnsureSchemaFromFile applies the SQL schema file if it looks like the DB is uninitialized.
 // This is a DevNet convenience to reduce manual setup friction.
 //
 // It checks for existence of a core table ("wallets"). If missing, it will execute the schema.
+// On an existing DB every statement is re-applied individually (the schema only
+// uses IF NOT EXISTS), so tables added to schema.sql later are still created.
 func EnsureSchemaFromFile(db *DB, schemaPath string) error {
 	if db == nil || db.sql == nil {
 		return nil
@@ -22,9 +26,6 @@ func EnsureSchemaFromFile(db *DB, schemaPath string) error {
 	if err != nil {
 		return err
 	}
-	if has {
-		return nil
-	}
 
 	f, err := os.Open(schemaPath)
 	if err != nil {
@@ -40,6 +41,15 @@ func EnsureSchemaFromFile(db *DB, schemaPath string) error {
 		return errors.New("schema file contained no statements")
 	}
 
+	if has {
+		for _, s := range stmts {
+			if _, err := db.sql.Exec(s); err != nil {
+				storeLog.Warn("schema statement skipped", logging.Err(err))
+			}
+		}
+		return ensureAddedColumns(db.sql)
+	}
+
 	tx, err := db.sql.Begin()
 	if err != nil {
 		return err
@@ -56,6 +66,51 @@ func EnsureSchemaFromFile(db *DB, schemaPath string) error {
 	return tx.Commit()
 }
 
+// addedColumns lists columns introduced after their table first shipped.
+// CREATE TABLE IF NOT EXISTS leaves existing tables untouched, so these are
+// added explicitly on older DBs.
+var addedColumns = []struct {
+	table, column, decl string
+}{
+	{"p2p_peers", "pubkey", "TEXT"},
+	{"pop_nodes", "node_pubkey", "TEXT"},
+	{"chain_blocks", "state_root", "TEXT"},
+}
+
+func ensureAddedColumns(db *sql.DB) error {
+	for _, c := range addedColumns {
+		has, err := hasColumn(db, c.table, c.column)
+		if err != nil {
+			return err
+		}
+		if has {
+			continue
+		}
+		if _, err := db.Exec(`ALTER TABLE ` + c.table + ` ADD COLUMN ` + c.column + ` ` + c.decl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasColumn(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
 func hasTable(db *sql.DB, table string) (bool, error) {
 	row := db.QueryRow(`SELECT 1 FROM sqlite_master WHERE type='table' AND name=? LIMIT 1`, table)
 	var one int
@@ -0,0 +1,206 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Peer sources describe how this node learned about an address.
+const (
+	PeerSourceRegistered = "registered" // peer registered itself with this seed
+	PeerSourceDiscovered = "discovered" // learned from a seed's /api/p2p/peers
+	PeerSourceStatic     = "static"     // configured via node.peers
+	PeerSourceSeed       = "seed"       // configured via p2p.seed_nodes
+)
+
+// Peer statuses. Only "live" peers are advertised to other nodes.
+const (
+	PeerStatusPending     = "pending"
+	PeerStatusLive        = "live"
+	PeerStatusUnreachable = "unreachable"
+)
+
+// PeerRecord mirrors a row in the p2p_peers table.
+type PeerRecord struct {
+	Addr      string     `json:"addr"`
+	NodeID    string     `json:"node_id"`
+	PubKey    string     `json:"pubkey"`
+	Version   string     `json:"version"`
+	ChainID   string     `json:"chain_id"`
+	Source    string     `json:"source"`
+	Status    string     `json:"status"`
+	Failures  int        `json:"failures"`
+	LastError string     `json:"last_error,omitempty"`
+	LastSeen  *time.Time `json:"last_seen,omitempty"`
+	LastProbe *time.Time `json:"last_probe,omitempty"`
+}
+
+// UpsertPeer inserts a peer address if it is unknown. Existing rows keep
+// their handshake data and liveness state; only the source is refreshed
+// so a static/seed entry is not downgraded by a later discovery.
+func (db *DB) UpsertPeer(ctx context.Context, addr, source string) error {
+	if db == nil || db.sql == nil {
+		return nil
+	}
+	if addr == "" {
+		return errors.New("addr required")
+	}
+	_, err := db.sql.ExecContext(ctx, `
+        INSERT INTO p2p_peers (addr, source, status, failures, created_at, updated_at)
+        VALUES (?, ?, ?, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+        ON CONFLICT(addr) DO UPDATE SET
+            source=CASE WHEN p2p_peers.source IN ('static', 'seed') THEN p2p_peers.source ELSE excluded.source END,
+            updated_at=CURRENT_TIMESTAMP
+    `, addr, source, PeerStatusPending)
+	return err
+}
+
+// MarkPeerLive records a successful (signature-verified) handshake with a peer.
+func (db *DB) MarkPeerLive(ctx context.Context, addr, nodeID, pubKey, version, chainID string, at time.Time) error {
+	if db == nil || db.sql == nil {
+		return nil
+	}
+	ts := at.UTC().Format(time.RFC3339)
+	_, err := db.sql.ExecContext(ctx, `
+        UPDATE p2p_peers SET
+            node_id=?, pubkey=?, version=?, chain_id=?, status=?, failures=0, last_error='',
+            last_seen=?, last_probe=?, updated_at=CURRENT_TIMESTAMP
+        WHERE addr=?
+    `, nodeID, pubKey, version, chainID, PeerStatusLive, ts, ts, addr)
+	return err
+}
+
+// MarkPeerFailed records a failed probe or sync attempt against a peer.
+func (db *DB) MarkPeerFailed(ctx context.Context, addr, reason string, at time.Time) error {
+	if db == nil || db.sql == nil {
+		return nil
+	}
+	_, err := db.sql.ExecContext(ctx, `
+        UPDATE p2p_peers SET
+            status=?, failures=failures+1, last_error=?, last_probe=?, updated_at=CURRENT_TIMESTAMP
+        WHERE addr=?
+    `, PeerStatusUnreachable, reason, at.UTC().Format(time.RFC3339), addr)
+	return err
+}
+
+// GetPeer returns a single peer record by address.
+func (db *DB) GetPeer(ctx context.Context, addr string) (PeerRecord, error) {
+	if db == nil || db.sql == nil {
+		return PeerRecord{}, ErrNotFound
+	}
+	row := db.sql.QueryRowContext(ctx, `
+        SELECT addr, node_id, pubkey, version, chain_id, source, status, failures, last_error, last_seen, last_probe
+        FROM p2p_peers WHERE addr=?
+    `, addr)
+	p, err := scanPeer(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return PeerRecord{}, ErrNotFound
+	}
+	return p, err
+}
+
+// ListPeers returns persisted peers, optionally filtered by source and/or
+// status, most recently seen first.
+func (db *DB) ListPeers(ctx context.Context, source, status string, limit int) ([]PeerRecord, error) {
+	if db == nil || db.sql == nil {
+		return nil, nil
+	}
+	if limit <= 0 || limit > 1000 {
+		limit = 256
+	}
+	q := `SELECT addr, node_id, pubkey, version, chain_id, source, status, failures, last_error, last_seen, last_probe
+          FROM p2p_peers WHERE 1=1`
+	args := []any{}
+	if source != "" {
+		q += ` AND source=?`
+		args = append(args, source)
+	}
+	if status != "" {
+		q += ` AND status=?`
+		args = append(args, status)
+	}
+	q += ` ORDER BY last_seen DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.sql.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []PeerRecord{}
+	for rows.Next() {
+		p, err := scanPeer(rows)
+		if err != nil {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// CountPeersByStatus returns the number of persisted peers per status.
+func (db *DB) CountPeersByStatus(ctx context.Context) (map[string]int, error) {
+	out := map[string]int{}
+	if db == nil || db.sql == nil {
+		return out, nil
+	}
+	rows, err := db.sql.QueryContext(ctx, `SELECT status, COUNT(*) FROM p2p_peers GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		out[status] = n
+	}
+	return out, rows.Err()
+}
+
+// PrunePeers deletes non-static peers that have not been seen since the
+// given cutoff and have failed at least minFailures consecutive probes.
+func (db *DB) PrunePeers(ctx context.Context, cutoff time.Time, minFailures int) (int64, error) {
+	if db == nil || db.sql == nil {
+		return 0, nil
+	}
+	res, err := db.sql.ExecContext(ctx, `
+        DELETE FROM p2p_peers
+        WHERE source NOT IN ('static', 'seed')
+          AND failures >= ?
+          AND (last_seen IS NULL OR last_seen < ?)
+    `, minFailures, cutoff.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPeer(row rowScanner) (PeerRecord, error) {
+	var p PeerRecord
+	var nodeID, pubKey, version, chainID, lastErr, lastSeen, lastProbe sql.NullString
+	if err := row.Scan(&p.Addr, &nodeID, &pubKey, &version, &chainID, &p.Source, &p.Status, &p.Failures, &lastErr, &lastSeen, &lastProbe); err != nil {
+		return PeerRecord{}, err
+	}
+	p.NodeID = nodeID.String
+	p.PubKey = pubKey.String
+	p.Version = version.String
+	p.ChainID = chainID.String
+	p.LastError = lastErr.String
+	if t, err := time.Parse(time.RFC3339, lastSeen.String); err == nil {
+		p.LastSeen = &t
+	}
+	if t, err := time.Parse(time.RFC3339, lastProbe.String); err == nil {
+		p.LastProbe = &t
+	}
+	return p, nil
+}
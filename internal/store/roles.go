@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+// RoleGrant mirrors a row in the role_grants table.
+type RoleGrant struct {
+	Identity  string `json:"identity"`
+	Role      string `json:"role"`
+	GrantedBy string `json:"granted_by"`
+	CreatedAt string `json:"created_at"`
+}
+
+// GrantRole gives identity a role. Granting a role the identity already
+// holds is a no-op and keeps the original grant.
+func (db *DB) GrantRole(ctx context.Context, identity, role, grantedBy string) error {
+	if db == nil || db.sql == nil {
+		return errors.New("role grants require a database")
+	}
+	if identity == "" || role == "" {
+		return errors.New("identity and role required")
+	}
+	_, err := db.sql.ExecContext(ctx, `
+        INSERT INTO role_grants (identity, role, granted_by, created_at)
+        VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+        ON CONFLICT(identity, role) DO NOTHING
+    `, identity, role, grantedBy)
+	return err
+}
+
+// RevokeRole removes a role from identity and reports whether it was held.
+func (db *DB) RevokeRole(ctx context.Context, identity, role string) (bool, error) {
+	if db == nil || db.sql == nil {
+		return false, errors.New("role grants require a database")
+	}
+	res, err := db.sql.ExecContext(ctx, `DELETE FROM role_grants WHERE identity=? AND role=?`, identity, role)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RolesFor returns the roles granted to identity.
+func (db *DB) RolesFor(ctx context.Context, identity string) ([]string, error) {
+	if db == nil || db.sql == nil {
+		return nil, nil
+	}
+	rows, err := db.sql.QueryContext(ctx, `SELECT role FROM role_grants WHERE identity=? ORDER BY role`, identity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		out = append(out, role)
+	}
+	return out, rows.Err()
+}
+
+// ListRoleGrants returns every grant, optionally only those for role.
+func (db *DB) ListRoleGrants(ctx context.Context, role string) ([]RoleGrant, error) {
+	if db == nil || db.sql == nil {
+		return nil, nil
+	}
+	rows, err := db.sql.QueryContext(ctx, `
+        SELECT identity, role, granted_by, COALESCE(created_at, '')
+        FROM role_grants WHERE ? = '' OR role = ?
+        ORDER BY role, identity
+    `, role, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []RoleGrant{}
+	for rows.Next() {
+		var g RoleGrant
+		if err := rows.Scan(&g.Identity, &g.Role, &g.GrantedBy, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,210 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// AuthChallenge mirrors a row in the auth_challenges table.
+type AuthChallenge struct {
+	Identity  string
+	Challenge string
+	ExpiresAt time.Time
+}
+
+// AuthSession mirrors a row in the auth_sessions table. ID is the hex
+// SHA-256 of the session token.
+type AuthSession struct {
+	ID           string    `json:"id"`
+	Identity     string    `json:"identity"`
+	Role         string    `json:"role"`
+	DeviceName   string    `json:"device_name,omitempty"`
+	UserAgent    string    `json:"user_agent,omitempty"`
+	IP           string    `json:"ip,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastSeenAt   time.Time `json:"last_seen_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	MaxExpiresAt time.Time `json:"max_expires_at"`
+}
+
+func formatAuthTime(t time.Time) string { return t.UTC().Format(eventTimeLayout) }
+
+func parseAuthTime(s string) time.Time {
+	t, _ := time.Parse(eventTimeLayout, s)
+	return t
+}
+
+// PutAuthChallenge stores the login challenge for an identity, replacing
+// any earlier one.
+func (db *DB) PutAuthChallenge(ctx context.Context, c AuthChallenge) error {
+	if db == nil || db.sql == nil {
+		return errors.New("auth store requires a database")
+	}
+	_, err := db.sql.ExecContext(ctx, `
+        INSERT INTO auth_challenges (identity, challenge, expires_at) VALUES (?, ?, ?)
+        ON CONFLICT(identity) DO UPDATE SET challenge=excluded.challenge, expires_at=excluded.expires_at
+    `, c.Identity, c.Challenge, formatAuthTime(c.ExpiresAt))
+	return err
+}
+
+// GetAuthChallenge returns the pending challenge for identity.
+func (db *DB) GetAuthChallenge(ctx context.Context, identity string) (AuthChallenge, error) {
+	if db == nil || db.sql == nil {
+		return AuthChallenge{}, ErrNotFound
+	}
+	c := AuthChallenge{Identity: identity}
+	var exp string
+	err := db.sql.QueryRowContext(ctx, `SELECT challenge, expires_at FROM auth_challenges WHERE identity=?`, identity).
+		Scan(&c.Challenge, &exp)
+	if errors.Is(err, sql.ErrNoRows) {
+		return AuthChallenge{}, ErrNotFound
+	}
+	if err != nil {
+		return AuthChallenge{}, err
+	}
+	c.ExpiresAt = parseAuthTime(exp)
+	return c, nil
+}
+
+// ConsumeAuthChallenge deletes identity's challenge if it still equals
+// challenge, reporting whether it did. Only one login can consume it.
+func (db *DB) ConsumeAuthChallenge(ctx context.Context, identity, challenge string) (bool, error) {
+	if db == nil || db.sql == nil {
+		return false, nil
+	}
+	res, err := db.sql.ExecContext(ctx, `DELETE FROM auth_challenges WHERE identity=? AND challenge=?`, identity, challenge)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// InsertAuthSession stores a new session.
+func (db *DB) InsertAuthSession(ctx context.Context, s AuthSession) error {
+	if db == nil || db.sql == nil {
+		return errors.New("auth store requires a database")
+	}
+	_, err := db.sql.ExecContext(ctx, `
+        INSERT INTO auth_sessions (id, identity, role, device_name, user_agent, ip,
+                                   created_at, last_seen_at, expires_at, max_expires_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, s.ID, s.Identity, s.Role, s.DeviceName, s.UserAgent, s.IP,
+		formatAuthTime(s.CreatedAt), formatAuthTime(s.LastSeenAt),
+		formatAuthTime(s.ExpiresAt), formatAuthTime(s.MaxExpiresAt))
+	return err
+}
+
+const authSessionColumns = `id, identity, role, COALESCE(device_name, ''), COALESCE(user_agent, ''), COALESCE(ip, ''),
+               created_at, last_seen_at, expires_at, max_expires_at`
+
+func scanAuthSession(row interface{ Scan(...any) error }) (AuthSession, error) {
+	var s AuthSession
+	var created, seen, exp, maxExp string
+	if err := row.Scan(&s.ID, &s.Identity, &s.Role, &s.DeviceName, &s.UserAgent, &s.IP,
+		&created, &seen, &exp, &maxExp); err != nil {
+		return AuthSession{}, err
+	}
+	s.CreatedAt = parseAuthTime(created)
+	s.LastSeenAt = parseAuthTime(seen)
+	s.ExpiresAt = parseAuthTime(exp)
+	s.MaxExpiresAt = parseAuthTime(maxExp)
+	return s, nil
+}
+
+// GetAuthSession returns a session by ID, expired or not.
+func (db *DB) GetAuthSession(ctx context.Context, id string) (AuthSession, error) {
+	if db == nil || db.sql == nil {
+		return AuthSession{}, ErrNotFound
+	}
+	s, err := scanAuthSession(db.sql.QueryRowContext(ctx, `
+        SELECT `+authSessionColumns+`
+        FROM auth_sessions WHERE id=?
+    `, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return AuthSession{}, ErrNotFound
+	}
+	return s, err
+}
+
+// TouchAuthSession records use of a session and moves its sliding expiry.
+func (db *DB) TouchAuthSession(ctx context.Context, id string, seen, expires time.Time) error {
+	if db == nil || db.sql == nil {
+		return nil
+	}
+	_, err := db.sql.ExecContext(ctx, `UPDATE auth_sessions SET last_seen_at=?, expires_at=? WHERE id=?`,
+		formatAuthTime(seen), formatAuthTime(expires), id)
+	return err
+}
+
+// ListAuthSessions returns identity's sessions, most recently used first.
+func (db *DB) ListAuthSessions(ctx context.Context, identity string) ([]AuthSession, error) {
+	if db == nil || db.sql == nil {
+		return nil, nil
+	}
+	rows, err := db.sql.QueryContext(ctx, `
+        SELECT `+authSessionColumns+`
+        FROM auth_sessions WHERE identity=? ORDER BY last_seen_at DESC
+    `, identity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []AuthSession{}
+	for rows.Next() {
+		s, err := scanAuthSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// DeleteAuthSession removes a session and reports whether it existed.
+func (db *DB) DeleteAuthSession(ctx context.Context, id string) (bool, error) {
+	if db == nil || db.sql == nil {
+		return false, nil
+	}
+	res, err := db.sql.ExecContext(ctx, `DELETE FROM auth_sessions WHERE id=?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteAuthSessionsFor removes every session of identity except the one
+// with ID except (which may be empty) and returns how many were removed.
+func (db *DB) DeleteAuthSessionsFor(ctx context.Context, identity, except string) (int64, error) {
+	if db == nil || db.sql == nil {
+		return 0, nil
+	}
+	res, err := db.sql.ExecContext(ctx, `DELETE FROM auth_sessions WHERE identity=? AND id<>?`, identity, except)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PruneAuth deletes challenges and sessions that expired before now.
+func (db *DB) PruneAuth(ctx context.Context, now time.Time) (int64, error) {
+	if db == nil || db.sql == nil {
+		return 0, nil
+	}
+	ts := formatAuthTime(now)
+	res, err := db.sql.ExecContext(ctx, `DELETE FROM auth_challenges WHERE expires_at < ?`, ts)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	res, err = db.sql.ExecContext(ctx, `DELETE FROM auth_sessions WHERE expires_at < ?`, ts)
+	if err != nil {
+		return n, err
+	}
+	m, _ := res.RowsAffected()
+	return n + m, nil
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAuthSessionTouchAndPrune(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	sessions := []AuthSession{
+		{ID: "live", Identity: "rc:a", Role: "client", CreatedAt: now, LastSeenAt: now,
+			ExpiresAt: now.Add(time.Minute), MaxExpiresAt: now.Add(24 * time.Hour)},
+		{ID: "idle", Identity: "rc:a", Role: "client", CreatedAt: now, LastSeenAt: now,
+			ExpiresAt: now.Add(time.Minute), MaxExpiresAt: now.Add(24 * time.Hour)},
+	}
+	for _, s := range sessions {
+		if err := db.InsertAuthSession(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Using "live" slides its expiry past the prune time; "idle" lapses.
+	seen := now.Add(30 * time.Second)
+	if err := db.TouchAuthSession(ctx, "live", seen, seen.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := db.GetAuthSession(ctx, "live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.LastSeenAt.Equal(seen) || !got.ExpiresAt.Equal(seen.Add(time.Hour)) || !got.MaxExpiresAt.Equal(now.Add(24*time.Hour)) {
+		t.Errorf("after touch: seen %s expires %s max %s", got.LastSeenAt, got.ExpiresAt, got.MaxExpiresAt)
+	}
+
+	n, err := db.PruneAuth(ctx, now.Add(10*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("pruned %d rows, want 1", n)
+	}
+	tests := []struct {
+		id   string
+		kept bool
+	}{
+		{"live", true},
+		{"idle", false},
+	}
+	for _, tt := range tests {
+		_, err := db.GetAuthSession(ctx, tt.id)
+		if kept := err == nil; kept != tt.kept {
+			t.Errorf("%s: kept = %v, want %v (err %v)", tt.id, kept, tt.kept, err)
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: err = %v, want ErrNotFound", tt.id, err)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// StateSnapshotRow mirrors a row in the state_snapshots table. Data holds
+// the JSON-encoded core.StateSnapshot; it is left opaque here.
+type StateSnapshotRow struct {
+	Height    uint64    `json:"height"`
+	BlockHash string    `json:"block_hash"`
+	StateRoot string    `json:"state_root"`
+	Size      int       `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      []byte    `json:"-"`
+}
+
+// SaveStateSnapshot stores a snapshot and keeps only the newest `keep`
+// snapshots (all of them when keep <= 0).
+func (db *DB) SaveStateSnapshot(ctx context.Context, s StateSnapshotRow, keep int) error {
+	if db == nil || db.sql == nil {
+		return nil
+	}
+	if s.BlockHash == "" || s.StateRoot == "" || len(s.Data) == 0 {
+		return errors.New("block_hash, state_root and data required")
+	}
+	_, err := db.sql.ExecContext(ctx, `
+        INSERT OR REPLACE INTO state_snapshots (height, block_hash, state_root, data, created_at)
+        VALUES (?, ?, ?, ?, ?)
+    `, s.Height, s.BlockHash, s.StateRoot, s.Data, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+	if keep > 0 {
+		_, err = db.sql.ExecContext(ctx, `
+            DELETE FROM state_snapshots
+            WHERE height NOT IN (SELECT height FROM state_snapshots ORDER BY height DESC LIMIT ?)
+        `, keep)
+	}
+	return err
+}
+
+// GetStateSnapshot returns the snapshot taken at height, including data.
+func (db *DB) GetStateSnapshot(ctx context.Context, height uint64) (StateSnapshotRow, error) {
+	if db == nil || db.sql == nil {
+		return StateSnapshotRow{}, ErrNotFound
+	}
+	row := db.sql.QueryRowContext(ctx, `
+        SELECT height, block_hash, state_root, data, created_at
+        FROM state_snapshots WHERE height=?
+    `, height)
+	return scanStateSnapshot(row)
+}
+
+// LatestStateSnapshot returns the most recent snapshot, including data.
+func (db *DB) LatestStateSnapshot(ctx context.Context) (StateSnapshotRow, error) {
+	if db == nil || db.sql == nil {
+		return StateSnapshotRow{}, ErrNotFound
+	}
+	row := db.sql.QueryRowContext(ctx, `
+        SELECT height, block_hash, state_root, data, created_at
+        FROM state_snapshots ORDER BY height DESC LIMIT 1
+    `)
+	return scanStateSnapshot(row)
+}
+
+func scanStateSnapshot(row rowScanner) (StateSnapshotRow, error) {
+	var s StateSnapshotRow
+	var created sql.NullString
+	if err := row.Scan(&s.Height, &s.BlockHash, &s.StateRoot, &s.Data, &created); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return StateSnapshotRow{}, ErrNotFound
+		}
+		return StateSnapshotRow{}, err
+	}
+	s.Size = len(s.Data)
+	if t, err := time.Parse(time.RFC3339, created.String); err == nil {
+		s.CreatedAt = t
+	}
+	return s, nil
+}
+
+// ResetChainLog deletes every persisted block and tx. It is used before a
+// fresh node restores from a snapshot so the log starts at the snapshot.
+func (db *DB) ResetChainLog(ctx context.Context) error {
+	if db == nil || db.sql == nil {
+		return nil
+	}
+	tx, err := db.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, q := range []string{`DELETE FROM chain_tx`, `DELETE FROM chain_blocks`} {
+		if _, err := tx.ExecContext(ctx, q); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ReplaceStakes replaces every RSX stake position with the given set.
+func (db *DB) ReplaceStakes(ctx context.Context, stakes []StakePosition) error {
+	if db == nil || db.sql == nil {
+		return nil
+	}
+	tx, err := db.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM rsx_stakes`); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	for _, s := range stakes {
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO rsx_stakes (staker_wallet, validator_id, amount_rsx, lock_until_epoch, updated_at)
+            VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+        `, s.StakerWallet, s.ValidatorID, s.AmountRSX, s.LockUntilEpoch); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ReplacePoPRegistry replaces every PoP node registration and capability row.
+func (db *DB) ReplacePoPRegistry(ctx context.Context, nodes []PoPNode, caps []PoPCapability) error {
+	if db == nil || db.sql == nil {
+		return nil
+	}
+	tx, err := db.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	fail := func(err error) error {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM pop_node_caps`); err != nil {
+		return fail(err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM pop_nodes`); err != nil {
+		return fail(err)
+	}
+	for _, n := range nodes {
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO pop_nodes (node_id, operator_wallet, role, node_pubkey, updated_at)
+            VALUES (?, ?, ?, NULLIF(?, ''), CURRENT_TIMESTAMP)
+        `, n.NodeID, n.OperatorWallet, n.Role, n.NodePubKey); err != nil {
+			return fail(err)
+		}
+	}
+	for _, c := range caps {
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO pop_node_caps (node_id, cpu_score, ram_score, storage_score, bandwidth_score, updated_at)
+            VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+        `, c.NodeID, c.CPUScore, c.RAMScore, c.StorageScore, c.BandwidthScore); err != nil {
+			return fail(err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListPoPCapabilities returns every node capability row.
+func (db *DB) ListPoPCapabilities(ctx context.Context) ([]PoPCapability, error) {
+	if db == nil || db.sql == nil {
+		return nil, nil
+	}
+	rows, err := db.sql.QueryContext(ctx, `SELECT node_id, cpu_score, ram_score, storage_score, bandwidth_score FROM pop_node_caps`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []PoPCapability{}
+	for rows.Next() {
+		var c PoPCapability
+		if err := rows.Scan(&c.NodeID, &c.CPUScore, &c.RAMScore, &c.StorageScore, &c.BandwidthScore); err != nil {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
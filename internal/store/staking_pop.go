@@ -27,6 +27,7 @@ type PoPNode struct {
 	NodeID         string `json:"node_id"`
 	OperatorWallet string `json:"operator_wallet"`
 	Role           string `json:"role"`
+	NodePubKey     string `json:"node_pubkey,omitempty"`
 }
 
 type PoPCapability struct {
@@ -119,6 +120,22 @@ func (db *DB) ListValidators(ctx context.Context) ([]Validator, error) {
 	return out, nil
 }
 
+// GetValidator returns one validator by ID.
+func (db *DB) GetValidator(ctx context.Context, id string) (Validator, error) {
+	var v Validator
+	if db == nil || db.sql == nil {
+		return v, ErrNotFound
+	}
+	row := db.sql.QueryRowContext(ctx, `SELECT validator_id, operator_wallet, commission_bps, status FROM rsx_validators WHERE validator_id=?`, id)
+	if err := row.Scan(&v.ValidatorID, &v.OperatorWallet, &v.CommissionBps, &v.Status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return v, ErrNotFound
+		}
+		return v, err
+	}
+	return v, nil
+}
+
 func (db *DB) UpsertStake(ctx context.Context, s StakePosition) error {
 	if db == nil || db.sql == nil {
 		return nil
@@ -235,13 +252,14 @@ func (db *DB) UpsertPoPNode(ctx context.Context, n PoPNode) error {
 		return errors.New("node_id and operator_wallet required")
 	}
 	_, err := db.sql.ExecContext(ctx, `
-        INSERT INTO pop_nodes (node_id, operator_wallet, role, updated_at)
-        VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+        INSERT INTO pop_nodes (node_id, operator_wallet, role, node_pubkey, updated_at)
+        VALUES (?, ?, ?, NULLIF(?, ''), CURRENT_TIMESTAMP)
         ON CONFLICT(node_id) DO UPDATE SET
             operator_wallet=excluded.operator_wallet,
             role=excluded.role,
+            node_pubkey=COALESCE(excluded.node_pubkey, pop_nodes.node_pubkey),
             updated_at=CURRENT_TIMESTAMP
-    `, n.NodeID, n.OperatorWallet, n.Role)
+    `, n.NodeID, n.OperatorWallet, n.Role, n.NodePubKey)
 	return err
 }
 
@@ -257,14 +275,15 @@ func (db *DB) UpsertPoPNodeWithTxHash(ctx context.Context, n PoPNode, txHash str
     if txHash != "" {
         // Try new schema first (includes tx_hash).
         _, err := db.sql.ExecContext(ctx, `
-            INSERT INTO pop_nodes (node_id, operator_wallet, role, tx_hash, updated_at)
-            VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+            INSERT INTO pop_nodes (node_id, operator_wallet, role, node_pubkey, tx_hash, updated_at)
+            VALUES (?, ?, ?, NULLIF(?, ''), ?, CURRENT_TIMESTAMP)
             ON CONFLICT(node_id) DO UPDATE SET
                 operator_wallet=excluded.operator_wallet,
                 role=excluded.role,
+                node_pubkey=COALESCE(excluded.node_pubkey, pop_nodes.node_pubkey),
                 tx_hash=excluded.tx_hash,
                 updated_at=CURRENT_TIMESTAMP
-        `, n.NodeID, n.OperatorWallet, n.Role, txHash)
+        `, n.NodeID, n.OperatorWallet, n.Role, n.NodePubKey, txHash)
         if err == nil {
             return nil
         }
@@ -392,8 +411,8 @@ func (db *DB) GetPoPNode(ctx context.Context, nodeID string) (PoPNode, error) {
 	if db == nil || db.sql == nil {
 		return n, ErrNotFound
 	}
-	row := db.sql.QueryRowContext(ctx, `SELECT node_id, operator_wallet, role FROM pop_nodes WHERE node_id=?`, nodeID)
-	if err := row.Scan(&n.NodeID, &n.OperatorWallet, &n.Role); err != nil {
+	row := db.sql.QueryRowContext(ctx, `SELECT node_id, operator_wallet, role, COALESCE(node_pubkey, '') FROM pop_nodes WHERE node_id=?`, nodeID)
+	if err := row.Scan(&n.NodeID, &n.OperatorWallet, &n.Role, &n.NodePubKey); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return n, ErrNotFound
 		}
@@ -406,7 +425,7 @@ func (db *DB) ListPoPNodes(ctx context.Context) ([]PoPNode, error) {
 	if db == nil || db.sql == nil {
 		return nil, nil
 	}
-	rows, err := db.sql.QueryContext(ctx, `SELECT node_id, operator_wallet, role FROM pop_nodes`)
+	rows, err := db.sql.QueryContext(ctx, `SELECT node_id, operator_wallet, role, COALESCE(node_pubkey, '') FROM pop_nodes`)
 	if err != nil {
 		return nil, err
 	}
@@ -414,7 +433,7 @@ func (db *DB) ListPoPNodes(ctx context.Context) ([]PoPNode, error) {
 	out := []PoPNode{}
 	for rows.Next() {
 		var n PoPNode
-		if err := rows.Scan(&n.NodeID, &n.OperatorWallet, &n.Role); err != nil {
+		if err := rows.Scan(&n.NodeID, &n.OperatorWallet, &n.Role, &n.NodePubKey); err != nil {
 			continue
 		}
 		out = append(out, n)
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+)
+
+// BalanceDiff is the balance of one address/asset pair after a block.
+type BalanceDiff struct {
+	Address string  `json:"address"`
+	Asset   string  `json:"asset"`
+	Balance float64 `json:"balance"`
+}
+
+// InsertStateDiffs records the balances a block at height left behind and,
+// when epoch >= 0, the econ epoch it was sealed in. Re-recording a height
+// overwrites it.
+func (db *DB) InsertStateDiffs(ctx context.Context, height uint64, epoch int64, diffs []BalanceDiff) error {
+	if db == nil || db.sql == nil {
+		return nil
+	}
+	sqlTx, err := db.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+	for _, d := range diffs {
+		if _, err := sqlTx.ExecContext(ctx, `
+        INSERT OR REPLACE INTO account_balance_history (address, asset, block_height, balance)
+        VALUES (?, ?, ?, ?)`, d.Address, d.Asset, height, d.Balance); err != nil {
+			return err
+		}
+	}
+	if epoch >= 0 {
+		if _, err := sqlTx.ExecContext(ctx, `
+        INSERT OR REPLACE INTO chain_block_epochs (height, epoch) VALUES (?, ?)`, height, epoch); err != nil {
+			return err
+		}
+	}
+	return sqlTx.Commit()
+}
+
+// StateHistoryRange returns the lowest and highest heights with recorded
+// diffs. ok is false when nothing has been recorded yet.
+func (db *DB) StateHistoryRange(ctx context.Context) (lo, hi uint64, ok bool, err error) {
+	if db == nil || db.sql == nil {
+		return 0, 0, false, nil
+	}
+	var minH, maxH sql.NullInt64
+	if err := db.sql.QueryRowContext(ctx,
+		`SELECT MIN(block_height), MAX(block_height) FROM account_balance_history`).Scan(&minH, &maxH); err != nil {
+		return 0, 0, false, err
+	}
+	if !minH.Valid {
+		return 0, 0, false, nil
+	}
+	return uint64(minH.Int64), uint64(maxH.Int64), true, nil
+}
+
+// BalancesAt reconstructs balances as of height, sorted by address and
+// asset. An empty address returns every account.
+func (db *DB) BalancesAt(ctx context.Context, address string, height uint64) ([]BalanceDiff, error) {
+	if db == nil || db.sql == nil {
+		return nil, nil
+	}
+	// SQLite returns the bare balance column from the MAX(block_height) row.
+	query := `
+        SELECT address, asset, balance, MAX(block_height)
+        FROM account_balance_history
+        WHERE block_height <= ?`
+	args := []any{height}
+	if address != "" {
+		query += ` AND address = ?`
+		args = append(args, address)
+	}
+	query += ` GROUP BY address, asset`
+
+	rows, err := db.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []BalanceDiff
+	for rows.Next() {
+		var d BalanceDiff
+		var h int64
+		if err := rows.Scan(&d.Address, &d.Asset, &d.Balance, &h); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Address != out[j].Address {
+			return out[i].Address < out[j].Address
+		}
+		return out[i].Asset < out[j].Asset
+	})
+	return out, nil
+}
+
+// EpochEndHeight returns the last block sealed in epoch or earlier.
+func (db *DB) EpochEndHeight(ctx context.Context, epoch int64) (uint64, error) {
+	if db == nil || db.sql == nil {
+		return 0, ErrNotFound
+	}
+	var h sql.NullInt64
+	if err := db.sql.QueryRowContext(ctx,
+		`SELECT MAX(height) FROM chain_block_epochs WHERE epoch <= ?`, epoch).Scan(&h); err != nil {
+		return 0, err
+	}
+	if !h.Valid {
+		return 0, ErrNotFound
+	}
+	return uint64(h.Int64), nil
+}
@@ -1,8 +1,9 @@
 package store
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"errors"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -37,6 +38,9 @@ type DB struct {
 	sql *sql.DB
 }
 
+// ErrNoDB is returned by Ping when the node runs without a database.
+var ErrNoDB = errors.New("no database configured")
+
 // OpenSQLite opens (or creates) a SQLite database at the given path.
 func OpenSQLite(path string) (*DB, error) {
 	db, err := sql.Open("sqlite", path)
@@ -57,33 +61,41 @@ func (db *DB) Close() error {
 	return db.sql.Close()
 }
 
+// Ping checks that the database is reachable. A nil DB reports ErrNoDB.
+func (db *DB) Ping(ctx context.Context) error {
+	if db == nil || db.sql == nil {
+		return ErrNoDB
+	}
+	return db.sql.PingContext(ctx)
+}
+
 // InsertTreasurySnapshot writes a snapshot into reserve_snapshots.
 //
 // This is treated as a "structural" event in the hybrid model (M3),
 // so we perform it synchronously. If the schema is missing (e.g. the
 // user has not run database/schema.sql yet), we log the error and
 // continue without failing the HTTP request.
-func (db *DB) InsertTreasurySnapshot(ts TreasurySnapshot, at time.Time) {
+func (db *DB) InsertTreasurySnapshot(ctx context.Context, ts TreasurySnapshot, at time.Time) {
 	if db == nil || db.sql == nil {
 		return
 	}
 
-	tx, err := db.sql.Begin()
+	tx, err := db.sql.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("[store] begin tx for treasury snapshot failed: %v", err)
+		LogWriteError(ctx, "insert_treasury_snapshot", err)
 		return
 	}
 	defer func() {
 		if err != nil {
 			_ = tx.Rollback()
 		} else {
-			_ = tx.Commit()
+			LogWriteError(ctx, "insert_treasury_snapshot", tx.Commit())
 		}
 	}()
 
 	// Tier1 aggregate row
 	tier1Notional := ts.Tier1.CashUSD + ts.Tier1.BillsUSD
-	_, err = tx.Exec(
+	_, err = tx.ExecContext(ctx,
 		`INSERT INTO reserve_snapshots (snapshot_time, total_usd, tier_code, notional_usd, notes)
          VALUES (?, ?, ?, ?, ?)`,
 		at.UTC().Format(time.RFC3339),
@@ -93,13 +105,13 @@ func (db *DB) InsertTreasurySnapshot(ts TreasurySnapshot, at time.Time) {
 		"Tier1 high-liquidity synthetic basket",
 	)
 	if err != nil {
-		log.Printf("[store] insert Tier1 reserve_snapshot failed: %v", err)
+		LogWriteError(ctx, "insert_treasury_snapshot", err)
 		return
 	}
 
 	// Tier2 buckets
 	for _, b := range ts.Tier2 {
-		_, err = tx.Exec(
+		_, err = tx.ExecContext(ctx,
 			`INSERT INTO reserve_snapshots (snapshot_time, total_usd, tier_code, notional_usd, notes)
              VALUES (?, ?, ?, ?, ?)`,
 			at.UTC().Format(time.RFC3339),
@@ -109,7 +121,7 @@ func (db *DB) InsertTreasurySnapshot(ts TreasurySnapshot, at time.Time) {
 			b.Type,
 		)
 		if err != nil {
-			log.Printf("[store] insert Tier2 reserve_snapshot failed: %v", err)
+			LogWriteError(ctx, "insert_treasury_snapshot", err)
 			return
 		}
 	}
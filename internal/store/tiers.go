@@ -0,0 +1,27 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ActiveTierForWallet returns the membership tier ("core", "elite",
+// "executive", "express") of the user owning a wallet address, or "" when
+// the wallet has no active or grace-period tier.
+func (db *DB) ActiveTierForWallet(ctx context.Context, address string) (string, error) {
+	if db == nil || db.sql == nil {
+		return "", nil
+	}
+	var tier string
+	err := db.sql.QueryRowContext(ctx, `
+        SELECT t.tier
+        FROM user_tiers t JOIN wallets w ON w.user_id = t.user_id
+        WHERE w.wallet_public = ? COLLATE NOCASE AND t.status IN ('active', 'grace')
+        LIMIT 1
+    `, address).Scan(&tier)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return tier, err
+}
@@ -0,0 +1,320 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Roles an address can play in an indexed transaction.
+const (
+	TxRoleSender   = "sender"
+	TxRoleReceiver = "receiver"
+	TxRoleVault    = "vault"
+	TxRoleStaker   = "staker"
+	TxRoleOperator = "operator"
+)
+
+// TxAddressRef is one address a transaction touches and how.
+type TxAddressRef struct {
+	Address string `json:"address"`
+	Role    string `json:"role"`
+}
+
+// txAddressField names a JSON field of a tx body that holds an address.
+// Vault IDs are mapped to the ledger's "vault:<id>" pseudo-address.
+type txAddressField struct {
+	field string
+	role  string
+	vault bool
+}
+
+// txAddressFields lists, per tx type, the body fields the address index
+// extracts. Types not listed (EMPTY, GENESIS) touch no address.
+var txAddressFields = map[string][]txAddressField{
+	"TX_TRANSFER":     {{field: "from", role: TxRoleSender}, {field: "to", role: TxRoleReceiver}},
+	"TX_MINT":         {{field: "address", role: TxRoleReceiver}},
+	"TX_REDEEM":       {{field: "address", role: TxRoleSender}},
+	"TX_TIER_RENEW":   {{field: "sender", role: TxRoleSender}},
+	"TX_STAKE_LOCK":   {{field: "staker_wallet", role: TxRoleStaker}},
+	"TX_STAKE_UNLOCK": {{field: "staker_wallet", role: TxRoleStaker}},
+	"TX_VAULT_CREATE": {
+		{field: "owner", role: TxRoleSender},
+		{field: "vault_id", role: TxRoleVault, vault: true},
+	},
+	"TX_VAULT_DEPOSIT": {
+		{field: "from", role: TxRoleSender},
+		{field: "vault_id", role: TxRoleVault, vault: true},
+	},
+	"TX_VAULT_WITHDRAW": {
+		{field: "vault_id", role: TxRoleVault, vault: true},
+		{field: "to", role: TxRoleReceiver},
+	},
+	"TX_VAULT_TRANSFER": {
+		{field: "from_vault_id", role: TxRoleVault, vault: true},
+		{field: "to_vault_id", role: TxRoleVault, vault: true},
+	},
+	"TX_POP_REGISTER_NODE":   {{field: "operator_wallet", role: TxRoleOperator}},
+	"TX_POP_SET_CAPS":        {{field: "operator_wallet", role: TxRoleOperator}},
+	"TX_POP_WORK_CLAIM":      {{field: "operator_wallet", role: TxRoleOperator}},
+	"TX_EPOCH_PAYOUT_COMMIT": {{field: "author", role: TxRoleOperator}},
+}
+
+// TxAddresses returns the addresses a tx body touches, without duplicates.
+func TxAddresses(txType string, body []byte) []TxAddressRef {
+	fields := txAddressFields[txType]
+	if len(fields) == 0 {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil
+	}
+	out := make([]TxAddressRef, 0, len(fields))
+	seen := map[TxAddressRef]bool{}
+	for _, f := range fields {
+		v, _ := m[f.field].(string)
+		if v == "" {
+			continue
+		}
+		if f.vault {
+			v = "vault:" + v
+		}
+		ref := TxAddressRef{Address: v, Role: f.role}
+		if !seen[ref] {
+			seen[ref] = true
+			out = append(out, ref)
+		}
+	}
+	return out
+}
+
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// indexTx writes the typed projection (chain_tx_transfer/_vault/_tier)
+// and the address index rows of one chain_tx row. It is idempotent.
+func indexTx(ctx context.Context, ex sqlExecer, txID int64, height uint64, txType string, body []byte) error {
+	for _, ref := range TxAddresses(txType, body) {
+		if _, err := ex.ExecContext(ctx, `
+        INSERT OR IGNORE INTO chain_tx_addresses (tx_id, address, role, block_height, tx_type)
+        VALUES (?, ?, ?, ?, ?)`, txID, ref.Address, ref.Role, height, txType); err != nil {
+			return err
+		}
+	}
+
+	var tx struct {
+		From        string  `json:"from"`
+		To          string  `json:"to"`
+		Asset       string  `json:"asset"`
+		Amount      float64 `json:"amount"`
+		VaultID     string  `json:"vault_id"`
+		FromVaultID string  `json:"from_vault_id"`
+		ToVaultID   string  `json:"to_vault_id"`
+		Sender      string  `json:"sender"`
+		Tier        string  `json:"tier"`
+		Billing     string  `json:"billing_cycle"`
+		Payment     struct {
+			AmountGRC float64 `json:"amount_grc"`
+		} `json:"payment"`
+	}
+	if err := json.Unmarshal(body, &tx); err != nil {
+		return nil
+	}
+	var err error
+	switch txType {
+	case "TX_TRANSFER":
+		_, err = ex.ExecContext(ctx, `
+        INSERT OR REPLACE INTO chain_tx_transfer (tx_id, from_address, to_address, asset, amount)
+        VALUES (?, ?, ?, ?, ?)`, txID, tx.From, tx.To, tx.Asset, tx.Amount)
+	case "TX_VAULT_CREATE", "TX_VAULT_DEPOSIT", "TX_VAULT_WITHDRAW", "TX_VAULT_TRANSFER":
+		op := strings.TrimPrefix(txType, "TX_VAULT_")
+		vaultID, from, to := tx.VaultID, nullString(""), nullString("")
+		if txType == "TX_VAULT_TRANSFER" {
+			vaultID, from, to = tx.FromVaultID, nullString(tx.FromVaultID), nullString(tx.ToVaultID)
+		}
+		var asset sql.NullString
+		var amount sql.NullFloat64
+		if txType != "TX_VAULT_CREATE" {
+			asset = nullString(tx.Asset)
+			amount = sql.NullFloat64{Float64: tx.Amount, Valid: true}
+		}
+		_, err = ex.ExecContext(ctx, `
+        INSERT OR REPLACE INTO chain_tx_vault (tx_id, vault_id, op_type, from_vault, to_vault, asset, amount)
+        VALUES (?, ?, ?, ?, ?, ?, ?)`, txID, vaultID, op, from, to, asset, amount)
+	case "TX_TIER_RENEW":
+		periods := 1
+		if b := strings.ToLower(tx.Billing); b == "annual" || b == "yearly" {
+			periods = 12
+		}
+		_, err = ex.ExecContext(ctx, `
+        INSERT OR REPLACE INTO chain_tx_tier (tx_id, wallet_addr, tier_code, periods, amount_grc)
+        VALUES (?, ?, ?, ?, ?)`, txID, tx.Sender, tx.Tier, periods, tx.Payment.AmountGRC)
+	}
+	return err
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// IndexChainTx indexes chain_tx rows written before the address index
+// existed. Only address-bearing rows above the highest indexed tx are
+// scanned. It returns the number of rows indexed.
+func (db *DB) IndexChainTx(ctx context.Context) (int, error) {
+	if db == nil || db.sql == nil {
+		return 0, nil
+	}
+	var after int64
+	if err := db.sql.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(tx_id), 0) FROM chain_tx_addresses`).Scan(&after); err != nil {
+		return 0, err
+	}
+	args := []any{after}
+	for t := range txAddressFields {
+		args = append(args, t)
+	}
+	rows, err := db.sql.QueryContext(ctx, `
+        SELECT id, block_height, tx_type, body_json
+        FROM chain_tx
+        WHERE id > ? AND tx_type IN (`+placeholders(len(txAddressFields))+`)
+        ORDER BY id ASC`, args...)
+	if err != nil {
+		return 0, err
+	}
+	type pending struct {
+		id     int64
+		height uint64
+		txType string
+		body   string
+	}
+	var todo []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.height, &p.txType, &p.body); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		todo = append(todo, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(todo) == 0 {
+		return 0, nil
+	}
+
+	sqlTx, err := db.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlTx.Rollback()
+	for _, p := range todo {
+		if err := indexTx(ctx, sqlTx, p.id, p.height, p.txType, []byte(p.body)); err != nil {
+			return 0, err
+		}
+	}
+	return len(todo), sqlTx.Commit()
+}
+
+// HasAddressTxs reports whether any indexed tx touches address.
+func (db *DB) HasAddressTxs(ctx context.Context, address string) (bool, error) {
+	if db == nil || db.sql == nil {
+		return false, nil
+	}
+	var one int
+	err := db.sql.QueryRowContext(ctx,
+		`SELECT 1 FROM chain_tx_addresses WHERE address = ? LIMIT 1`, address).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// AddressTx is one entry of an address's transaction history.
+type AddressTx struct {
+	ID        int64           `json:"id"`
+	Height    uint64          `json:"height"`
+	TxHash    string          `json:"tx_hash"`
+	TxType    string          `json:"tx_type"`
+	Roles     []string        `json:"roles"`
+	Timestamp time.Time       `json:"timestamp"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// AddressTxQuery selects a page of an address's history, newest first.
+// Before is the cursor: the ID of the last entry of the previous page.
+type AddressTxQuery struct {
+	Address string
+	Types   []string
+	Roles   []string
+	Before  int64
+	Limit   int
+}
+
+// ListAddressTxs returns the transactions touching q.Address, newest first.
+func (db *DB) ListAddressTxs(ctx context.Context, q AddressTxQuery) ([]AddressTx, error) {
+	if db == nil || db.sql == nil {
+		return nil, nil
+	}
+	if q.Limit <= 0 || q.Limit > 500 {
+		q.Limit = 50
+	}
+	where := []string{"a.address = ?"}
+	args := []any{q.Address}
+	if q.Before > 0 {
+		where = append(where, "a.tx_id < ?")
+		args = append(args, q.Before)
+	}
+	if len(q.Types) > 0 {
+		where = append(where, "a.tx_type IN ("+placeholders(len(q.Types))+")")
+		for _, t := range q.Types {
+			args = append(args, t)
+		}
+	}
+	if len(q.Roles) > 0 {
+		where = append(where, "a.role IN ("+placeholders(len(q.Roles))+")")
+		for _, r := range q.Roles {
+			args = append(args, r)
+		}
+	}
+	args = append(args, q.Limit)
+
+	rows, err := db.sql.QueryContext(ctx, `
+        SELECT a.tx_id, t.block_height, t.tx_hash, t.tx_type, GROUP_CONCAT(a.role),
+               COALESCE(b.timestamp, ''), t.body_json
+        FROM chain_tx_addresses a
+        JOIN chain_tx t ON t.id = a.tx_id
+        LEFT JOIN chain_blocks b ON b.height = t.block_height
+        WHERE `+strings.Join(where, " AND ")+`
+        GROUP BY a.tx_id
+        ORDER BY a.tx_id DESC
+        LIMIT ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AddressTx
+	for rows.Next() {
+		var e AddressTx
+		var roles, ts, body string
+		if err := rows.Scan(&e.ID, &e.Height, &e.TxHash, &e.TxType, &roles, &ts, &body); err != nil {
+			return nil, err
+		}
+		e.Roles = strings.Split(roles, ",")
+		e.Timestamp, _ = time.Parse(time.RFC3339, ts)
+		e.Body = json.RawMessage(body)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
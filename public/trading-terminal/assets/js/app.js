@@ -446,7 +446,14 @@ function renderDashboard(state) {
             let detail = '';
             if (e.type === 'Mint' || e.type === 'Redeem') {
                 const p = e.payload;
-                detail = `${p.amount ?? ''} ${p.from ?? ''} -> ${p.to ?? ''} (${p.address ?? ''})`;
+                // v2 payloads carry both legs; v1 had one amount and from/to symbols.
+                if (p.asset !== undefined) {
+                    detail = e.type === 'Mint'
+                        ? `${p.deposit ?? ''} ${p.asset} -> ${p.minted ?? ''} ${p.token} (${p.address ?? ''})`
+                        : `${p.burned ?? ''} ${p.token} -> ${p.payout ?? ''} ${p.asset} (${p.address ?? ''})`;
+                } else {
+                    detail = `${p.amount ?? ''} ${p.from ?? ''} -> ${p.to ?? ''} (${p.address ?? ''})`;
+                }
             }
             return `
                 <tr>
@@ -115,6 +115,20 @@ function connectWebSocket() {
 
     ws.onopen = () => {
         Store.setConnectionStatus('connected');
+        // Only receive the event types this UI renders. Account events
+        // need an address the session owns; anonymous sockets get an error
+        // ack for that subscription and keep the public one.
+        ws.send(JSON.stringify({
+            op: "subscribe",
+            id: "terminal",
+            types: ["ValuationTick", "ProfileChange", "WindowUpdate"]
+        }));
+        ws.send(JSON.stringify({
+            op: "subscribe",
+            id: "account",
+            types: ["Mint", "Redeem", "Transfer"],
+            address: Store.state.address
+        }));
         fetchBalances();
         fetch('/api/profile/get')
             .then(r => r.json())
@@ -129,6 +143,11 @@ function connectWebSocket() {
     ws.onmessage = (msg) => {
         try {
             const ev = JSON.parse(msg.data);
+            if (ev.op) {
+                // subscribe/unsubscribe acknowledgements
+                if (ev.op === "error") console.warn("WS subscription error", ev.error);
+                return;
+            }
             if (ev.type === "ValuationTick") {
                 Store.updateFromValuationTick(ev.payload);
             } else if (ev.type === "Mint" || ev.type === "Redeem" || ev.type === "Transfer") {
@@ -457,7 +457,12 @@ function draw() {
         if (!msg || !msg.event) return;
         // expected shape:
         // msg.event = { type, candleIndex or ts, amount, nav, reserve_before, reserve_after, composition_delta }
-        addReserveEvent(msg.event);
+        // Live events carry no candleIndex; they belong on the current candle.
+        const evt = Object.assign({}, msg.event);
+        if (typeof evt.candleIndex !== 'number' && state.candles.length) {
+            evt.candleIndex = state.candles.length - 1;
+        }
+        addReserveEvent(evt);
         draw();
     });
 
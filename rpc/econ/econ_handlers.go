@@ -13,9 +13,28 @@ import (
 	intecon "reservechain/internal/econ"
 )
 
+// Roles required by the state-changing econ endpoints. They match the
+// node's RBAC role names.
+const (
+	RoleOperator = "operator"
+	RoleTreasury = "treasury"
+)
+
+// Guard wraps a handler so that only callers holding role reach it.
+type Guard func(role string, next http.HandlerFunc) http.HandlerFunc
+
 // AttachHTTP mounts the economics/analytics handlers under a given mux prefix.
-// In DevNet this is intentionally minimal; main wiring happens in http_rpc.go.
-func AttachHTTP(mux *http.ServeMux, prefix string) {
+// Read-only views are public; every POST endpoint is wrapped by guard with
+// the role it requires. With a nil guard the POST endpoints answer 404.
+func AttachHTTP(mux *http.ServeMux, prefix string, guard Guard) {
+	if guard == nil {
+		guard = func(string, http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				http.NotFound(w, r)
+			}
+		}
+	}
+
 	mux.HandleFunc(prefix+"/ping", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(map[string]any{
@@ -33,7 +52,6 @@ func AttachHTTP(mux *http.ServeMux, prefix string) {
 		_ = json.NewEncoder(w).Encode(snap)
 	})
 
-
 	// /econ/coverage returns a compact reserve coverage snapshot based
 	// on the current mainnet monetary state, the configured crypto-only
 	// reserve basket, and the internal price map. This is intended for
@@ -59,7 +77,6 @@ func AttachHTTP(mux *http.ServeMux, prefix string) {
 		_ = json.NewEncoder(w).Encode(snap)
 	})
 
-	
 	// /econ/grc-issuance returns the current DevNet GRC issuance
 	// recommendation derived from the treasury snapshot.
 	mux.HandleFunc(prefix+"/grc-issuance", func(w http.ResponseWriter, r *http.Request) {
@@ -68,17 +85,17 @@ func AttachHTTP(mux *http.ServeMux, prefix string) {
 		_ = json.NewEncoder(w).Encode(sig)
 	})
 
-// /econ/advance-epoch forces a DevNet epoch advance, settling both
-	// mint and redemption queues. This is intended for operator-only use.
-	mux.HandleFunc(prefix+"/advance-epoch", func(w http.ResponseWriter, r *http.Request) {
+	// /econ/advance-epoch forces a DevNet epoch advance, settling both
+	// mint and redemption queues. Operator only.
+	mux.HandleFunc(prefix+"/advance-epoch", guard(RoleOperator, func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
 		intecon.AdvanceDevnetEpoch()
 		w.WriteHeader(http.StatusNoContent)
-	})
-}
+	}))
+
 	// /econ/mainnet-state exposes the current mainnet monetary state.
 	mux.HandleFunc(prefix+"/mainnet-state", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -86,8 +103,6 @@ func AttachHTTP(mux *http.ServeMux, prefix string) {
 		_ = json.NewEncoder(w).Encode(state)
 	})
 
-	
-
 	// /econ/history returns a rolling window of recent epoch-level
 	// monetary history entries for analytics and dashboards. Results
 	// are returned in chronological order (oldest first).
@@ -104,10 +119,10 @@ func AttachHTTP(mux *http.ServeMux, prefix string) {
 		h := intecon.GetMainnetHistory(limit)
 		_ = json.NewEncoder(w).Encode(h)
 	})
-// /econ/settle-mainnet-epoch applies basic mainnet USDR policy and
-	// advances the mainnet monetary state by a single epoch. Intended
-	// for DevNet/Testnet operator use while wiring the full node.
-	mux.HandleFunc(prefix+"/settle-mainnet-epoch", func(w http.ResponseWriter, r *http.Request) {
+
+	// /econ/settle-mainnet-epoch applies basic mainnet USDR policy and
+	// advances the mainnet monetary state by a single epoch. Treasury only.
+	mux.HandleFunc(prefix+"/settle-mainnet-epoch", guard(RoleTreasury, func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
@@ -115,13 +130,12 @@ func AttachHTTP(mux *http.ServeMux, prefix string) {
 		state := intecon.SettleMainnetEpochBasic()
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(state)
-	})
-
+	}))
 
 	// /econ/mint-usdr registers a pending USDR mint on the mainnet
 	// monetary state. This does not immediately change supply; the
 	// mint will only be finalized when a mainnet epoch is settled.
-	mux.HandleFunc(prefix+"/mint-usdr", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(prefix+"/mint-usdr", guard(RoleTreasury, func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
@@ -153,12 +167,12 @@ func AttachHTTP(mux *http.ServeMux, prefix string) {
 
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(state)
-	})
+	}))
 
 	// /econ/redeem-usdr registers a pending USDR redemption on the
 	// mainnet monetary state. The redemption will be capped by policy
 	// and finalized at epoch settlement time.
-	mux.HandleFunc(prefix+"/redeem-usdr", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(prefix+"/redeem-usdr", guard(RoleTreasury, func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
@@ -188,13 +202,13 @@ func AttachHTTP(mux *http.ServeMux, prefix string) {
 
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(state)
-	})
+	}))
 
 	// /econ/issue-grc registers a pending GRC issuance entry on the
 	// mainnet monetary state. This does not immediately change the
 	// GRC supply; it will be applied at the next mainnet epoch
 	// settlement after policy evaluation.
-	mux.HandleFunc(prefix+"/issue-grc", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(prefix+"/issue-grc", guard(RoleTreasury, func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
@@ -224,12 +238,12 @@ func AttachHTTP(mux *http.ServeMux, prefix string) {
 
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(state)
-	})
+	}))
 
 	// /econ/burn-grc registers a pending GRC burn entry on the mainnet
 	// monetary state. The burn will be applied at the next epoch
 	// settlement.
-	mux.HandleFunc(prefix+"/burn-grc", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(prefix+"/burn-grc", guard(RoleTreasury, func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
@@ -259,5 +273,5 @@ func AttachHTTP(mux *http.ServeMux, prefix string) {
 
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(state)
-	})
+	}))
 }
@@ -51,7 +51,7 @@ if not exist "%ROOT%\config\devnet.yaml" (
 
 REM Start node (foreground). CTRL+C will be delivered to the Go process
 REM so it can perform a clean shutdown and flush state.
-"%ROOT%\runtime\go\bin\go.exe" run ./cmd/node
+"%ROOT%\runtime\go\bin\go.exe" run ./cmd/node run %*
 
 echo.
 echo -----------------------------------------------
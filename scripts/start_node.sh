@@ -35,4 +35,4 @@ if [ ! -f "$ROOT/config/devnet.yaml" ]; then
 fi
 
 cd "$ROOT"
-"$ROOT/runtime/go/bin/go" run ./cmd/node
+"$ROOT/runtime/go/bin/go" run ./cmd/node run "$@"